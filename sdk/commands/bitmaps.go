@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxBitOffset is the largest offset a bitmap can be addressed at, which limits bitmaps to 512MB.
+const maxBitOffset = 1<<32 - 1
+
+func parseBitOffset(s string) (uint64, error) {
+	offset, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || offset > maxBitOffset {
+		return 0, errors.New("bit offset is not an integer or out of range")
+	}
+	return offset, nil
+}
+
+func parseBit(s string) (bool, error) {
+	switch s {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, errors.New("bit is not an integer or out of range")
+	}
+}
+
+// parseBitRange parses the optional [start [end [BYTE | BIT]]] arguments shared by BITCOUNT and BITPOS.
+func parseBitRange(parts []string) (start, end int64, hasEnd, bitMode bool, err error) {
+	end = -1
+	if len(parts) > 3 {
+		return 0, 0, false, false, ErrWrongArgCount
+	}
+
+	if len(parts) > 0 {
+		if start, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return 0, 0, false, false, errors.New("invalid start")
+		}
+	}
+	if len(parts) > 1 {
+		if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return 0, 0, false, false, errors.New("invalid end")
+		}
+		hasEnd = true
+	}
+	if len(parts) > 2 {
+		switch strings.ToUpper(parts[2]) {
+		case "BYTE":
+		case "BIT":
+			bitMode = true
+		default:
+			return 0, 0, false, false, errors.New("syntax error")
+		}
+	}
+	return start, end, hasEnd, bitMode, nil
+}
+
+type SetBitCommand struct {
+	Key    string
+	Offset uint64
+	Value  bool
+	LineMessage
+}
+
+// NewSetBitCommand parses SETBIT key offset value.
+func NewSetBitCommand(line LineMessage) (*SetBitCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	offset, err := parseBitOffset(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseBit(parts[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetBitCommand{
+		Key:         parts[1],
+		Offset:      offset,
+		Value:       value,
+		LineMessage: line,
+	}, nil
+}
+
+type GetBitCommand struct {
+	Key    string
+	Offset uint64
+	LineMessage
+}
+
+// NewGetBitCommand parses GETBIT key offset.
+func NewGetBitCommand(line LineMessage) (*GetBitCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	offset, err := parseBitOffset(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetBitCommand{
+		Key:         parts[1],
+		Offset:      offset,
+		LineMessage: line,
+	}, nil
+}
+
+type BitCountCommand struct {
+	Key     string
+	Start   int64
+	End     int64
+	BitMode bool // Start and End are bit offsets instead of byte offsets
+	LineMessage
+}
+
+// NewBitCountCommand parses BITCOUNT key [start end [BYTE | BIT]].
+func NewBitCountCommand(line LineMessage) (*BitCountCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 && len(parts) != 4 && len(parts) != 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	start, end, _, bitMode, err := parseBitRange(parts[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitCountCommand{
+		Key:         parts[1],
+		Start:       start,
+		End:         end,
+		BitMode:     bitMode,
+		LineMessage: line,
+	}, nil
+}
+
+type BitPosCommand struct {
+	Key     string
+	Value   bool
+	Start   int64
+	End     int64
+	HasEnd  bool
+	BitMode bool // Start and End are bit offsets instead of byte offsets
+	LineMessage
+}
+
+// NewBitPosCommand parses BITPOS key bit [start [end [BYTE | BIT]]].
+func NewBitPosCommand(line LineMessage) (*BitPosCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 || len(parts) > 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	value, err := parseBit(parts[2])
+	if err != nil {
+		return nil, errors.New("the bit argument must be 1 or 0")
+	}
+
+	start, end, hasEnd, bitMode, err := parseBitRange(parts[3:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitPosCommand{
+		Key:         parts[1],
+		Value:       value,
+		Start:       start,
+		End:         end,
+		HasEnd:      hasEnd,
+		BitMode:     bitMode,
+		LineMessage: line,
+	}, nil
+}
+
+type BitOpCommand struct {
+	Operation  string // One of AND, OR, XOR or NOT
+	DestKey    string
+	SourceKeys []string
+	LineMessage
+}
+
+// NewBitOpCommand parses BITOP <AND | OR | XOR | NOT> destkey key [key ...].
+func NewBitOpCommand(line LineMessage) (*BitOpCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	operation := strings.ToUpper(parts[1])
+	switch operation {
+	case "AND", "OR", "XOR":
+	case "NOT":
+		if len(parts) != 4 {
+			return nil, errors.New("BITOP NOT must be called with a single source key")
+		}
+	default:
+		return nil, errors.New("syntax error")
+	}
+
+	return &BitOpCommand{
+		Operation:   operation,
+		DestKey:     parts[2],
+		SourceKeys:  parts[3:],
+		LineMessage: line,
+	}, nil
+}
+
+// BitFieldOp is a single GET, SET or INCRBY sub-operation of a BITFIELD command.
+type BitFieldOp struct {
+	Kind     string // One of GET, SET or INCRBY
+	Signed   bool
+	Bits     uint
+	Offset   uint64 // Offset in bits
+	Value    int64  // Value to SET, or increment to INCRBY
+	Overflow string // One of WRAP, SAT or FAIL, as set by the last OVERFLOW before this operation
+}
+
+type BitFieldCommand struct {
+	Key string
+	Ops []BitFieldOp
+	LineMessage
+}
+
+// NewBitFieldCommand parses
+// BITFIELD key [GET encoding offset | [OVERFLOW <WRAP | SAT | FAIL>] <SET encoding offset value | INCRBY encoding offset increment> ...].
+//
+// Encodings are i1 to i64 and u1 to u63. Offsets are in bits, or in multiples of the width of the encoding if prefixed with #.
+func NewBitFieldCommand(line LineMessage) (*BitFieldCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &BitFieldCommand{Key: parts[1], LineMessage: line}
+	overflow := "WRAP"
+
+	for i := 2; i < len(parts); i++ {
+		kind := strings.ToUpper(parts[i])
+		args := parts[i+1:]
+
+		switch kind {
+		case "OVERFLOW":
+			if len(args) < 1 {
+				return nil, errors.New("syntax error")
+			}
+			overflow = strings.ToUpper(args[0])
+			if overflow != "WRAP" && overflow != "SAT" && overflow != "FAIL" {
+				return nil, errors.New("invalid OVERFLOW type specified")
+			}
+			i++
+			continue
+		case "GET":
+			if len(args) < 2 {
+				return nil, errors.New("syntax error")
+			}
+		case "SET", "INCRBY":
+			if len(args) < 3 {
+				return nil, errors.New("syntax error")
+			}
+		default:
+			return nil, errors.New("syntax error")
+		}
+
+		op := BitFieldOp{Kind: kind, Overflow: overflow}
+
+		var err error
+		if op.Signed, op.Bits, err = parseBitFieldType(args[0]); err != nil {
+			return nil, err
+		}
+		if op.Offset, err = parseBitFieldOffset(args[1], op.Bits); err != nil {
+			return nil, err
+		}
+		i += 2
+
+		if kind != "GET" {
+			if op.Value, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+				return nil, errors.New("value is not an integer or out of range")
+			}
+			i++
+		}
+		cmd.Ops = append(cmd.Ops, op)
+	}
+	return cmd, nil
+}
+
+func parseBitFieldType(s string) (bool, uint, error) {
+	errInvalid := errors.New("invalid bitfield type. use something like i16 u8. note that u64 is not supported but i64 is")
+	if len(s) < 2 {
+		return false, 0, errInvalid
+	}
+
+	var signed bool
+	switch s[0] {
+	case 'i', 'I':
+		signed = true
+	case 'u', 'U':
+	default:
+		return false, 0, errInvalid
+	}
+
+	bits, err := strconv.ParseUint(s[1:], 10, 8)
+	if err != nil || bits < 1 || (signed && bits > 64) || (!signed && bits > 63) {
+		return false, 0, errInvalid
+	}
+	return signed, uint(bits), nil
+}
+
+func parseBitFieldOffset(s string, bits uint) (uint64, error) {
+	errInvalid := errors.New("bit offset is not an integer or out of range")
+
+	multiple := strings.HasPrefix(s, "#")
+	offset, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 10, 64)
+	if err != nil {
+		return 0, errInvalid
+	}
+	if multiple {
+		if offset > maxBitOffset/uint64(bits) {
+			return 0, errInvalid
+		}
+		offset *= uint64(bits)
+	}
+	if offset+uint64(bits)-1 > maxBitOffset {
+		return 0, errInvalid
+	}
+	return offset, nil
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxBloomFilterBits bounds the number of bits a single Bloom filter may allocate.
+const maxBloomFilterBits = 1 << 30
+
+// maxBloomExpansion bounds how many times larger each sub-filter of a scaling filter can be than the last.
+const maxBloomExpansion = 32768
+
+type BFReserveCommand struct {
+	Key        string
+	ErrorRate  float64
+	Capacity   uint
+	Expansion  uint // Zero uses the server default
+	NonScaling bool
+	LineMessage
+}
+
+// NewBFReserveCommand parses BF.RESERVE key error_rate capacity [EXPANSION expansion | NONSCALING].
+func NewBFReserveCommand(line LineMessage) (*BFReserveCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	errorRate, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || errorRate <= 0 || errorRate >= 1 {
+		return nil, errors.New("invalid error rate")
+	}
+
+	capacity, err := strconv.ParseUint(parts[3], 10, 0)
+	if err != nil || capacity == 0 {
+		return nil, errors.New("invalid capacity")
+	}
+	// The filter has capacity*ln(1/error_rate)/ln(2)^2 bits.
+	if float64(capacity)*math.Log(1/errorRate)/(math.Ln2*math.Ln2) > maxBloomFilterBits {
+		return nil, errors.New("filter is too large")
+	}
+
+	cmd := &BFReserveCommand{
+		Key:         parts[1],
+		ErrorRate:   errorRate,
+		Capacity:    uint(capacity),
+		LineMessage: line,
+	}
+	switch options := parts[4:]; {
+	case len(options) == 0:
+	case len(options) == 1 && strings.ToUpper(options[0]) == "NONSCALING":
+		cmd.NonScaling = true
+	case len(options) == 2 && strings.ToUpper(options[0]) == "EXPANSION":
+		expansion, err := strconv.ParseUint(options[1], 10, 0)
+		if err != nil || expansion == 0 || expansion > maxBloomExpansion {
+			return nil, errors.New("invalid expansion")
+		}
+		cmd.Expansion = uint(expansion)
+	default:
+		return nil, errors.New("syntax error")
+	}
+	return cmd, nil
+}
+
+// BFAddCommand serves both BF.ADD and BF.MADD.
+type BFAddCommand struct {
+	Key   string
+	Items []string
+	LineMessage
+}
+
+func NewBFAddCommand(line LineMessage) (*BFAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &BFAddCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+func NewBFMAddCommand(line LineMessage) (*BFAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &BFAddCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+// BFExistsCommand serves both BF.EXISTS and BF.MEXISTS.
+type BFExistsCommand struct {
+	Key   string
+	Items []string
+	LineMessage
+}
+
+func NewBFExistsCommand(line LineMessage) (*BFExistsCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &BFExistsCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+func NewBFMExistsCommand(line LineMessage) (*BFExistsCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &BFExistsCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+type BFCardCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewBFCardCommand(line LineMessage) (*BFCardCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &BFCardCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+type BFInfoCommand struct {
+	Key   string
+	Field string // One of CAPACITY, SIZE, HASHES, FILTERS, ITEMS or EXPANSION, or empty for all of them
+	LineMessage
+}
+
+// NewBFInfoCommand parses BF.INFO key [CAPACITY | SIZE | HASHES | FILTERS | ITEMS | EXPANSION].
+func NewBFInfoCommand(line LineMessage) (*BFInfoCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &BFInfoCommand{Key: parts[1], LineMessage: line}
+	if len(parts) == 3 {
+		cmd.Field = strings.ToUpper(parts[2])
+		switch cmd.Field {
+		case "CAPACITY", "SIZE", "HASHES", "FILTERS", "ITEMS", "EXPANSION":
+		default:
+			return nil, errors.New("invalid info field")
+		}
+	}
+	return cmd, nil
+}
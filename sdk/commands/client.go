@@ -3,8 +3,10 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type LineMessage struct {
@@ -36,8 +38,12 @@ type LPushCommand struct {
 	LineMessage
 }
 
+// NewLPushCommand parses LPUSH key element [element ...] and LPUSHX key element [element ...].
 func NewLPushCommand(line LineMessage) (*LPushCommand, error) {
 	parts := strings.Split(line.Line, " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
 	return &LPushCommand{
 		Key:         parts[1],
 		Values:      parts[2:],
@@ -51,8 +57,12 @@ type RPushCommand struct {
 	LineMessage
 }
 
+// NewRPushCommand parses RPUSH key element [element ...] and RPUSHX key element [element ...].
 func NewRPushCommand(line LineMessage) (*RPushCommand, error) {
 	parts := strings.Split(line.Line, " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
 	return &RPushCommand{
 		Key:         parts[1],
 		Values:      parts[2:],
@@ -124,13 +134,16 @@ type LRangeCommand struct {
 	LineMessage
 }
 
+// NewLRangeCommand parses LRANGE key [start [end]], where negative indices count back from the end.
 func NewLRangeCommand(line LineMessage) (*LRangeCommand, error) {
 	parts := strings.Split(line.String(), " ")
-	var err error
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, ErrWrongArgCount
+	}
 
-	key := parts[1]
+	var err error
 	startIdx := 0
-	if len(parts) == 3 {
+	if len(parts) >= 3 {
 		startIdx, err = strconv.Atoi(parts[2])
 		if err != nil {
 			return nil, errors.New("invalid start index")
@@ -144,7 +157,255 @@ func NewLRangeCommand(line LineMessage) (*LRangeCommand, error) {
 			return nil, errors.New("invalid end index")
 		}
 	}
-	return &LRangeCommand{Key: key, Start: startIdx, End: endIdx, LineMessage: line}, nil
+	return &LRangeCommand{Key: parts[1], Start: startIdx, End: endIdx, LineMessage: line}, nil
+}
+
+type LIndexCommand struct {
+	Key   string
+	Index int
+	LineMessage
+}
+
+// NewLIndexCommand parses LINDEX key index, where a negative index counts back from the end.
+func NewLIndexCommand(line LineMessage) (*LIndexCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	index, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid index")
+	}
+	return &LIndexCommand{Key: parts[1], Index: index, LineMessage: line}, nil
+}
+
+type LSetCommand struct {
+	Key   string
+	Index int
+	Value string
+	LineMessage
+}
+
+// NewLSetCommand parses LSET key index element, where a negative index counts back from the end.
+func NewLSetCommand(line LineMessage) (*LSetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	index, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid index")
+	}
+	return &LSetCommand{Key: parts[1], Index: index, Value: parts[3], LineMessage: line}, nil
+}
+
+type LInsertCommand struct {
+	Key    string
+	Before bool
+	Pivot  string
+	Value  string
+	LineMessage
+}
+
+// NewLInsertCommand parses LINSERT key BEFORE|AFTER pivot element.
+func NewLInsertCommand(line LineMessage) (*LInsertCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &LInsertCommand{Key: parts[1], Pivot: parts[3], Value: parts[4], LineMessage: line}
+	switch strings.ToUpper(parts[2]) {
+	case "BEFORE":
+		cmd.Before = true
+	case "AFTER":
+	default:
+		return nil, errors.New("syntax error")
+	}
+	return cmd, nil
+}
+
+type LRemCommand struct {
+	Key   string
+	Count int // Positive removes from the head, negative from the tail and zero removes every match
+	Value string
+	LineMessage
+}
+
+// NewLRemCommand parses LREM key count element.
+func NewLRemCommand(line LineMessage) (*LRemCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	count, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid count")
+	}
+	return &LRemCommand{Key: parts[1], Count: count, Value: parts[3], LineMessage: line}, nil
+}
+
+type LTrimCommand struct {
+	Key   string
+	Start int
+	End   int
+	LineMessage
+}
+
+// NewLTrimCommand parses LTRIM key start end, where negative indices count back from the end.
+func NewLTrimCommand(line LineMessage) (*LTrimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	start, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid start index")
+	}
+	end, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, errors.New("invalid end index")
+	}
+	return &LTrimCommand{Key: parts[1], Start: start, End: end, LineMessage: line}, nil
+}
+
+type LPosCommand struct {
+	Key       string
+	Value     string
+	Rank      int
+	Count     int  // Zero returns every match
+	WithCount bool // Whether a count was given, which makes the response a list
+	MaxLen    int  // Zero compares every element
+	LineMessage
+}
+
+// NewLPosCommand parses LPOS key element [RANK rank] [COUNT num] [MAXLEN len].
+func NewLPosCommand(line LineMessage) (*LPosCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 || len(parts)%2 != 1 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &LPosCommand{Key: parts[1], Value: parts[2], Rank: 1, LineMessage: line}
+	for i := 3; i < len(parts); i += 2 {
+		value, err := strconv.Atoi(parts[i+1])
+		if err != nil {
+			return nil, errors.New("invalid " + strings.ToLower(parts[i]))
+		}
+
+		switch strings.ToUpper(parts[i]) {
+		case "RANK":
+			if value == 0 || value == math.MinInt {
+				return nil, errors.New("rank can't be zero or out of range")
+			}
+			cmd.Rank = value
+		case "COUNT":
+			if value < 0 {
+				return nil, errors.New("count can't be negative")
+			}
+			cmd.Count, cmd.WithCount = value, true
+		case "MAXLEN":
+			if value < 0 {
+				return nil, errors.New("maxlen can't be negative")
+			}
+			cmd.MaxLen = value
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
+
+// LMoveCommand serves LMOVE, BLMOVE and RPOPLPUSH, which pop an element from one list and push it onto another.
+type LMoveCommand struct {
+	Source      string
+	Destination string
+	FromLeft    bool          // Whether to pop from the head of the source rather than its tail
+	ToLeft      bool          // Whether to push onto the head of the destination rather than its tail
+	Timeout     time.Duration // How long BLMOVE waits for the source to have an element, where zero waits forever
+	LineMessage
+}
+
+// NewLMoveCommand parses LMOVE source destination LEFT|RIGHT LEFT|RIGHT, BLMOVE with a timeout in seconds after
+// that, and RPOPLPUSH source destination, which is LMOVE source destination RIGHT LEFT.
+func NewLMoveCommand(line LineMessage) (*LMoveCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if line.MessageType == RPopLPush {
+		if len(parts) != 3 {
+			return nil, ErrWrongArgCount
+		}
+		return &LMoveCommand{Source: parts[1], Destination: parts[2], ToLeft: true, LineMessage: line}, nil
+	}
+
+	if (line.MessageType == BLMove && len(parts) != 6) || (line.MessageType == LMove && len(parts) != 5) {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &LMoveCommand{Source: parts[1], Destination: parts[2], LineMessage: line}
+	var err error
+	if line.MessageType == BLMove {
+		if cmd.Timeout, err = parseBlockTimeout(parts[5]); err != nil {
+			return nil, err
+		}
+	}
+	if cmd.FromLeft, err = parseListEnd(parts[3]); err != nil {
+		return nil, err
+	}
+	if cmd.ToLeft, err = parseListEnd(parts[4]); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// BPopCommand serves BLPOP and BRPOP, which pop from the first of several lists to have an element.
+type BPopCommand struct {
+	Keys    []string
+	Timeout time.Duration // How long to wait for an element, where zero waits forever
+	LineMessage
+}
+
+// NewBPopCommand parses BLPOP key [key ...] timeout and BRPOP key [key ...] timeout, with the timeout in seconds.
+func NewBPopCommand(line LineMessage) (*BPopCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	timeout, err := parseBlockTimeout(parts[len(parts)-1])
+	if err != nil {
+		return nil, err
+	}
+	return &BPopCommand{Keys: parts[1 : len(parts)-1], Timeout: timeout, LineMessage: line}, nil
+}
+
+// parseBlockTimeout parses the timeout of a blocking command, which is a non-negative number of seconds.
+func parseBlockTimeout(s string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil || seconds < 0 || math.IsNaN(seconds) || seconds > maxBlockTimeout.Seconds() {
+		return 0, errors.New("timeout is not a non-negative number of seconds or out of range")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// IsBlocking reports whether commands of a type can wait for their keys to change before they respond.
+func IsBlocking(t MessageType) bool {
+	return t == BLPop || t == BRPop || t == BLMove
+}
+
+// parseListEnd parses LEFT or RIGHT, returning whether it is LEFT.
+func parseListEnd(s string) (bool, error) {
+	switch strings.ToUpper(s) {
+	case "LEFT":
+		return true, nil
+	case "RIGHT":
+		return false, nil
+	default:
+		return false, errors.New("syntax error")
+	}
 }
 
 type SAddCommand struct {
@@ -153,8 +414,12 @@ type SAddCommand struct {
 	LineMessage
 }
 
+// NewSAddCommand parses SADD key member [member ...].
 func NewSAddCommand(line LineMessage) (*SAddCommand, error) {
-	parts := strings.Split(line.String(), ":")
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
 	return &SAddCommand{
 		Key:         parts[1],
 		Values:      parts[2:],
@@ -248,6 +513,136 @@ func NewSMembersCommand(line LineMessage) (*SMembersCommand, error) {
 	}, nil
 }
 
+// SValuesCommand serves SREM and SMISMEMBER, which both take a list of members.
+type SValuesCommand struct {
+	Key    string
+	Values []string
+	LineMessage
+}
+
+func NewSValuesCommand(line LineMessage) (*SValuesCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &SValuesCommand{
+		Key:         parts[1],
+		Values:      parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+// maxBlockTimeout bounds the timeout of a blocking command, so that it can be held in a time.Duration.
+const maxBlockTimeout = 365 * 24 * time.Hour
+
+// maxSetCount bounds the count of SPOP and SRANDMEMBER, as SRANDMEMBER can pick more members than a set has.
+const maxSetCount = 1 << 20
+
+// SPopCommand serves SPOP and SRANDMEMBER, which pick random members of a set.
+type SPopCommand struct {
+	Key       string
+	Count     int
+	WithCount bool // Whether a count was given, which makes the response a list even for a single member
+	LineMessage
+}
+
+// NewSPopCommand parses SPOP key [count] and SRANDMEMBER key [count]. SRANDMEMBER can take a negative
+// count, to pick members which can repeat.
+func NewSPopCommand(line LineMessage) (*SPopCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &SPopCommand{Key: parts[1], Count: 1, LineMessage: line}
+	if len(parts) == 3 {
+		count, err := strconv.Atoi(parts[2])
+		if err != nil || count > maxSetCount || count < -maxSetCount || (count < 0 && line.MessageType == SPop) {
+			return nil, errors.New("count is not an integer or out of range")
+		}
+		cmd.Count, cmd.WithCount = count, true
+	}
+	return cmd, nil
+}
+
+type SMoveCommand struct {
+	Source      string
+	Destination string
+	Member      string
+	LineMessage
+}
+
+// NewSMoveCommand parses SMOVE source destination member.
+func NewSMoveCommand(line LineMessage) (*SMoveCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+	return &SMoveCommand{
+		Source:      parts[1],
+		Destination: parts[2],
+		Member:      parts[3],
+		LineMessage: line,
+	}, nil
+}
+
+type SInterCardCommand struct {
+	Keys  []string
+	Limit int // Zero counts every member
+	LineMessage
+}
+
+// NewSInterCardCommand parses SINTERCARD numkeys key [key ...] [LIMIT limit].
+func NewSInterCardCommand(line LineMessage) (*SInterCardCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	numKeys, err := strconv.Atoi(parts[1])
+	if err != nil || numKeys < 1 || numKeys > len(parts)-2 {
+		return nil, errors.New("numkeys is not a positive integer or out of range")
+	}
+
+	cmd := &SInterCardCommand{Keys: parts[2 : 2+numKeys], LineMessage: line}
+	rest := parts[2+numKeys:]
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.ToUpper(rest[0]) == "LIMIT":
+		limit, err := strconv.Atoi(rest[1])
+		if err != nil || limit < 0 {
+			return nil, errors.New("limit is not a non-negative integer")
+		}
+		cmd.Limit = limit
+	default:
+		return nil, errors.New("syntax error")
+	}
+	return cmd, nil
+}
+
+// SStoreCommand serves SINTERSTORE, SUNIONSTORE and SDIFFSTORE, which store what SINTER, SUNION and
+// SDIFF respond with.
+type SStoreCommand struct {
+	Destination string
+	Key         string
+	OtherKeys   []string
+	LineMessage
+}
+
+// NewSStoreCommand parses SINTERSTORE destination key [key ...], and likewise for SUNIONSTORE and SDIFFSTORE.
+func NewSStoreCommand(line LineMessage) (*SStoreCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &SStoreCommand{
+		Destination: parts[1],
+		Key:         parts[2],
+		OtherKeys:   parts[3:],
+		LineMessage: line,
+	}, nil
+}
+
 type PFAddCommand struct {
 	Key    string
 	Values []string
@@ -264,14 +659,19 @@ func NewPFAddCommand(line LineMessage) (*PFAddCommand, error) {
 }
 
 type PFCountCommand struct {
-	Key string
+	Key  string
+	Keys []string // All keys whose union is counted, starting with Key
 	LineMessage
 }
 
 func NewPFCountCommand(line LineMessage) (*PFCountCommand, error) {
 	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
 	return &PFCountCommand{
 		Key:         parts[1],
+		Keys:        parts[1:],
 		LineMessage: line,
 	}, nil
 }
@@ -284,6 +684,9 @@ type PFMergeCommand struct {
 
 func NewPFMergeCommand(line LineMessage) (*PFMergeCommand, error) {
 	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
 	return &PFMergeCommand{
 		DestKey:     parts[1],
 		SourceKeys:  parts[2:],
@@ -304,19 +707,58 @@ func NewGetCommand(line LineMessage) (*GetCommand, error) {
 	}, nil
 }
 
+// SetCommand serves SET, SETNX and GETSET, which all set a key to a string, but under different conditions
+// and with different responses.
 type SetCommand struct {
 	Key   string
 	Value string
+	NX    bool // Only set the key if it doesn't exist
+	XX    bool // Only set the key if it exists
+	Get   bool // Respond with the value the key had before
 	LineMessage
 }
 
+// NewSetCommand parses SET key value [NX|XX] [GET], SETNX key value and GETSET key value.
+// The value is the rest of the line, so it may contain spaces, up to the options at its end.
 func NewSetCommand(line LineMessage) (*SetCommand, error) {
-	parts := strings.Split(line.String(), " ")
-	return &SetCommand{
-		LineMessage: line,
-		Key:         parts[1],
-		Value:       parts[2],
-	}, nil
+	parts := strings.SplitN(line.String(), " ", 3)
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &SetCommand{Key: parts[1], Value: parts[2], LineMessage: line}
+	switch line.MessageType {
+	case SetNX:
+		cmd.NX = true
+		return cmd, nil
+	case GetSet:
+		cmd.Get = true
+		return cmd, nil
+	}
+
+options:
+	for {
+		i := strings.LastIndexByte(cmd.Value, ' ')
+		if i == -1 {
+			break
+		}
+		switch strings.ToUpper(cmd.Value[i+1:]) {
+		case "NX":
+			cmd.NX = true
+		case "XX":
+			cmd.XX = true
+		case "GET":
+			cmd.Get = true
+		default:
+			break options
+		}
+		cmd.Value = cmd.Value[:i]
+	}
+
+	if cmd.NX && cmd.XX {
+		return nil, errors.New("NX and XX are mutually exclusive")
+	}
+	return cmd, nil
 }
 
 type AuthChallengeResponseCommand struct {
@@ -13,6 +13,25 @@ const (
 	Set  MessageType = "SET"
 	Del  MessageType = "DEL"
 
+	SetNX    MessageType = "SETNX"
+	GetSet   MessageType = "GETSET"
+	GetDel   MessageType = "GETDEL"
+	MSet     MessageType = "MSET"
+	MSetNX   MessageType = "MSETNX"
+	MGet     MessageType = "MGET"
+	Append   MessageType = "APPEND"
+	GetRange MessageType = "GETRANGE"
+	SetRange MessageType = "SETRANGE"
+	StrLen   MessageType = "STRLEN"
+
+	Incr        MessageType = "INCR"
+	Decr        MessageType = "DECR"
+	IncrBy      MessageType = "INCRBY"
+	DecrBy      MessageType = "DECRBY"
+	IncrByFloat MessageType = "INCRBYFLOAT"
+
+	Throttle MessageType = "THROTTLE" // Rate limits a request against a key, responding whether it is allowed and how long to wait.
+
 	LPush  MessageType = "LPUSH"
 	RPush  MessageType = "RPUSH"
 	LPop   MessageType = "LPOP"
@@ -20,6 +39,20 @@ const (
 	LRange MessageType = "LRANGE"
 	LLen   MessageType = "LLEN"
 
+	LPushX    MessageType = "LPUSHX"
+	RPushX    MessageType = "RPUSHX"
+	LIndex    MessageType = "LINDEX"
+	LSet      MessageType = "LSET"
+	LInsert   MessageType = "LINSERT"
+	LRem      MessageType = "LREM"
+	LTrim     MessageType = "LTRIM"
+	LPos      MessageType = "LPOS"
+	LMove     MessageType = "LMOVE"
+	RPopLPush MessageType = "RPOPLPUSH"
+	BLPop     MessageType = "BLPOP"
+	BRPop     MessageType = "BRPOP"
+	BLMove    MessageType = "BLMOVE"
+
 	SAdd      MessageType = "SADD"
 	SCard     MessageType = "SCARD"
 	SDiff     MessageType = "SDIFF"
@@ -28,12 +61,131 @@ const (
 	SMembers  MessageType = "SMEMBERS"
 	SUnion    MessageType = "SUNION"
 
-	BFAdd     MessageType = "BF.ADD"     // Adds multiple items to a Bloom Filter. Creates a filter if it doesn't already exist.
+	SRem        MessageType = "SREM"
+	SPop        MessageType = "SPOP"
+	SRandMember MessageType = "SRANDMEMBER"
+	SMove       MessageType = "SMOVE"
+	SMIsMember  MessageType = "SMISMEMBER"
+	SInterCard  MessageType = "SINTERCARD"
+	SInterStore MessageType = "SINTERSTORE"
+	SUnionStore MessageType = "SUNIONSTORE"
+	SDiffStore  MessageType = "SDIFFSTORE"
+
+	HDel         MessageType = "HDEL"
+	HExists      MessageType = "HEXISTS"
+	HGet         MessageType = "HGET"
+	HGetAll      MessageType = "HGETALL"
+	HIncrBy      MessageType = "HINCRBY"
+	HIncrByFloat MessageType = "HINCRBYFLOAT"
+	HKeys        MessageType = "HKEYS"
+	HLen         MessageType = "HLEN"
+	HMGet        MessageType = "HMGET"
+	HScan        MessageType = "HSCAN"
+	HSet         MessageType = "HSET"
+	HSetNX       MessageType = "HSETNX"
+	HVals        MessageType = "HVALS"
+
+	ZAdd             MessageType = "ZADD"
+	ZCard            MessageType = "ZCARD"
+	ZIncrBy          MessageType = "ZINCRBY"
+	ZRange           MessageType = "ZRANGE"
+	ZRangeByScore    MessageType = "ZRANGEBYSCORE"
+	ZRank            MessageType = "ZRANK"
+	ZRem             MessageType = "ZREM"
+	ZRevRange        MessageType = "ZREVRANGE"
+	ZRevRangeByScore MessageType = "ZREVRANGEBYSCORE"
+	ZRevRank         MessageType = "ZREVRANK"
+	ZScore           MessageType = "ZSCORE"
+
+	GeoAdd    MessageType = "GEOADD"
+	GeoDist   MessageType = "GEODIST"
+	GeoHash   MessageType = "GEOHASH"
+	GeoPos    MessageType = "GEOPOS"
+	GeoSearch MessageType = "GEOSEARCH"
+
+	SetBit   MessageType = "SETBIT"
+	GetBit   MessageType = "GETBIT"
+	BitCount MessageType = "BITCOUNT"
+	BitPos   MessageType = "BITPOS"
+	BitOp    MessageType = "BITOP"
+	BitField MessageType = "BITFIELD"
+
+	BFAdd     MessageType = "BF.ADD"     // Adds an item to a Bloom Filter. Creates a filter if it doesn't already exist.
 	BFCard    MessageType = "BF.CARD"    // Returns the cardinality of a Bloom Filter.
 	BFExists  MessageType = "BF.EXISTS"  // Checks whether an item exists in a Bloom Filter.
 	BFInfo    MessageType = "BF.INFO"    // Returns information about a Bloom Filter.
+	BFMAdd    MessageType = "BF.MADD"    // Adds multiple items to a Bloom Filter. Creates a filter if it doesn't already exist.
+	BFMExists MessageType = "BF.MEXISTS" // Checks whether multiple items exist in a Bloom Filter.
 	BFReserve MessageType = "BF.RESERVE" // Creates a new Bloom Filter.
 
+	CFAdd     MessageType = "CF.ADD"     // Adds an item to a Cuckoo Filter. Creates a filter if it doesn't already exist.
+	CFAddNX   MessageType = "CF.ADDNX"   // Adds an item to a Cuckoo Filter only if it doesn't already exist in it.
+	CFCount   MessageType = "CF.COUNT"   // Returns the number of times an item may be in a Cuckoo Filter.
+	CFDel     MessageType = "CF.DEL"     // Deletes an item from a Cuckoo Filter.
+	CFExists  MessageType = "CF.EXISTS"  // Checks whether an item exists in a Cuckoo Filter.
+	CFInfo    MessageType = "CF.INFO"    // Returns information about a Cuckoo Filter.
+	CFReserve MessageType = "CF.RESERVE" // Creates a new Cuckoo Filter.
+
+	CMSIncrBy     MessageType = "CMS.INCRBY"     // Increments the counts of items in a Count-Min Sketch.
+	CMSInfo       MessageType = "CMS.INFO"       // Returns information about a Count-Min Sketch.
+	CMSInitByDim  MessageType = "CMS.INITBYDIM"  // Creates a new Count-Min Sketch of a given width and depth.
+	CMSInitByProb MessageType = "CMS.INITBYPROB" // Creates a new Count-Min Sketch for a given error rate and probability.
+	CMSMerge      MessageType = "CMS.MERGE"      // Merges Count-Min Sketches into an existing sketch, with optional weights.
+	CMSQuery      MessageType = "CMS.QUERY"      // Returns the estimated counts of items in a Count-Min Sketch.
+
+	TopKAdd     MessageType = "TOPK.ADD"     // Adds items to a Top-K sketch, and returns the items expelled from it.
+	TopKCount   MessageType = "TOPK.COUNT"   // Returns the estimated counts of items in a Top-K sketch.
+	TopKIncrBy  MessageType = "TOPK.INCRBY"  // Increments the counts of items in a Top-K sketch, and returns the items expelled from it.
+	TopKList    MessageType = "TOPK.LIST"    // Returns the items in a Top-K sketch, optionally with their counts.
+	TopKQuery   MessageType = "TOPK.QUERY"   // Checks whether items are in a Top-K sketch.
+	TopKReserve MessageType = "TOPK.RESERVE" // Creates a new Top-K sketch.
+
+	TDigestAdd         MessageType = "TDIGEST.ADD"          // Adds samples to a t-digest.
+	TDigestCDF         MessageType = "TDIGEST.CDF"          // Returns the fraction of samples less than or equal to each value.
+	TDigestCreate      MessageType = "TDIGEST.CREATE"       // Creates a new t-digest.
+	TDigestInfo        MessageType = "TDIGEST.INFO"         // Returns information about a t-digest.
+	TDigestMax         MessageType = "TDIGEST.MAX"          // Returns the largest sample in a t-digest.
+	TDigestMerge       MessageType = "TDIGEST.MERGE"        // Merges t-digests into a destination key.
+	TDigestMin         MessageType = "TDIGEST.MIN"          // Returns the smallest sample in a t-digest.
+	TDigestQuantile    MessageType = "TDIGEST.QUANTILE"     // Returns the value at each quantile.
+	TDigestTrimmedMean MessageType = "TDIGEST.TRIMMED_MEAN" // Returns the mean of the samples between two quantiles.
+
+	TSAdd      MessageType = "TS.ADD"      // Adds a sample to a time series. Creates the series if it doesn't already exist.
+	TSCreate   MessageType = "TS.CREATE"   // Creates a new time series.
+	TSGet      MessageType = "TS.GET"      // Returns the newest sample in a time series.
+	TSMAdd     MessageType = "TS.MADD"     // Adds samples to existing time series.
+	TSRange    MessageType = "TS.RANGE"    // Returns the samples in a time range, optionally filtered and aggregated.
+	TSRevRange MessageType = "TS.REVRANGE" // Returns the samples in a time range from newest to oldest.
+
+	JSONArrAppend MessageType = "JSON.ARRAPPEND" // Appends values to the arrays at a path of a JSON document.
+	JSONDel       MessageType = "JSON.DEL"       // Deletes the values at a path of a JSON document.
+	JSONGet       MessageType = "JSON.GET"       // Returns the values at paths of a JSON document.
+	JSONNumIncrBy MessageType = "JSON.NUMINCRBY" // Increments the numbers at a path of a JSON document.
+	JSONObjKeys   MessageType = "JSON.OBJKEYS"   // Returns the member names of the objects at a path of a JSON document.
+	JSONSet       MessageType = "JSON.SET"       // Sets the value at a path of a JSON document. Creates the document if it doesn't already exist.
+	JSONType      MessageType = "JSON.TYPE"      // Returns the types of the values at a path of a JSON document.
+
+	XAck       MessageType = "XACK"       // Acknowledges entries pending in a consumer group.
+	XAdd       MessageType = "XADD"       // Appends an entry to a stream. Creates the stream if it doesn't already exist.
+	XAutoClaim MessageType = "XAUTOCLAIM" // Claims the idle pending entries of a consumer group from an ID onwards.
+	XClaim     MessageType = "XCLAIM"     // Claims idle pending entries of a consumer group by ID.
+	XGroup     MessageType = "XGROUP"     // Creates a consumer group of a stream.
+	XLen       MessageType = "XLEN"       // Returns the number of entries in a stream.
+	XPending   MessageType = "XPENDING"   // Returns the entries pending acknowledgement in a consumer group.
+	XRange     MessageType = "XRANGE"     // Returns the entries of a stream in an ID range.
+	XReadGroup MessageType = "XREADGROUP" // Reads entries from streams on behalf of a consumer of a group.
+	XRevRange  MessageType = "XREVRANGE"  // Returns the entries of a stream in an ID range from newest to oldest.
+	XTrim      MessageType = "XTRIM"      // Removes the oldest entries of a stream.
+
+	VAdd  MessageType = "VADD"  // Adds an element with a vector to a vector set. Creates the set if it doesn't already exist.
+	VCard MessageType = "VCARD" // Returns the number of elements in a vector set.
+	VRem  MessageType = "VREM"  // Removes an element from a vector set.
+	VSim  MessageType = "VSIM"  // Returns the elements of a vector set nearest to a vector or to another element.
+
+	FTCreate    MessageType = "FT.CREATE"    // Creates a full-text index over the keys starting with a prefix.
+	FTDropIndex MessageType = "FT.DROPINDEX" // Deletes a full-text index, leaving the keys it covers.
+	FTSearch    MessageType = "FT.SEARCH"    // Returns the keys matching a full-text query, from the most to the least relevant.
+
 	PFAdd   MessageType = "PFADD"
 	PFCount MessageType = "PFCOUNT"
 	PFMerge MessageType = "PFMERGE"
@@ -45,6 +197,7 @@ const (
 	Count   MessageType = "COUNT"
 	String  MessageType = "STRING"
 	Boolean MessageType = "BOOLEAN"
+	Float   MessageType = "FLOAT"
 )
 
 type Command interface {
@@ -72,14 +225,48 @@ func ParseStringIntoCommand(s string) (Command, error) {
 		return NewAuthChallengeRequestCommand(lineMessage)
 	case string(Get):
 		return NewGetCommand(lineMessage)
-	case string(Set):
+	case string(Set), string(SetNX), string(GetSet):
 		return NewSetCommand(lineMessage)
+	case string(GetDel), string(StrLen):
+		return NewStringKeyCommand(lineMessage)
+	case string(MSet), string(MSetNX):
+		return NewMSetCommand(lineMessage)
+	case string(MGet):
+		return NewMGetCommand(lineMessage)
+	case string(Append):
+		return NewAppendCommand(lineMessage)
+	case string(GetRange):
+		return NewGetRangeCommand(lineMessage)
+	case string(SetRange):
+		return NewSetRangeCommand(lineMessage)
 	case string(Del):
 		return NewDelCommand(lineMessage)
-	case string(LPush):
+	case string(Incr), string(Decr), string(IncrBy), string(DecrBy):
+		return NewIncrCommand(lineMessage)
+	case string(IncrByFloat):
+		return NewIncrByFloatCommand(lineMessage)
+	case string(Throttle):
+		return NewThrottleCommand(lineMessage)
+	case string(LPush), string(LPushX):
 		return NewLPushCommand(lineMessage)
-	case string(RPush):
+	case string(RPush), string(RPushX):
 		return NewRPushCommand(lineMessage)
+	case string(LIndex):
+		return NewLIndexCommand(lineMessage)
+	case string(LSet):
+		return NewLSetCommand(lineMessage)
+	case string(LInsert):
+		return NewLInsertCommand(lineMessage)
+	case string(LRem):
+		return NewLRemCommand(lineMessage)
+	case string(LTrim):
+		return NewLTrimCommand(lineMessage)
+	case string(LPos):
+		return NewLPosCommand(lineMessage)
+	case string(LMove), string(RPopLPush), string(BLMove):
+		return NewLMoveCommand(lineMessage)
+	case string(BLPop), string(BRPop):
+		return NewBPopCommand(lineMessage)
 	case string(LLen):
 		return NewLLenCommand(lineMessage)
 	case string(LPop):
@@ -102,6 +289,194 @@ func ParseStringIntoCommand(s string) (Command, error) {
 		return NewSIsMemberCommand(lineMessage)
 	case string(SMembers):
 		return NewSMembersCommand(lineMessage)
+	case string(SRem), string(SMIsMember):
+		return NewSValuesCommand(lineMessage)
+	case string(SPop), string(SRandMember):
+		return NewSPopCommand(lineMessage)
+	case string(SMove):
+		return NewSMoveCommand(lineMessage)
+	case string(SInterCard):
+		return NewSInterCardCommand(lineMessage)
+	case string(SInterStore), string(SUnionStore), string(SDiffStore):
+		return NewSStoreCommand(lineMessage)
+	case string(HSet):
+		return NewHSetCommand(lineMessage)
+	case string(HSetNX):
+		return NewHSetNXCommand(lineMessage)
+	case string(HGet), string(HExists):
+		return NewHFieldCommand(lineMessage)
+	case string(HMGet), string(HDel):
+		return NewHFieldsCommand(lineMessage)
+	case string(HLen), string(HKeys), string(HVals), string(HGetAll):
+		return NewHKeyCommand(lineMessage)
+	case string(HIncrBy):
+		return NewHIncrByCommand(lineMessage)
+	case string(HIncrByFloat):
+		return NewHIncrByFloatCommand(lineMessage)
+	case string(HScan):
+		return NewHScanCommand(lineMessage)
+	case string(ZAdd):
+		return NewZAddCommand(lineMessage)
+	case string(ZCard):
+		return NewZCardCommand(lineMessage)
+	case string(ZIncrBy):
+		return NewZIncrByCommand(lineMessage)
+	case string(ZRange):
+		return NewZRangeCommand(lineMessage)
+	case string(ZRangeByScore):
+		return NewZRangeByScoreCommand(lineMessage)
+	case string(ZRank):
+		return NewZRankCommand(lineMessage)
+	case string(ZRem):
+		return NewZRemCommand(lineMessage)
+	case string(ZRevRange):
+		return NewZRevRangeCommand(lineMessage)
+	case string(ZRevRangeByScore):
+		return NewZRevRangeByScoreCommand(lineMessage)
+	case string(ZRevRank):
+		return NewZRevRankCommand(lineMessage)
+	case string(ZScore):
+		return NewZScoreCommand(lineMessage)
+	case string(GeoAdd):
+		return NewGeoAddCommand(lineMessage)
+	case string(GeoDist):
+		return NewGeoDistCommand(lineMessage)
+	case string(GeoHash), string(GeoPos):
+		return NewGeoMembersCommand(lineMessage)
+	case string(GeoSearch):
+		return NewGeoSearchCommand(lineMessage)
+	case string(SetBit):
+		return NewSetBitCommand(lineMessage)
+	case string(GetBit):
+		return NewGetBitCommand(lineMessage)
+	case string(BitCount):
+		return NewBitCountCommand(lineMessage)
+	case string(BitPos):
+		return NewBitPosCommand(lineMessage)
+	case string(BitOp):
+		return NewBitOpCommand(lineMessage)
+	case string(BitField):
+		return NewBitFieldCommand(lineMessage)
+	case string(BFAdd):
+		return NewBFAddCommand(lineMessage)
+	case string(BFCard):
+		return NewBFCardCommand(lineMessage)
+	case string(BFExists):
+		return NewBFExistsCommand(lineMessage)
+	case string(BFInfo):
+		return NewBFInfoCommand(lineMessage)
+	case string(BFMAdd):
+		return NewBFMAddCommand(lineMessage)
+	case string(BFMExists):
+		return NewBFMExistsCommand(lineMessage)
+	case string(BFReserve):
+		return NewBFReserveCommand(lineMessage)
+	case string(CFAdd), string(CFAddNX):
+		return NewCFAddCommand(lineMessage)
+	case string(CFCount):
+		return NewCFCountCommand(lineMessage)
+	case string(CFDel):
+		return NewCFDelCommand(lineMessage)
+	case string(CFExists):
+		return NewCFExistsCommand(lineMessage)
+	case string(CFInfo):
+		return NewCFInfoCommand(lineMessage)
+	case string(CFReserve):
+		return NewCFReserveCommand(lineMessage)
+	case string(CMSIncrBy):
+		return NewCMSIncrByCommand(lineMessage)
+	case string(CMSInfo):
+		return NewCMSInfoCommand(lineMessage)
+	case string(CMSInitByDim):
+		return NewCMSInitByDimCommand(lineMessage)
+	case string(CMSInitByProb):
+		return NewCMSInitByProbCommand(lineMessage)
+	case string(CMSMerge):
+		return NewCMSMergeCommand(lineMessage)
+	case string(CMSQuery):
+		return NewCMSQueryCommand(lineMessage)
+	case string(TopKAdd):
+		return NewTopKAddCommand(lineMessage)
+	case string(TopKCount), string(TopKQuery):
+		return NewTopKItemsCommand(lineMessage)
+	case string(TopKIncrBy):
+		return NewTopKIncrByCommand(lineMessage)
+	case string(TopKList):
+		return NewTopKListCommand(lineMessage)
+	case string(TopKReserve):
+		return NewTopKReserveCommand(lineMessage)
+	case string(TDigestAdd):
+		return NewTDigestAddCommand(lineMessage)
+	case string(TDigestCDF):
+		return NewTDigestCDFCommand(lineMessage)
+	case string(TDigestCreate):
+		return NewTDigestCreateCommand(lineMessage)
+	case string(TDigestInfo), string(TDigestMax), string(TDigestMin):
+		return NewTDigestKeyCommand(lineMessage)
+	case string(TDigestMerge):
+		return NewTDigestMergeCommand(lineMessage)
+	case string(TDigestQuantile):
+		return NewTDigestQuantileCommand(lineMessage)
+	case string(TDigestTrimmedMean):
+		return NewTDigestTrimmedMeanCommand(lineMessage)
+	case string(TSAdd):
+		return NewTSAddCommand(lineMessage)
+	case string(TSCreate):
+		return NewTSCreateCommand(lineMessage)
+	case string(TSGet):
+		return NewTSGetCommand(lineMessage)
+	case string(TSMAdd):
+		return NewTSMAddCommand(lineMessage)
+	case string(TSRange):
+		return NewTSRangeCommand(lineMessage)
+	case string(TSRevRange):
+		return NewTSRevRangeCommand(lineMessage)
+	case string(JSONArrAppend):
+		return NewJSONArrAppendCommand(lineMessage)
+	case string(JSONDel), string(JSONObjKeys), string(JSONType):
+		return NewJSONPathCommand(lineMessage)
+	case string(JSONGet):
+		return NewJSONGetCommand(lineMessage)
+	case string(JSONNumIncrBy):
+		return NewJSONNumIncrByCommand(lineMessage)
+	case string(JSONSet):
+		return NewJSONSetCommand(lineMessage)
+	case string(XAck):
+		return NewXAckCommand(lineMessage)
+	case string(XAdd):
+		return NewXAddCommand(lineMessage)
+	case string(XAutoClaim):
+		return NewXAutoClaimCommand(lineMessage)
+	case string(XClaim):
+		return NewXClaimCommand(lineMessage)
+	case string(XGroup):
+		return NewXGroupCommand(lineMessage)
+	case string(XLen):
+		return NewXLenCommand(lineMessage)
+	case string(XPending):
+		return NewXPendingCommand(lineMessage)
+	case string(XRange):
+		return NewXRangeCommand(lineMessage)
+	case string(XReadGroup):
+		return NewXReadGroupCommand(lineMessage)
+	case string(XRevRange):
+		return NewXRevRangeCommand(lineMessage)
+	case string(XTrim):
+		return NewXTrimCommand(lineMessage)
+	case string(VAdd):
+		return NewVAddCommand(lineMessage)
+	case string(VCard):
+		return NewVCardCommand(lineMessage)
+	case string(VRem):
+		return NewVRemCommand(lineMessage)
+	case string(VSim):
+		return NewVSimCommand(lineMessage)
+	case string(FTCreate):
+		return NewFTCreateCommand(lineMessage)
+	case string(FTDropIndex):
+		return NewFTDropIndexCommand(lineMessage)
+	case string(FTSearch):
+		return NewFTSearchCommand(lineMessage)
 	case string(PFAdd):
 		return NewPFAddCommand(lineMessage)
 	case string(PFCount):
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxSketchCounters bounds the number of counters a single sketch may allocate.
+const maxSketchCounters = 1 << 24
+
+type CMSInitByDimCommand struct {
+	Key   string
+	Width uint
+	Depth uint
+	LineMessage
+}
+
+// NewCMSInitByDimCommand parses CMS.INITBYDIM key width depth.
+func NewCMSInitByDimCommand(line LineMessage) (*CMSInitByDimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	width, err := strconv.ParseUint(parts[2], 10, 0)
+	if err != nil || width == 0 {
+		return nil, errors.New("invalid width")
+	}
+	depth, err := strconv.ParseUint(parts[3], 10, 0)
+	if err != nil || depth == 0 {
+		return nil, errors.New("invalid depth")
+	}
+	if width > maxSketchCounters/depth {
+		return nil, errors.New("sketch is too large")
+	}
+
+	return &CMSInitByDimCommand{
+		Key:         parts[1],
+		Width:       uint(width),
+		Depth:       uint(depth),
+		LineMessage: line,
+	}, nil
+}
+
+type CMSInitByProbCommand struct {
+	Key         string
+	ErrorRate   float64 // Estimates exceed the true count by at most this fraction of the total count...
+	Probability float64 // ...except with this probability
+	LineMessage
+}
+
+// NewCMSInitByProbCommand parses CMS.INITBYPROB key error probability.
+func NewCMSInitByProbCommand(line LineMessage) (*CMSInitByProbCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	errorRate, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil || errorRate <= 0 || errorRate >= 1 {
+		return nil, errors.New("invalid error rate")
+	}
+	probability, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil || probability <= 0 || probability >= 1 {
+		return nil, errors.New("invalid probability")
+	}
+	// The sketch has 2/error counters in each of its log2(1/probability) rows.
+	if math.Ceil(2/errorRate)*math.Ceil(math.Log2(1/probability)) > maxSketchCounters {
+		return nil, errors.New("sketch is too large")
+	}
+
+	return &CMSInitByProbCommand{
+		Key:         parts[1],
+		ErrorRate:   errorRate,
+		Probability: probability,
+		LineMessage: line,
+	}, nil
+}
+
+// CMSIncrement is an item of a sketch along with the amount to increment its count by.
+type CMSIncrement struct {
+	Item      string
+	Increment uint64
+}
+
+type CMSIncrByCommand struct {
+	Key        string
+	Increments []CMSIncrement
+	LineMessage
+}
+
+// NewCMSIncrByCommand parses CMS.INCRBY key item increment [item increment ...].
+func NewCMSIncrByCommand(line LineMessage) (*CMSIncrByCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 || len(parts)%2 != 0 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &CMSIncrByCommand{Key: parts[1], LineMessage: line}
+	for i := 2; i < len(parts); i += 2 {
+		increment, err := strconv.ParseUint(parts[i+1], 10, 64)
+		if err != nil {
+			return nil, errors.New("increment is not a non-negative integer")
+		}
+		cmd.Increments = append(cmd.Increments, CMSIncrement{Item: parts[i], Increment: increment})
+	}
+	return cmd, nil
+}
+
+type CMSQueryCommand struct {
+	Key   string
+	Items []string
+	LineMessage
+}
+
+// NewCMSQueryCommand parses CMS.QUERY key item [item ...].
+func NewCMSQueryCommand(line LineMessage) (*CMSQueryCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &CMSQueryCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+type CMSMergeCommand struct {
+	DestKey    string
+	SourceKeys []string
+	Weights    []uint64 // The weight of each source, which defaults to 1
+	LineMessage
+}
+
+// NewCMSMergeCommand parses CMS.MERGE destination numkeys source [source ...] [WEIGHTS weight [weight ...]].
+func NewCMSMergeCommand(line LineMessage) (*CMSMergeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	numKeys, err := strconv.Atoi(parts[2])
+	if err != nil || numKeys < 1 {
+		return nil, errors.New("invalid numkeys")
+	}
+	if len(parts) < 3+numKeys {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &CMSMergeCommand{
+		DestKey:     parts[1],
+		SourceKeys:  parts[3 : 3+numKeys],
+		Weights:     make([]uint64, numKeys),
+		LineMessage: line,
+	}
+
+	rest := parts[3+numKeys:]
+	switch {
+	case len(rest) == 0:
+		for i := range cmd.Weights {
+			cmd.Weights[i] = 1
+		}
+	case strings.ToUpper(rest[0]) == "WEIGHTS" && len(rest) == 1+numKeys:
+		for i, part := range rest[1:] {
+			if cmd.Weights[i], err = strconv.ParseUint(part, 10, 64); err != nil {
+				return nil, errors.New("weight is not a non-negative integer")
+			}
+		}
+	default:
+		return nil, errors.New("syntax error")
+	}
+	return cmd, nil
+}
+
+type CMSInfoCommand struct {
+	Key string
+	LineMessage
+}
+
+// NewCMSInfoCommand parses CMS.INFO key.
+func NewCMSInfoCommand(line LineMessage) (*CMSInfoCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &CMSInfoCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxCuckooCapacity   = 1 << 24 // Bounds the number of items a cuckoo filter is sized for
+	maxCuckooIterations = 65535   // Bounds the evictions an insert into a cuckoo filter may attempt
+)
+
+type CFReserveCommand struct {
+	Key             string
+	Capacity        uint
+	BucketSize      uint // Zero uses the server default
+	FingerprintSize uint // Zero uses the server default
+	MaxIterations   uint // Zero uses the server default
+	LineMessage
+}
+
+// NewCFReserveCommand parses CF.RESERVE key capacity [BUCKETSIZE n] [FINGERPRINTSIZE n] [MAXITERATIONS n].
+func NewCFReserveCommand(line LineMessage) (*CFReserveCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 || len(parts)%2 != 1 {
+		return nil, ErrWrongArgCount
+	}
+
+	capacity, err := strconv.ParseUint(parts[2], 10, 0)
+	if err != nil || capacity == 0 {
+		return nil, errors.New("invalid capacity")
+	}
+	if capacity > maxCuckooCapacity {
+		return nil, errors.New("filter is too large")
+	}
+
+	cmd := &CFReserveCommand{Key: parts[1], Capacity: uint(capacity), LineMessage: line}
+	for i := 3; i < len(parts); i += 2 {
+		value, err := strconv.ParseUint(parts[i+1], 10, 0)
+		if err != nil || value == 0 {
+			return nil, errors.New("invalid " + strings.ToLower(parts[i]))
+		}
+
+		switch strings.ToUpper(parts[i]) {
+		case "BUCKETSIZE":
+			if value > 255 {
+				return nil, errors.New("invalid bucketsize")
+			}
+			cmd.BucketSize = uint(value)
+		case "FINGERPRINTSIZE":
+			if value > 4 {
+				return nil, errors.New("invalid fingerprintsize")
+			}
+			cmd.FingerprintSize = uint(value)
+		case "MAXITERATIONS":
+			if value > maxCuckooIterations {
+				return nil, errors.New("invalid maxiterations")
+			}
+			cmd.MaxIterations = uint(value)
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
+
+// CFAddCommand serves both CF.ADD and CF.ADDNX.
+type CFAddCommand struct {
+	Key  string
+	Item string
+	LineMessage
+}
+
+func NewCFAddCommand(line LineMessage) (*CFAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &CFAddCommand{
+		Key:         parts[1],
+		Item:        parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+type CFExistsCommand struct {
+	Key  string
+	Item string
+	LineMessage
+}
+
+func NewCFExistsCommand(line LineMessage) (*CFExistsCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &CFExistsCommand{
+		Key:         parts[1],
+		Item:        parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+type CFDelCommand struct {
+	Key  string
+	Item string
+	LineMessage
+}
+
+func NewCFDelCommand(line LineMessage) (*CFDelCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &CFDelCommand{
+		Key:         parts[1],
+		Item:        parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+type CFCountCommand struct {
+	Key  string
+	Item string
+	LineMessage
+}
+
+func NewCFCountCommand(line LineMessage) (*CFCountCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &CFCountCommand{
+		Key:         parts[1],
+		Item:        parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+type CFInfoCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewCFInfoCommand(line LineMessage) (*CFInfoCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &CFInfoCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
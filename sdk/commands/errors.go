@@ -3,8 +3,16 @@ package commands
 import "errors"
 
 var (
-	ErrorInvalidDataType = errors.New("InvalidDataType")
-	ErrorNotFound        = errors.New("NotFound")
-	ErrInvalidCommand    = errors.New("InvalidCommand")
-	ErrEmptyCommand      = errors.New("EmptyCommand")
+	ErrorInvalidDataType    = errors.New("InvalidDataType")
+	ErrorNotFound           = errors.New("NotFound")
+	ErrorKeyExists          = errors.New("KeyExists")
+	ErrorIndexExists        = errors.New("IndexExists")
+	ErrorFilterFull         = errors.New("FilterFull")
+	ErrorInvalidCoordinates = errors.New("InvalidCoordinates")
+	ErrInvalidCommand       = errors.New("InvalidCommand")
+	ErrEmptyCommand         = errors.New("EmptyCommand")
+	ErrWrongArgCount        = errors.New("WrongArgCount")
+	ErrorNotANumber         = errors.New("NotANumber")
+	ErrorTimeout            = errors.New("Timeout")
+	ErrorTooLarge           = errors.New("TooLarge")
 )
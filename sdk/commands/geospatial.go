@@ -0,0 +1,260 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// GeoUnits maps the distance units accepted by geo commands to their length in metres.
+var GeoUnits = map[string]float64{
+	"M":  1,
+	"KM": 1000,
+	"FT": 0.3048,
+	"MI": 1609.34,
+}
+
+func parseGeoUnit(s string) (float64, error) {
+	unit, ok := GeoUnits[strings.ToUpper(s)]
+	if !ok {
+		return 0, errors.New("unsupported unit provided. please use M, KM, FT, MI")
+	}
+	return unit, nil
+}
+
+func parseDistance(s string) (float64, error) {
+	distance, err := parseFiniteFloat(s)
+	if err != nil || distance < 0 {
+		return 0, errors.New("invalid distance")
+	}
+	return distance, nil
+}
+
+// GeoLocation is a member of a geo index along with its position.
+type GeoLocation struct {
+	Longitude float64
+	Latitude  float64
+	Member    string
+}
+
+type GeoAddCommand struct {
+	Key       string
+	NX        bool // Only add new members, never update existing ones
+	XX        bool // Only update existing members, never add new ones
+	CH        bool // Count changed members as well as added ones
+	Locations []GeoLocation
+	LineMessage
+}
+
+// NewGeoAddCommand parses GEOADD key [NX | XX] [CH] longitude latitude member [longitude latitude member ...].
+func NewGeoAddCommand(line LineMessage) (*GeoAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &GeoAddCommand{Key: parts[1], LineMessage: line}
+
+	idx := 2
+options:
+	for ; idx < len(parts); idx++ {
+		switch strings.ToUpper(parts[idx]) {
+		case "NX":
+			cmd.NX = true
+		case "XX":
+			cmd.XX = true
+		case "CH":
+			cmd.CH = true
+		default:
+			break options
+		}
+	}
+
+	if cmd.NX && cmd.XX {
+		return nil, errors.New("XX and NX options at the same time are not compatible")
+	}
+
+	triples := parts[idx:]
+	if len(triples) == 0 || len(triples)%3 != 0 {
+		return nil, ErrWrongArgCount
+	}
+
+	for i := 0; i < len(triples); i += 3 {
+		longitude, err := parseFiniteFloat(triples[i])
+		if err != nil {
+			return nil, errors.New("invalid longitude")
+		}
+		latitude, err := parseFiniteFloat(triples[i+1])
+		if err != nil {
+			return nil, errors.New("invalid latitude")
+		}
+		cmd.Locations = append(cmd.Locations, GeoLocation{Longitude: longitude, Latitude: latitude, Member: triples[i+2]})
+	}
+	return cmd, nil
+}
+
+// GeoMembersCommand serves GEOPOS and GEOHASH, which both take a list of members.
+type GeoMembersCommand struct {
+	Key     string
+	Members []string
+	LineMessage
+}
+
+func NewGeoMembersCommand(line LineMessage) (*GeoMembersCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &GeoMembersCommand{
+		Key:         parts[1],
+		Members:     parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+type GeoDistCommand struct {
+	Key     string
+	Member1 string
+	Member2 string
+	Unit    float64 // Length of the unit in metres
+	LineMessage
+}
+
+// NewGeoDistCommand parses GEODIST key member1 member2 [M | KM | FT | MI].
+func NewGeoDistCommand(line LineMessage) (*GeoDistCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 && len(parts) != 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &GeoDistCommand{Key: parts[1], Member1: parts[2], Member2: parts[3], Unit: 1, LineMessage: line}
+	if len(parts) == 5 {
+		var err error
+		if cmd.Unit, err = parseGeoUnit(parts[4]); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+type GeoSearchCommand struct {
+	Key        string
+	FromMember string // Searches around this member, unless FromLonLat is set
+	FromLonLat bool
+	Longitude  float64
+	Latitude   float64
+	Radius     float64 // Radius of the search in metres, unless ByBox is set
+	ByBox      bool
+	Width      float64 // Width of the search box in metres
+	Height     float64 // Height of the search box in metres
+	Unit       float64 // Length of the unit in metres
+	Desc       bool
+	Count      int // Zero returns all matches
+	WithCoord  bool
+	WithDist   bool
+	WithHash   bool
+	LineMessage
+}
+
+// NewGeoSearchCommand parses
+// GEOSEARCH key <FROMMEMBER member | FROMLONLAT longitude latitude> <BYRADIUS radius unit | BYBOX width height unit>
+// [ASC | DESC] [COUNT count [ANY]] [WITHCOORD] [WITHDIST] [WITHHASH].
+func NewGeoSearchCommand(line LineMessage) (*GeoSearchCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &GeoSearchCommand{Key: parts[1], LineMessage: line}
+	hasFrom, hasBy := false, false
+
+	var err error
+	for i := 2; i < len(parts); i++ {
+		args := parts[i+1:]
+		switch strings.ToUpper(parts[i]) {
+		case "FROMMEMBER":
+			if hasFrom || len(args) < 1 {
+				return nil, errors.New("syntax error")
+			}
+			cmd.FromMember = args[0]
+			hasFrom = true
+			i++
+		case "FROMLONLAT":
+			if hasFrom || len(args) < 2 {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Longitude, err = parseFiniteFloat(args[0]); err != nil {
+				return nil, errors.New("invalid longitude")
+			}
+			if cmd.Latitude, err = parseFiniteFloat(args[1]); err != nil {
+				return nil, errors.New("invalid latitude")
+			}
+			cmd.FromLonLat = true
+			hasFrom = true
+			i += 2
+		case "BYRADIUS":
+			if hasBy || len(args) < 2 {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Radius, err = parseDistance(args[0]); err != nil {
+				return nil, err
+			}
+			if cmd.Unit, err = parseGeoUnit(args[1]); err != nil {
+				return nil, err
+			}
+			cmd.Radius *= cmd.Unit
+			hasBy = true
+			i += 2
+		case "BYBOX":
+			if hasBy || len(args) < 3 {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Width, err = parseDistance(args[0]); err != nil {
+				return nil, err
+			}
+			if cmd.Height, err = parseDistance(args[1]); err != nil {
+				return nil, err
+			}
+			if cmd.Unit, err = parseGeoUnit(args[2]); err != nil {
+				return nil, err
+			}
+			cmd.Width *= cmd.Unit
+			cmd.Height *= cmd.Unit
+			cmd.ByBox = true
+			hasBy = true
+			i += 3
+		case "ASC":
+			cmd.Desc = false
+		case "DESC":
+			cmd.Desc = true
+		case "COUNT":
+			if len(args) < 1 {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Count, err = strconv.Atoi(args[0]); err != nil || cmd.Count <= 0 {
+				return nil, errors.New("COUNT must be > 0")
+			}
+			i++
+			// Matches are always sorted, so ANY returns the same results as a plain COUNT.
+			if len(args) > 1 && strings.ToUpper(args[1]) == "ANY" {
+				i++
+			}
+		case "WITHCOORD":
+			cmd.WithCoord = true
+		case "WITHDIST":
+			cmd.WithDist = true
+		case "WITHHASH":
+			cmd.WithHash = true
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+
+	if !hasFrom {
+		return nil, errors.New("exactly one of FROMMEMBER or FROMLONLAT can be specified")
+	}
+	if !hasBy {
+		return nil, errors.New("exactly one of BYRADIUS and BYBOX can be specified")
+	}
+	return cmd, nil
+}
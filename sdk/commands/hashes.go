@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// HashField is a field of a hash along with its value.
+type HashField struct {
+	Field string
+	Value string
+}
+
+type HSetCommand struct {
+	Key    string
+	Fields []HashField
+	LineMessage
+}
+
+// NewHSetCommand parses HSET key field value [field value ...].
+func NewHSetCommand(line LineMessage) (*HSetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 || len(parts)%2 != 0 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &HSetCommand{Key: parts[1], LineMessage: line}
+	for i := 2; i < len(parts); i += 2 {
+		cmd.Fields = append(cmd.Fields, HashField{Field: parts[i], Value: parts[i+1]})
+	}
+	return cmd, nil
+}
+
+type HSetNXCommand struct {
+	Key   string
+	Field string
+	Value string
+	LineMessage
+}
+
+// NewHSetNXCommand parses HSETNX key field value.
+func NewHSetNXCommand(line LineMessage) (*HSetNXCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+	return &HSetNXCommand{
+		Key:         parts[1],
+		Field:       parts[2],
+		Value:       parts[3],
+		LineMessage: line,
+	}, nil
+}
+
+// HFieldCommand serves HGET and HEXISTS, which both take a single field.
+type HFieldCommand struct {
+	Key   string
+	Field string
+	LineMessage
+}
+
+func NewHFieldCommand(line LineMessage) (*HFieldCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &HFieldCommand{
+		Key:         parts[1],
+		Field:       parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+// HFieldsCommand serves HMGET and HDEL, which both take a list of fields.
+type HFieldsCommand struct {
+	Key    string
+	Fields []string
+	LineMessage
+}
+
+func NewHFieldsCommand(line LineMessage) (*HFieldsCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &HFieldsCommand{
+		Key:         parts[1],
+		Fields:      parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+// HKeyCommand serves HLEN, HKEYS, HVALS and HGETALL, which only take a key.
+type HKeyCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewHKeyCommand(line LineMessage) (*HKeyCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &HKeyCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+type HIncrByCommand struct {
+	Key       string
+	Field     string
+	Increment int64
+	LineMessage
+}
+
+// NewHIncrByCommand parses HINCRBY key field increment.
+func NewHIncrByCommand(line LineMessage) (*HIncrByCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	increment, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return nil, errors.New("increment is not an integer or out of range")
+	}
+
+	return &HIncrByCommand{
+		Key:         parts[1],
+		Field:       parts[2],
+		Increment:   increment,
+		LineMessage: line,
+	}, nil
+}
+
+type HIncrByFloatCommand struct {
+	Key       string
+	Field     string
+	Increment float64
+	LineMessage
+}
+
+// NewHIncrByFloatCommand parses HINCRBYFLOAT key field increment.
+func NewHIncrByFloatCommand(line LineMessage) (*HIncrByFloatCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	increment, err := parseFiniteFloat(parts[3])
+	if err != nil {
+		return nil, errors.New("increment is not a valid float")
+	}
+
+	return &HIncrByFloatCommand{
+		Key:         parts[1],
+		Field:       parts[2],
+		Increment:   increment,
+		LineMessage: line,
+	}, nil
+}
+
+type HScanCommand struct {
+	Key     string
+	Cursor  uint64
+	Pattern string // Empty matches every field
+	Count   int
+	LineMessage
+}
+
+// NewHScanCommand parses HSCAN key cursor [MATCH pattern] [COUNT count].
+func NewHScanCommand(line LineMessage) (*HScanCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cursor, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	cmd := &HScanCommand{Key: parts[1], Cursor: cursor, Count: 10, LineMessage: line}
+	for i := 3; i < len(parts); i += 2 {
+		if i+1 >= len(parts) {
+			return nil, errors.New("syntax error")
+		}
+
+		switch strings.ToUpper(parts[i]) {
+		case "MATCH":
+			cmd.Pattern = parts[i+1]
+		case "COUNT":
+			if cmd.Count, err = strconv.Atoi(parts[i+1]); err != nil || cmd.Count < 1 {
+				return nil, errors.New("invalid count")
+			}
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
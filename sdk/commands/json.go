@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const jsonRootPath = "$"
+
+var errInvalidJSONValue = errors.New("invalid JSON value")
+
+// splitJSONValues splits s into the JSON values it holds one after another, which may contain spaces,
+// and returns whatever follows the last value.
+func splitJSONValues(s string, limit int) ([]string, string, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+
+	values := make([]string, 0)
+	for decoder.More() && (limit == 0 || len(values) < limit) {
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, "", errInvalidJSONValue
+		}
+		values = append(values, string(value))
+	}
+	return values, strings.TrimSpace(s[decoder.InputOffset():]), nil
+}
+
+type JSONSetCommand struct {
+	Key   string
+	Path  string
+	Value string // Encoded JSON value
+	NX    bool   // Only set the path if it doesn't exist
+	XX    bool   // Only set the path if it already exists
+	LineMessage
+}
+
+// NewJSONSetCommand parses JSON.SET key path value [NX | XX]. The value is the rest of the line, so it may contain spaces.
+func NewJSONSetCommand(line LineMessage) (*JSONSetCommand, error) {
+	parts := strings.SplitN(line.String(), " ", 4)
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	values, rest, err := splitJSONValues(parts[3], 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &JSONSetCommand{Key: parts[1], Path: parts[2], Value: values[0], LineMessage: line}
+	switch strings.ToUpper(rest) {
+	case "":
+	case "NX":
+		cmd.NX = true
+	case "XX":
+		cmd.XX = true
+	default:
+		return nil, errors.New("syntax error")
+	}
+	return cmd, nil
+}
+
+type JSONGetCommand struct {
+	Key   string
+	Paths []string
+	LineMessage
+}
+
+// NewJSONGetCommand parses JSON.GET key [path ...], where the path defaults to the root.
+func NewJSONGetCommand(line LineMessage) (*JSONGetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &JSONGetCommand{Key: parts[1], Paths: parts[2:], LineMessage: line}
+	if len(cmd.Paths) == 0 {
+		cmd.Paths = []string{jsonRootPath}
+	}
+	return cmd, nil
+}
+
+// JSONPathCommand serves JSON.DEL, JSON.TYPE and JSON.OBJKEYS, which take a single path.
+type JSONPathCommand struct {
+	Key  string
+	Path string
+	LineMessage
+}
+
+// NewJSONPathCommand parses a key and an optional path, which defaults to the root.
+func NewJSONPathCommand(line LineMessage) (*JSONPathCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &JSONPathCommand{Key: parts[1], Path: jsonRootPath, LineMessage: line}
+	if len(parts) == 3 {
+		cmd.Path = parts[2]
+	}
+	return cmd, nil
+}
+
+type JSONArrAppendCommand struct {
+	Key    string
+	Path   string
+	Values []string // Encoded JSON values
+	LineMessage
+}
+
+// NewJSONArrAppendCommand parses JSON.ARRAPPEND key path value [value ...]. The values may contain spaces.
+func NewJSONArrAppendCommand(line LineMessage) (*JSONArrAppendCommand, error) {
+	parts := strings.SplitN(line.String(), " ", 4)
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	values, rest, err := splitJSONValues(parts[3], 0)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, errInvalidJSONValue
+	}
+
+	return &JSONArrAppendCommand{
+		Key:         parts[1],
+		Path:        parts[2],
+		Values:      values,
+		LineMessage: line,
+	}, nil
+}
+
+type JSONNumIncrByCommand struct {
+	Key       string
+	Path      string
+	Increment json.Number
+	LineMessage
+}
+
+// NewJSONNumIncrByCommand parses JSON.NUMINCRBY key path increment.
+func NewJSONNumIncrByCommand(line LineMessage) (*JSONNumIncrByCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	// The increment is kept as written, so that adding integers stays exact.
+	increment, err := strconv.ParseFloat(parts[3], 64)
+	if err != nil || math.IsInf(increment, 0) || math.IsNaN(increment) || !json.Valid([]byte(parts[3])) {
+		return nil, errors.New("increment is not a valid JSON number")
+	}
+
+	return &JSONNumIncrByCommand{
+		Key:         parts[1],
+		Path:        parts[2],
+		Increment:   json.Number(parts[3]),
+		LineMessage: line,
+	}, nil
+}
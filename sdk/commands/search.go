@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxSearchLimit bounds the number of keys FT.SEARCH returns at once.
+const maxSearchLimit = 10000
+
+// SchemaField is a field of the values an index covers, along with how it is indexed.
+type SchemaField struct {
+	Name string // What queries call the field
+	Path string // The member of a hash or the JSONPath of a document the field is read from
+	Type string // TEXT, TAG, NUMERIC or GEO
+}
+
+type FTCreateCommand struct {
+	Index  string
+	On     string // HASH, JSON or STRING
+	Prefix string
+	Schema []SchemaField
+	LineMessage
+}
+
+// NewFTCreateCommand parses FT.CREATE index [ON HASH|JSON|STRING] PREFIX prefix
+// [SCHEMA field [AS name] TEXT|TAG|NUMERIC|GEO ...].
+// Hash and JSON indexes need a schema, whereas a string is indexed as a single text field.
+func NewFTCreateCommand(line LineMessage) (*FTCreateCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &FTCreateCommand{Index: parts[1], On: "HASH", LineMessage: line}
+	rest := parts[2:]
+	if strings.ToUpper(rest[0]) == "ON" {
+		cmd.On = strings.ToUpper(rest[1])
+		if cmd.On != "HASH" && cmd.On != "JSON" && cmd.On != "STRING" {
+			return nil, errors.New("indexes can be on HASH, JSON or STRING")
+		}
+		rest = rest[2:]
+	}
+
+	if len(rest) < 2 || strings.ToUpper(rest[0]) != "PREFIX" {
+		return nil, errors.New("syntax error")
+	}
+	cmd.Prefix, rest = rest[1], rest[2:]
+
+	if len(rest) > 0 {
+		if strings.ToUpper(rest[0]) != "SCHEMA" {
+			return nil, errors.New("syntax error")
+		}
+		schema, err := parseSchema(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		cmd.Schema = schema
+	}
+
+	if cmd.On != "STRING" && len(cmd.Schema) == 0 {
+		return nil, errors.New("hash and JSON indexes need a schema")
+	}
+	if cmd.On == "STRING" && len(cmd.Schema) > 0 {
+		return nil, errors.New("string indexes have no schema")
+	}
+	return cmd, nil
+}
+
+func parseSchema(parts []string) ([]SchemaField, error) {
+	var schema []SchemaField
+	names := make(map[string]bool)
+	for i := 0; i < len(parts); {
+		field := SchemaField{Name: parts[i], Path: parts[i]}
+		i++
+		if i+1 < len(parts) && strings.ToUpper(parts[i]) == "AS" {
+			field.Name = parts[i+1]
+			i += 2
+		}
+		if i == len(parts) {
+			return nil, errors.New("syntax error")
+		}
+
+		field.Type = strings.ToUpper(parts[i])
+		i++
+		switch field.Type {
+		case "TEXT", "TAG", "NUMERIC", "GEO":
+		default:
+			return nil, errors.New("unknown field type " + parts[i-1])
+		}
+
+		if names[field.Name] {
+			return nil, errors.New("duplicate field " + field.Name)
+		}
+		names[field.Name] = true
+		schema = append(schema, field)
+	}
+	return schema, nil
+}
+
+type FTSearchCommand struct {
+	Index      string
+	Query      string
+	Offset     int
+	Limit      int
+	WithScores bool
+	LineMessage
+}
+
+// NewFTSearchCommand parses FT.SEARCH index query [LIMIT offset num] [WITHSCORES].
+// The query is the rest of the line, up to the options at its end.
+func NewFTSearchCommand(line LineMessage) (*FTSearchCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &FTSearchCommand{Index: parts[1], Limit: 10, LineMessage: line}
+	query := parts[2:]
+	for len(query) > 1 {
+		n := len(query)
+		if strings.ToUpper(query[n-1]) == "WITHSCORES" {
+			cmd.WithScores = true
+			query = query[:n-1]
+			continue
+		}
+		if n > 3 && strings.ToUpper(query[n-3]) == "LIMIT" {
+			offset, err := strconv.Atoi(query[n-2])
+			if err != nil || offset < 0 || offset > maxSearchLimit {
+				return nil, errors.New("invalid offset")
+			}
+			limit, err := strconv.Atoi(query[n-1])
+			if err != nil || limit < 0 || limit > maxSearchLimit {
+				return nil, errors.New("invalid limit")
+			}
+			cmd.Offset, cmd.Limit = offset, limit
+			query = query[:n-3]
+			continue
+		}
+		break
+	}
+
+	cmd.Query = strings.Join(query, " ")
+	return cmd, nil
+}
+
+type FTDropIndexCommand struct {
+	Index string
+	LineMessage
+}
+
+func NewFTDropIndexCommand(line LineMessage) (*FTDropIndexCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &FTDropIndexCommand{Index: parts[1], LineMessage: line}, nil
+}
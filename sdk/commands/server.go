@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -49,6 +50,19 @@ func (s *StringResponse) String() string {
 	return fmt.Sprintf("%s %s", String, s.Value)
 }
 
+type FloatResponse struct {
+	Value float64
+}
+
+func (f *FloatResponse) String() string {
+	return fmt.Sprintf("%s %s", Float, FormatFloat(f.Value))
+}
+
+// FormatFloat formats a float in the shortest representation that parses back to the same value.
+func FormatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
 type ListResponse struct {
 	Values []string
 }
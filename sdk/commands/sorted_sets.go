@@ -0,0 +1,375 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ScoredMember is a member of a sorted set paired with its score.
+type ScoredMember struct {
+	Score  float64
+	Member string
+}
+
+// ScoreBound is one end of a score interval, such as "5", "(5" or "+inf".
+type ScoreBound struct {
+	Value     float64
+	Exclusive bool
+}
+
+func parseScore(s string) (float64, error) {
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, errors.New("invalid score")
+	}
+	return score, nil
+}
+
+func parseScoreBound(s string) (ScoreBound, error) {
+	var bound ScoreBound
+	if strings.HasPrefix(s, "(") {
+		bound.Exclusive = true
+		s = s[1:]
+	}
+
+	value, err := parseScore(s)
+	if err != nil {
+		return bound, errors.New("invalid score bound")
+	}
+	bound.Value = value
+	return bound, nil
+}
+
+type ZAddCommand struct {
+	Key     string
+	NX      bool // Only add new members, never update existing ones
+	XX      bool // Only update existing members, never add new ones
+	GT      bool // Only update existing members if the new score is greater
+	LT      bool // Only update existing members if the new score is lower
+	CH      bool // Count changed members as well as added ones
+	Incr    bool // Increment the score of a single member, like ZINCRBY
+	Members []ScoredMember
+	LineMessage
+}
+
+func NewZAddCommand(line LineMessage) (*ZAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &ZAddCommand{Key: parts[1], LineMessage: line}
+
+	idx := 2
+options:
+	for ; idx < len(parts); idx++ {
+		switch strings.ToUpper(parts[idx]) {
+		case "NX":
+			cmd.NX = true
+		case "XX":
+			cmd.XX = true
+		case "GT":
+			cmd.GT = true
+		case "LT":
+			cmd.LT = true
+		case "CH":
+			cmd.CH = true
+		case "INCR":
+			cmd.Incr = true
+		default:
+			break options
+		}
+	}
+
+	if cmd.NX && cmd.XX {
+		return nil, errors.New("XX and NX options at the same time are not compatible")
+	}
+	if (cmd.GT && cmd.LT) || (cmd.NX && (cmd.GT || cmd.LT)) {
+		return nil, errors.New("GT, LT, and/or NX options at the same time are not compatible")
+	}
+
+	pairs := parts[idx:]
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return nil, ErrWrongArgCount
+	}
+	if cmd.Incr && len(pairs) != 2 {
+		return nil, errors.New("INCR option supports a single increment-element pair")
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := parseScore(pairs[i])
+		if err != nil {
+			return nil, err
+		}
+		cmd.Members = append(cmd.Members, ScoredMember{Score: score, Member: pairs[i+1]})
+	}
+	return cmd, nil
+}
+
+type ZRemCommand struct {
+	Key     string
+	Members []string
+	LineMessage
+}
+
+func NewZRemCommand(line LineMessage) (*ZRemCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &ZRemCommand{
+		Key:         parts[1],
+		Members:     parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+type ZIncrByCommand struct {
+	Key       string
+	Increment float64
+	Member    string
+	LineMessage
+}
+
+func NewZIncrByCommand(line LineMessage) (*ZIncrByCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	increment, err := parseScore(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid increment")
+	}
+	return &ZIncrByCommand{
+		Key:         parts[1],
+		Increment:   increment,
+		Member:      parts[3],
+		LineMessage: line,
+	}, nil
+}
+
+type ZScoreCommand struct {
+	Key    string
+	Member string
+	LineMessage
+}
+
+func NewZScoreCommand(line LineMessage) (*ZScoreCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &ZScoreCommand{
+		Key:         parts[1],
+		Member:      parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+type ZCardCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewZCardCommand(line LineMessage) (*ZCardCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &ZCardCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+// ZRankCommand serves both ZRANK and ZREVRANK.
+type ZRankCommand struct {
+	Key     string
+	Member  string
+	Reverse bool
+	LineMessage
+}
+
+func NewZRankCommand(line LineMessage) (*ZRankCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &ZRankCommand{
+		Key:         parts[1],
+		Member:      parts[2],
+		LineMessage: line,
+	}, nil
+}
+
+func NewZRevRankCommand(line LineMessage) (*ZRankCommand, error) {
+	cmd, err := NewZRankCommand(line)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Reverse = true
+	return cmd, nil
+}
+
+// ZRangeCommand serves ZRANGE, ZREVRANGE, ZRANGEBYSCORE and ZREVRANGEBYSCORE.
+//
+// When ByScore is set, Min and Max hold the score interval, otherwise Start and Stop hold the index interval.
+type ZRangeCommand struct {
+	Key        string
+	Start      int
+	Stop       int
+	Min        ScoreBound
+	Max        ScoreBound
+	ByScore    bool
+	Rev        bool
+	WithScores bool
+	Offset     int
+	Count      int // A negative count returns all matching members
+	LineMessage
+}
+
+// NewZRangeCommand parses ZRANGE key start stop [BYSCORE] [REV] [LIMIT offset count] [WITHSCORES].
+func NewZRangeCommand(line LineMessage) (*ZRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &ZRangeCommand{Key: parts[1], Count: -1, LineMessage: line}
+	limited := false
+	for i := 4; i < len(parts); i++ {
+		switch strings.ToUpper(parts[i]) {
+		case "BYSCORE":
+			cmd.ByScore = true
+		case "REV":
+			cmd.Rev = true
+		case "WITHSCORES":
+			cmd.WithScores = true
+		case "LIMIT":
+			if err := cmd.parseLimit(parts[i+1:]); err != nil {
+				return nil, err
+			}
+			limited = true
+			i += 2
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+
+	if limited && !cmd.ByScore {
+		return nil, errors.New("LIMIT is only supported in combination with BYSCORE")
+	}
+
+	if !cmd.ByScore {
+		return cmd, cmd.parseIndexes(parts[2], parts[3])
+	}
+
+	// With REV, the interval is given from the highest score to the lowest.
+	if cmd.Rev {
+		return cmd, cmd.parseBounds(parts[3], parts[2])
+	}
+	return cmd, cmd.parseBounds(parts[2], parts[3])
+}
+
+// NewZRevRangeCommand parses ZREVRANGE key start stop [WITHSCORES].
+func NewZRevRangeCommand(line LineMessage) (*ZRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 || len(parts) > 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &ZRangeCommand{Key: parts[1], Rev: true, Count: -1, LineMessage: line}
+	if len(parts) == 5 {
+		if strings.ToUpper(parts[4]) != "WITHSCORES" {
+			return nil, errors.New("syntax error")
+		}
+		cmd.WithScores = true
+	}
+	return cmd, cmd.parseIndexes(parts[2], parts[3])
+}
+
+// NewZRangeByScoreCommand parses ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count].
+func NewZRangeByScoreCommand(line LineMessage) (*ZRangeCommand, error) {
+	cmd, err := newZRangeByScoreCommand(line)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(line.String(), " ")
+	return cmd, cmd.parseBounds(parts[2], parts[3])
+}
+
+// NewZRevRangeByScoreCommand parses ZREVRANGEBYSCORE key max min [WITHSCORES] [LIMIT offset count].
+func NewZRevRangeByScoreCommand(line LineMessage) (*ZRangeCommand, error) {
+	cmd, err := newZRangeByScoreCommand(line)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(line.String(), " ")
+	cmd.Rev = true
+	return cmd, cmd.parseBounds(parts[3], parts[2])
+}
+
+func newZRangeByScoreCommand(line LineMessage) (*ZRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &ZRangeCommand{Key: parts[1], ByScore: true, Count: -1, LineMessage: line}
+	for i := 4; i < len(parts); i++ {
+		switch strings.ToUpper(parts[i]) {
+		case "WITHSCORES":
+			cmd.WithScores = true
+		case "LIMIT":
+			if err := cmd.parseLimit(parts[i+1:]); err != nil {
+				return nil, err
+			}
+			i += 2
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
+
+func (cmd *ZRangeCommand) parseIndexes(start, stop string) error {
+	var err error
+	if cmd.Start, err = strconv.Atoi(start); err != nil {
+		return errors.New("invalid start index")
+	}
+	if cmd.Stop, err = strconv.Atoi(stop); err != nil {
+		return errors.New("invalid stop index")
+	}
+	return nil
+}
+
+func (cmd *ZRangeCommand) parseBounds(min, max string) error {
+	var err error
+	if cmd.Min, err = parseScoreBound(min); err != nil {
+		return err
+	}
+	if cmd.Max, err = parseScoreBound(max); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cmd *ZRangeCommand) parseLimit(args []string) error {
+	if len(args) < 2 {
+		return ErrWrongArgCount
+	}
+
+	var err error
+	if cmd.Offset, err = strconv.Atoi(args[0]); err != nil || cmd.Offset < 0 {
+		return errors.New("invalid offset")
+	}
+	if cmd.Count, err = strconv.Atoi(args[1]); err != nil {
+		return errors.New("invalid count")
+	}
+	return nil
+}
@@ -0,0 +1,624 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errInvalidStreamID = errors.New("invalid stream ID specified as stream command argument")
+
+// StreamID identifies an entry of a stream, as a millisecond time and a sequence number.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// parseStreamID parses an ID of the form ms-seq, or ms alone with the sequence number defaulting to seq.
+func parseStreamID(s string, seq uint64) (StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, errInvalidStreamID
+	}
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return StreamID{}, errInvalidStreamID
+		}
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+// parseRangeStart parses the start of an ID range, where - is the smallest ID and a ( prefix excludes the ID itself.
+func parseRangeStart(s string) (StreamID, error) {
+	if s == "-" {
+		return StreamID{}, nil
+	}
+
+	exclusive := strings.HasPrefix(s, "(")
+	id, err := parseStreamID(strings.TrimPrefix(s, "("), 0)
+	if err != nil || !exclusive {
+		return id, err
+	}
+	if id.Seq == math.MaxUint64 {
+		if id.Ms == math.MaxUint64 {
+			return StreamID{}, errors.New("invalid start ID for the interval")
+		}
+		return StreamID{Ms: id.Ms + 1}, nil
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, nil
+}
+
+// parseRangeEnd parses the end of an ID range, where + is the largest ID and a ( prefix excludes the ID itself.
+func parseRangeEnd(s string) (StreamID, error) {
+	if s == "+" {
+		return StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}, nil
+	}
+
+	exclusive := strings.HasPrefix(s, "(")
+	id, err := parseStreamID(strings.TrimPrefix(s, "("), math.MaxUint64)
+	if err != nil || !exclusive {
+		return id, err
+	}
+	if id.Seq == 0 {
+		if id.Ms == 0 {
+			return StreamID{}, errors.New("invalid end ID for the interval")
+		}
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, nil
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, nil
+}
+
+func parseMilliseconds(s string) (int64, error) {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms < 0 {
+		return 0, errors.New("invalid time in milliseconds")
+	}
+	return ms, nil
+}
+
+// StreamTrim is the trimming strategy of XADD and XTRIM.
+type StreamTrim struct {
+	Strategy string // MAXLEN, MINID, or empty to not trim
+	MaxLen   int
+	MinID    StreamID
+}
+
+// parseStreamTrim parses <MAXLEN | MINID> [= | ~] threshold [LIMIT count] at the start of parts,
+// and returns the number of parts it used. Trimming is always exact, so ~ and LIMIT are accepted but have no effect.
+func parseStreamTrim(parts []string) (StreamTrim, int, error) {
+	trim := StreamTrim{Strategy: strings.ToUpper(parts[0])}
+	used := 1
+
+	if len(parts) > used && (parts[used] == "=" || parts[used] == "~") {
+		used++
+	}
+	if len(parts) <= used {
+		return StreamTrim{}, 0, errors.New("syntax error")
+	}
+
+	var err error
+	if trim.Strategy == "MAXLEN" {
+		if trim.MaxLen, err = strconv.Atoi(parts[used]); err != nil || trim.MaxLen < 0 {
+			return StreamTrim{}, 0, errors.New("the MAXLEN argument must be >= 0")
+		}
+	} else if trim.MinID, err = parseStreamID(parts[used], 0); err != nil {
+		return StreamTrim{}, 0, err
+	}
+	used++
+
+	if len(parts) > used+1 && strings.ToUpper(parts[used]) == "LIMIT" {
+		if _, err := strconv.Atoi(parts[used+1]); err != nil {
+			return StreamTrim{}, 0, errors.New("invalid LIMIT")
+		}
+		used += 2
+	}
+	return trim, used, nil
+}
+
+type XAddCommand struct {
+	Key        string
+	NoMkStream bool // Don't create the stream if it doesn't exist
+	Trim       StreamTrim
+	AutoID     bool     // The ID was given as *, and is generated from the time the leader appended the command
+	AutoSeq    bool     // The ID was given as ms-*, and only its sequence number is generated
+	ID         StreamID // The ID of the entry, or only its time if AutoSeq is set
+	Fields     []string // Field and value pairs
+	LineMessage
+}
+
+// NewXAddCommand parses XADD key [NOMKSTREAM] [<MAXLEN | MINID> [= | ~] threshold [LIMIT count]] <* | id> field value [field value ...].
+func NewXAddCommand(line LineMessage) (*XAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &XAddCommand{Key: parts[1], LineMessage: line}
+
+	idx := 2
+options:
+	for idx < len(parts) {
+		switch strings.ToUpper(parts[idx]) {
+		case "NOMKSTREAM":
+			cmd.NoMkStream = true
+			idx++
+		case "MAXLEN", "MINID":
+			trim, used, err := parseStreamTrim(parts[idx:])
+			if err != nil {
+				return nil, err
+			}
+			cmd.Trim = trim
+			idx += used
+		default:
+			break options
+		}
+	}
+
+	if idx >= len(parts) {
+		return nil, ErrWrongArgCount
+	}
+
+	var err error
+	switch id := parts[idx]; {
+	case id == "*":
+		cmd.AutoID = true
+	case strings.HasSuffix(id, "-*"):
+		cmd.AutoSeq = true
+		if cmd.ID.Ms, err = strconv.ParseUint(strings.TrimSuffix(id, "-*"), 10, 64); err != nil {
+			return nil, errInvalidStreamID
+		}
+	default:
+		if cmd.ID, err = parseStreamID(id, 0); err != nil {
+			return nil, err
+		}
+		if cmd.ID == (StreamID{}) {
+			return nil, errors.New("the ID specified in XADD must be greater than 0-0")
+		}
+	}
+
+	cmd.Fields = parts[idx+1:]
+	if len(cmd.Fields) == 0 || len(cmd.Fields)%2 != 0 {
+		return nil, ErrWrongArgCount
+	}
+	return cmd, nil
+}
+
+// XRangeCommand serves both XRANGE and XREVRANGE.
+type XRangeCommand struct {
+	Key     string
+	Start   StreamID
+	End     StreamID
+	Count   int // Zero returns all entries
+	Reverse bool
+	LineMessage
+}
+
+// NewXRangeCommand parses XRANGE key start end [COUNT count].
+func NewXRangeCommand(line LineMessage) (*XRangeCommand, error) {
+	return newXRangeCommand(line, false)
+}
+
+// NewXRevRangeCommand parses XREVRANGE key end start [COUNT count].
+func NewXRevRangeCommand(line LineMessage) (*XRangeCommand, error) {
+	return newXRangeCommand(line, true)
+}
+
+func newXRangeCommand(line LineMessage, reverse bool) (*XRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 && len(parts) != 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	startArg, endArg := parts[2], parts[3]
+	if reverse {
+		startArg, endArg = endArg, startArg
+	}
+
+	cmd := &XRangeCommand{Key: parts[1], Reverse: reverse, LineMessage: line}
+
+	var err error
+	if cmd.Start, err = parseRangeStart(startArg); err != nil {
+		return nil, err
+	}
+	if cmd.End, err = parseRangeEnd(endArg); err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 6 {
+		if strings.ToUpper(parts[4]) != "COUNT" {
+			return nil, errors.New("syntax error")
+		}
+		if cmd.Count, err = strconv.Atoi(parts[5]); err != nil || cmd.Count < 0 {
+			return nil, errors.New("invalid count")
+		}
+		// A count of zero asks for no entries at all, rather than all of them.
+		if cmd.Count == 0 {
+			cmd.Start, cmd.End = StreamID{Ms: 1}, StreamID{}
+		}
+	}
+	return cmd, nil
+}
+
+type XLenCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewXLenCommand(line LineMessage) (*XLenCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &XLenCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+type XTrimCommand struct {
+	Key  string
+	Trim StreamTrim
+	LineMessage
+}
+
+// NewXTrimCommand parses XTRIM key <MAXLEN | MINID> [= | ~] threshold [LIMIT count].
+func NewXTrimCommand(line LineMessage) (*XTrimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	strategy := strings.ToUpper(parts[2])
+	if strategy != "MAXLEN" && strategy != "MINID" {
+		return nil, errors.New("syntax error")
+	}
+
+	trim, used, err := parseStreamTrim(parts[2:])
+	if err != nil {
+		return nil, err
+	}
+	if 2+used != len(parts) {
+		return nil, errors.New("syntax error")
+	}
+
+	return &XTrimCommand{
+		Key:         parts[1],
+		Trim:        trim,
+		LineMessage: line,
+	}, nil
+}
+
+type XGroupCommand struct {
+	Subcommand string // Only CREATE is supported
+	Key        string
+	Group      string
+	ID         StreamID // Entries after this ID are delivered to the group
+	LastID     bool     // The ID was given as $, so only entries added after the group are delivered
+	MkStream   bool     // Create the stream if it doesn't exist
+	LineMessage
+}
+
+// NewXGroupCommand parses XGROUP CREATE key group <id | $> [MKSTREAM].
+func NewXGroupCommand(line LineMessage) (*XGroupCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
+
+	subcommand := strings.ToUpper(parts[1])
+	if subcommand != "CREATE" {
+		return nil, errors.New("unknown XGROUP subcommand")
+	}
+	if len(parts) != 5 && len(parts) != 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &XGroupCommand{Subcommand: subcommand, Key: parts[2], Group: parts[3], LineMessage: line}
+
+	if parts[4] == "$" {
+		cmd.LastID = true
+	} else {
+		var err error
+		if cmd.ID, err = parseStreamID(parts[4], 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(parts) == 6 {
+		if strings.ToUpper(parts[5]) != "MKSTREAM" {
+			return nil, errors.New("syntax error")
+		}
+		cmd.MkStream = true
+	}
+	return cmd, nil
+}
+
+// XReadGroupStream is a stream read by XREADGROUP.
+type XReadGroupStream struct {
+	Key   string
+	New   bool     // The ID was given as >, so entries never delivered to the group are read
+	After StreamID // Otherwise, the pending entries of the consumer after this ID are read again
+}
+
+type XReadGroupCommand struct {
+	Group    string
+	Consumer string
+	Count    int // Zero reads all entries
+	NoAck    bool
+	Streams  []XReadGroupStream
+	LineMessage
+}
+
+// NewXReadGroupCommand parses XREADGROUP GROUP group consumer [COUNT count] [NOACK] STREAMS key [key ...] id [id ...].
+func NewXReadGroupCommand(line LineMessage) (*XReadGroupCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 7 {
+		return nil, ErrWrongArgCount
+	}
+	if strings.ToUpper(parts[1]) != "GROUP" {
+		return nil, errors.New("syntax error")
+	}
+
+	cmd := &XReadGroupCommand{Group: parts[2], Consumer: parts[3], LineMessage: line}
+
+	idx := 4
+	for ; idx < len(parts) && strings.ToUpper(parts[idx]) != "STREAMS"; idx++ {
+		switch strings.ToUpper(parts[idx]) {
+		case "COUNT":
+			if idx+1 >= len(parts) {
+				return nil, errors.New("syntax error")
+			}
+			var err error
+			if cmd.Count, err = strconv.Atoi(parts[idx+1]); err != nil || cmd.Count < 0 {
+				return nil, errors.New("invalid count")
+			}
+			idx++
+		case "NOACK":
+			cmd.NoAck = true
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+
+	streams := parts[min(idx+1, len(parts)):]
+	if len(streams) == 0 || len(streams)%2 != 0 {
+		return nil, errors.New("unbalanced XREADGROUP list of streams: for each stream key an ID or '>' must be specified")
+	}
+
+	keys, ids := streams[:len(streams)/2], streams[len(streams)/2:]
+	for i, key := range keys {
+		stream := XReadGroupStream{Key: key}
+		if ids[i] == ">" {
+			stream.New = true
+		} else {
+			var err error
+			if stream.After, err = parseStreamID(ids[i], 0); err != nil {
+				return nil, err
+			}
+		}
+		cmd.Streams = append(cmd.Streams, stream)
+	}
+	return cmd, nil
+}
+
+type XAckCommand struct {
+	Key   string
+	Group string
+	IDs   []StreamID
+	LineMessage
+}
+
+// NewXAckCommand parses XACK key group id [id ...].
+func NewXAckCommand(line LineMessage) (*XAckCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &XAckCommand{Key: parts[1], Group: parts[2], LineMessage: line}
+	for _, part := range parts[3:] {
+		id, err := parseStreamID(part, 0)
+		if err != nil {
+			return nil, err
+		}
+		cmd.IDs = append(cmd.IDs, id)
+	}
+	return cmd, nil
+}
+
+type XPendingCommand struct {
+	Key      string
+	Group    string
+	Extended bool // Lists pending entries instead of summarising them
+	MinIdle  int64
+	Start    StreamID
+	End      StreamID
+	Count    int
+	Consumer string // Only lists entries pending for this consumer, if not empty
+	LineMessage
+}
+
+// NewXPendingCommand parses XPENDING key group [[IDLE min-idle-time] start end count [consumer]].
+func NewXPendingCommand(line LineMessage) (*XPendingCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &XPendingCommand{Key: parts[1], Group: parts[2], LineMessage: line}
+	if len(parts) == 3 {
+		return cmd, nil
+	}
+	cmd.Extended = true
+
+	args := parts[3:]
+	var err error
+	if strings.ToUpper(args[0]) == "IDLE" {
+		if len(args) < 2 {
+			return nil, errors.New("syntax error")
+		}
+		if cmd.MinIdle, err = parseMilliseconds(args[1]); err != nil {
+			return nil, err
+		}
+		args = args[2:]
+	}
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errors.New("syntax error")
+	}
+
+	if cmd.Start, err = parseRangeStart(args[0]); err != nil {
+		return nil, err
+	}
+	if cmd.End, err = parseRangeEnd(args[1]); err != nil {
+		return nil, err
+	}
+	if cmd.Count, err = strconv.Atoi(args[2]); err != nil || cmd.Count < 0 {
+		return nil, errors.New("invalid count")
+	}
+	if len(args) == 4 {
+		cmd.Consumer = args[3]
+	}
+	return cmd, nil
+}
+
+type XClaimCommand struct {
+	Key        string
+	Group      string
+	Consumer   string
+	MinIdle    int64
+	IDs        []StreamID
+	Idle       *int64  // Sets the idle time of claimed entries, if set
+	Time       *int64  // Sets the delivery time of claimed entries as a Unix time in milliseconds, if set
+	RetryCount *uint64 // Sets the delivery count of claimed entries, if set
+	Force      bool
+	JustID     bool
+	LineMessage
+}
+
+// NewXClaimCommand parses XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-milliseconds]
+// [RETRYCOUNT count] [FORCE] [JUSTID].
+func NewXClaimCommand(line LineMessage) (*XClaimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	minIdle, err := parseMilliseconds(parts[4])
+	if err != nil {
+		return nil, err
+	}
+	cmd := &XClaimCommand{Key: parts[1], Group: parts[2], Consumer: parts[3], MinIdle: minIdle, LineMessage: line}
+
+	idx := 5
+	for ; idx < len(parts); idx++ {
+		id, err := parseStreamID(parts[idx], 0)
+		if err != nil {
+			break
+		}
+		cmd.IDs = append(cmd.IDs, id)
+	}
+	if len(cmd.IDs) == 0 {
+		return nil, errInvalidStreamID
+	}
+
+	for ; idx < len(parts); idx++ {
+		option := strings.ToUpper(parts[idx])
+		switch option {
+		case "FORCE":
+			cmd.Force = true
+			continue
+		case "JUSTID":
+			cmd.JustID = true
+			continue
+		case "IDLE", "TIME", "RETRYCOUNT":
+		default:
+			return nil, errors.New("syntax error")
+		}
+
+		if idx+1 >= len(parts) {
+			return nil, errors.New("syntax error")
+		}
+		idx++
+
+		switch option {
+		case "IDLE":
+			idle, err := parseMilliseconds(parts[idx])
+			if err != nil {
+				return nil, err
+			}
+			cmd.Idle = &idle
+		case "TIME":
+			t, err := parseMilliseconds(parts[idx])
+			if err != nil {
+				return nil, err
+			}
+			cmd.Time = &t
+		case "RETRYCOUNT":
+			count, err := strconv.ParseUint(parts[idx], 10, 64)
+			if err != nil {
+				return nil, errors.New("invalid RETRYCOUNT")
+			}
+			cmd.RetryCount = &count
+		}
+	}
+	return cmd, nil
+}
+
+type XAutoClaimCommand struct {
+	Key      string
+	Group    string
+	Consumer string
+	MinIdle  int64
+	Start    StreamID
+	Count    int
+	JustID   bool
+	LineMessage
+}
+
+// NewXAutoClaimCommand parses XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID].
+func NewXAutoClaimCommand(line LineMessage) (*XAutoClaimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	minIdle, err := parseMilliseconds(parts[4])
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseRangeStart(parts[5])
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &XAutoClaimCommand{
+		Key:         parts[1],
+		Group:       parts[2],
+		Consumer:    parts[3],
+		MinIdle:     minIdle,
+		Start:       start,
+		Count:       100,
+		LineMessage: line,
+	}
+
+	for i := 6; i < len(parts); i++ {
+		switch strings.ToUpper(parts[i]) {
+		case "COUNT":
+			if i+1 >= len(parts) {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Count, err = strconv.Atoi(parts[i+1]); err != nil || cmd.Count < 1 {
+				return nil, errors.New("COUNT must be > 0")
+			}
+			i++
+		case "JUSTID":
+			cmd.JustID = true
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
@@ -0,0 +1,247 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxStringOffset bounds the byte offset SETRANGE writes at, to the largest string a server keeps.
+const maxStringOffset = 512<<20 - 1
+
+// IncrCommand serves INCR, DECR, INCRBY and DECRBY, which all add an increment to an integer.
+type IncrCommand struct {
+	Key       string
+	Increment int64
+	LineMessage
+}
+
+// NewIncrCommand parses INCR key, DECR key, INCRBY key increment and DECRBY key decrement.
+func NewIncrCommand(line LineMessage) (*IncrCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	byAmount := line.MessageType == IncrBy || line.MessageType == DecrBy
+	if (byAmount && len(parts) != 3) || (!byAmount && len(parts) != 2) {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &IncrCommand{Key: parts[1], Increment: 1, LineMessage: line}
+	if byAmount {
+		increment, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, errors.New("increment is not an integer or out of range")
+		}
+		cmd.Increment = increment
+	}
+
+	if line.MessageType == Decr || line.MessageType == DecrBy {
+		if cmd.Increment == math.MinInt64 {
+			return nil, errors.New("decrement is out of range")
+		}
+		cmd.Increment = -cmd.Increment
+	}
+	return cmd, nil
+}
+
+type IncrByFloatCommand struct {
+	Key       string
+	Increment float64
+	LineMessage
+}
+
+// NewIncrByFloatCommand parses INCRBYFLOAT key increment.
+func NewIncrByFloatCommand(line LineMessage) (*IncrByFloatCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	increment, err := parseFiniteFloat(parts[2])
+	if err != nil {
+		return nil, errors.New("increment is not a valid float")
+	}
+
+	return &IncrByFloatCommand{
+		Key:         parts[1],
+		Increment:   increment,
+		LineMessage: line,
+	}, nil
+}
+
+// maxThrottlePeriod bounds the period of THROTTLE in seconds, so that it can be counted in microseconds.
+const maxThrottlePeriod = math.MaxInt64 / 1_000_000
+
+// ThrottleCommand allows Count requests per Period seconds, with bursts of up to MaxBurst+1 requests at once.
+type ThrottleCommand struct {
+	Key      string
+	MaxBurst int64
+	Count    int64
+	Period   int64
+	Quantity int64 // How many requests this one counts as
+	LineMessage
+}
+
+// NewThrottleCommand parses THROTTLE key max_burst count period [quantity].
+func NewThrottleCommand(line LineMessage) (*ThrottleCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 5 || len(parts) > 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	maxBurst, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || maxBurst < 0 || maxBurst == math.MaxInt64 {
+		return nil, errors.New("max_burst is not a non-negative integer or out of range")
+	}
+	count, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || count <= 0 {
+		return nil, errors.New("count is not a positive integer or out of range")
+	}
+	period, err := strconv.ParseInt(parts[4], 10, 64)
+	if err != nil || period <= 0 || period > maxThrottlePeriod {
+		return nil, errors.New("period is not a positive integer or out of range")
+	}
+	if count > period*1_000_000 {
+		return nil, errors.New("count is more than one per microsecond")
+	}
+
+	cmd := &ThrottleCommand{
+		Key:         parts[1],
+		MaxBurst:    maxBurst,
+		Count:       count,
+		Period:      period,
+		Quantity:    1,
+		LineMessage: line,
+	}
+	if len(parts) == 6 {
+		quantity, err := strconv.ParseInt(parts[5], 10, 64)
+		if err != nil || quantity < 0 {
+			return nil, errors.New("quantity is not a non-negative integer or out of range")
+		}
+		cmd.Quantity = quantity
+	}
+	return cmd, nil
+}
+
+// Interval returns the time in microseconds it takes to earn back one request.
+func (cmd *ThrottleCommand) Interval() int64 {
+	return cmd.Period * 1_000_000 / cmd.Count
+}
+
+// KeyValue is a key along with the value to set it to.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// MSetCommand serves MSET and MSETNX, which set several keys in a single write.
+type MSetCommand struct {
+	Pairs []KeyValue
+	LineMessage
+}
+
+// NewMSetCommand parses MSET key value [key value ...] and MSETNX key value [key value ...].
+// Unlike SET, the values can't contain spaces.
+func NewMSetCommand(line LineMessage) (*MSetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 || len(parts)%2 != 1 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &MSetCommand{LineMessage: line}
+	for i := 1; i < len(parts); i += 2 {
+		cmd.Pairs = append(cmd.Pairs, KeyValue{Key: parts[i], Value: parts[i+1]})
+	}
+	return cmd, nil
+}
+
+type MGetCommand struct {
+	Keys []string
+	LineMessage
+}
+
+// NewMGetCommand parses MGET key [key ...].
+func NewMGetCommand(line LineMessage) (*MGetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &MGetCommand{Keys: parts[1:], LineMessage: line}, nil
+}
+
+// StringKeyCommand serves GETDEL and STRLEN, which only take a key.
+type StringKeyCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewStringKeyCommand(line LineMessage) (*StringKeyCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &StringKeyCommand{Key: parts[1], LineMessage: line}, nil
+}
+
+type AppendCommand struct {
+	Key   string
+	Value string
+	LineMessage
+}
+
+// NewAppendCommand parses APPEND key value. The value is the rest of the line, so it may contain spaces.
+func NewAppendCommand(line LineMessage) (*AppendCommand, error) {
+	parts := strings.SplitN(line.String(), " ", 3)
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &AppendCommand{Key: parts[1], Value: parts[2], LineMessage: line}, nil
+}
+
+type GetRangeCommand struct {
+	Key   string
+	Start int
+	End   int
+	LineMessage
+}
+
+// NewGetRangeCommand parses GETRANGE key start end, where the byte offsets are inclusive and
+// negative ones count back from the end.
+func NewGetRangeCommand(line LineMessage) (*GetRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	start, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, errors.New("start is not an integer")
+	}
+	end, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, errors.New("end is not an integer")
+	}
+
+	return &GetRangeCommand{Key: parts[1], Start: start, End: end, LineMessage: line}, nil
+}
+
+type SetRangeCommand struct {
+	Key    string
+	Offset int
+	Value  string
+	LineMessage
+}
+
+// NewSetRangeCommand parses SETRANGE key offset value. The value is the rest of the line, so it may contain spaces.
+func NewSetRangeCommand(line LineMessage) (*SetRangeCommand, error) {
+	parts := strings.SplitN(line.String(), " ", 4)
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	offset, err := strconv.Atoi(parts[2])
+	if err != nil || offset < 0 || offset > maxStringOffset {
+		return nil, errors.New("offset is not an integer or out of range")
+	}
+
+	return &SetRangeCommand{Key: parts[1], Offset: offset, Value: parts[3], LineMessage: line}, nil
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func parseFiniteFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errors.New("invalid value")
+	}
+	return f, nil
+}
+
+func parseCompression(s string) (float64, error) {
+	compression, err := strconv.ParseFloat(s, 64)
+	if err != nil || compression < 1 || compression > 10000 {
+		return 0, errors.New("invalid compression")
+	}
+	return compression, nil
+}
+
+type TDigestCreateCommand struct {
+	Key         string
+	Compression float64 // Zero uses the server default
+	LineMessage
+}
+
+// NewTDigestCreateCommand parses TDIGEST.CREATE key [COMPRESSION compression].
+func NewTDigestCreateCommand(line LineMessage) (*TDigestCreateCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 && len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TDigestCreateCommand{Key: parts[1], LineMessage: line}
+	if len(parts) == 4 {
+		if strings.ToUpper(parts[2]) != "COMPRESSION" {
+			return nil, errors.New("syntax error")
+		}
+
+		var err error
+		if cmd.Compression, err = parseCompression(parts[3]); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+type TDigestAddCommand struct {
+	Key    string
+	Values []float64
+	LineMessage
+}
+
+func NewTDigestAddCommand(line LineMessage) (*TDigestAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	values, err := parseFiniteFloats(parts[2:])
+	if err != nil {
+		return nil, err
+	}
+	return &TDigestAddCommand{
+		Key:         parts[1],
+		Values:      values,
+		LineMessage: line,
+	}, nil
+}
+
+// TDigestQueryCommand serves TDIGEST.QUANTILE and TDIGEST.CDF, which both take a list of values.
+type TDigestQueryCommand struct {
+	Key    string
+	Values []float64
+	LineMessage
+}
+
+func NewTDigestQuantileCommand(line LineMessage) (*TDigestQueryCommand, error) {
+	cmd, err := newTDigestQueryCommand(line)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range cmd.Values {
+		if q < 0 || q > 1 {
+			return nil, errors.New("quantile should be in [0,1]")
+		}
+	}
+	return cmd, nil
+}
+
+func NewTDigestCDFCommand(line LineMessage) (*TDigestQueryCommand, error) {
+	return newTDigestQueryCommand(line)
+}
+
+func newTDigestQueryCommand(line LineMessage) (*TDigestQueryCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	values, err := parseFiniteFloats(parts[2:])
+	if err != nil {
+		return nil, err
+	}
+	return &TDigestQueryCommand{
+		Key:         parts[1],
+		Values:      values,
+		LineMessage: line,
+	}, nil
+}
+
+// TDigestKeyCommand serves TDIGEST.MIN, TDIGEST.MAX and TDIGEST.INFO, which only take a key.
+type TDigestKeyCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewTDigestKeyCommand(line LineMessage) (*TDigestKeyCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &TDigestKeyCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+type TDigestTrimmedMeanCommand struct {
+	Key          string
+	LowQuantile  float64
+	HighQuantile float64
+	LineMessage
+}
+
+// NewTDigestTrimmedMeanCommand parses TDIGEST.TRIMMED_MEAN key low_cut_quantile high_cut_quantile.
+func NewTDigestTrimmedMeanCommand(line LineMessage) (*TDigestTrimmedMeanCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	low, err := parseFiniteFloat(parts[2])
+	if err != nil || low < 0 || low > 1 {
+		return nil, errors.New("invalid low cut quantile")
+	}
+	high, err := parseFiniteFloat(parts[3])
+	if err != nil || high < 0 || high > 1 || high <= low {
+		return nil, errors.New("invalid high cut quantile")
+	}
+
+	return &TDigestTrimmedMeanCommand{
+		Key:          parts[1],
+		LowQuantile:  low,
+		HighQuantile: high,
+		LineMessage:  line,
+	}, nil
+}
+
+type TDigestMergeCommand struct {
+	DestKey     string
+	SourceKeys  []string
+	Compression float64 // Zero uses the largest compression of the sources
+	Override    bool    // Discard the current contents of the destination instead of merging them in
+	LineMessage
+}
+
+// NewTDigestMergeCommand parses TDIGEST.MERGE destination numkeys source [source ...] [COMPRESSION compression] [OVERRIDE].
+func NewTDigestMergeCommand(line LineMessage) (*TDigestMergeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	numKeys, err := strconv.Atoi(parts[2])
+	if err != nil || numKeys < 1 {
+		return nil, errors.New("invalid numkeys")
+	}
+	if len(parts) < 3+numKeys {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TDigestMergeCommand{
+		DestKey:     parts[1],
+		SourceKeys:  parts[3 : 3+numKeys],
+		LineMessage: line,
+	}
+
+	for i := 3 + numKeys; i < len(parts); i++ {
+		switch strings.ToUpper(parts[i]) {
+		case "COMPRESSION":
+			if i+1 >= len(parts) {
+				return nil, ErrWrongArgCount
+			}
+			if cmd.Compression, err = parseCompression(parts[i+1]); err != nil {
+				return nil, err
+			}
+			i++
+		case "OVERRIDE":
+			cmd.Override = true
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
+
+func parseFiniteFloats(parts []string) ([]float64, error) {
+	values := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		value, err := parseFiniteFloat(part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
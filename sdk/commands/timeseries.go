@@ -0,0 +1,299 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var duplicatePolicies = map[string]bool{
+	"BLOCK": true,
+	"FIRST": true,
+	"LAST":  true,
+	"MIN":   true,
+	"MAX":   true,
+	"SUM":   true,
+}
+
+var aggregators = map[string]bool{
+	"AVG":   true,
+	"SUM":   true,
+	"MIN":   true,
+	"MAX":   true,
+	"RANGE": true,
+	"COUNT": true,
+	"FIRST": true,
+	"LAST":  true,
+	"STD.P": true,
+	"STD.S": true,
+	"VAR.P": true,
+	"VAR.S": true,
+}
+
+func parseTimestamp(s string) (int64, error) {
+	timestamp, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || timestamp < 0 {
+		return 0, errors.New("invalid timestamp")
+	}
+	return timestamp, nil
+}
+
+func parseRetention(s string) (int64, error) {
+	retention, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || retention < 0 {
+		return 0, errors.New("invalid retention")
+	}
+	return retention, nil
+}
+
+func parseDuplicatePolicy(s string) (string, error) {
+	policy := strings.ToUpper(s)
+	if !duplicatePolicies[policy] {
+		return "", errors.New("unknown duplicate policy")
+	}
+	return policy, nil
+}
+
+// parseSeriesOptions parses the [RETENTION retentionPeriod] [DUPLICATE_POLICY policy] options shared by TS.CREATE and TS.ADD,
+// and returns the value of ON_DUPLICATE if allowOnDuplicate is set.
+func parseSeriesOptions(parts []string, allowOnDuplicate bool) (retention int64, policy, onDuplicate string, err error) {
+	for i := 0; i < len(parts); i += 2 {
+		if i+1 >= len(parts) {
+			return 0, "", "", ErrWrongArgCount
+		}
+
+		switch option := strings.ToUpper(parts[i]); {
+		case option == "RETENTION":
+			retention, err = parseRetention(parts[i+1])
+		case option == "DUPLICATE_POLICY":
+			policy, err = parseDuplicatePolicy(parts[i+1])
+		case option == "ON_DUPLICATE" && allowOnDuplicate:
+			onDuplicate, err = parseDuplicatePolicy(parts[i+1])
+		default:
+			err = errors.New("syntax error")
+		}
+		if err != nil {
+			return 0, "", "", err
+		}
+	}
+	return retention, policy, onDuplicate, nil
+}
+
+type TSCreateCommand struct {
+	Key             string
+	Retention       int64  // Retention window in milliseconds, or zero to keep samples forever
+	DuplicatePolicy string // Empty uses the server default
+	LineMessage
+}
+
+// NewTSCreateCommand parses TS.CREATE key [RETENTION retentionPeriod] [DUPLICATE_POLICY policy].
+func NewTSCreateCommand(line LineMessage) (*TSCreateCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 2 {
+		return nil, ErrWrongArgCount
+	}
+
+	retention, policy, _, err := parseSeriesOptions(parts[2:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TSCreateCommand{
+		Key:             parts[1],
+		Retention:       retention,
+		DuplicatePolicy: policy,
+		LineMessage:     line,
+	}, nil
+}
+
+type TSAddCommand struct {
+	Key             string
+	Timestamp       int64
+	AutoTimestamp   bool // The timestamp was given as *, and is the time the leader appends the command at
+	Value           float64
+	Retention       int64  // Retention window used if the series is created by this command
+	DuplicatePolicy string // Duplicate policy used if the series is created by this command
+	OnDuplicate     string // Overrides the duplicate policy of the series for this sample
+	LineMessage
+}
+
+// NewTSAddCommand parses TS.ADD key <timestamp | *> value [RETENTION retentionPeriod] [DUPLICATE_POLICY policy] [ON_DUPLICATE policy].
+func NewTSAddCommand(line LineMessage) (*TSAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TSAddCommand{Key: parts[1], LineMessage: line}
+
+	var err error
+	if parts[2] == "*" {
+		cmd.AutoTimestamp = true
+	} else if cmd.Timestamp, err = parseTimestamp(parts[2]); err != nil {
+		return nil, err
+	}
+	if cmd.Value, err = parseFiniteFloat(parts[3]); err != nil {
+		return nil, err
+	}
+	if cmd.Retention, cmd.DuplicatePolicy, cmd.OnDuplicate, err = parseSeriesOptions(parts[4:], true); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// TSSample is a sample added by TS.MADD.
+type TSSample struct {
+	Key           string
+	Timestamp     int64
+	AutoTimestamp bool
+	Value         float64
+}
+
+type TSMAddCommand struct {
+	Samples []TSSample
+	LineMessage
+}
+
+// NewTSMAddCommand parses TS.MADD key <timestamp | *> value [key <timestamp | *> value ...].
+func NewTSMAddCommand(line LineMessage) (*TSMAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 || (len(parts)-1)%3 != 0 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TSMAddCommand{LineMessage: line}
+	for i := 1; i < len(parts); i += 3 {
+		sample := TSSample{Key: parts[i]}
+
+		var err error
+		if parts[i+1] == "*" {
+			sample.AutoTimestamp = true
+		} else if sample.Timestamp, err = parseTimestamp(parts[i+1]); err != nil {
+			return nil, err
+		}
+		if sample.Value, err = parseFiniteFloat(parts[i+2]); err != nil {
+			return nil, err
+		}
+		cmd.Samples = append(cmd.Samples, sample)
+	}
+	return cmd, nil
+}
+
+type TSGetCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewTSGetCommand(line LineMessage) (*TSGetCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &TSGetCommand{
+		Key:         parts[1],
+		LineMessage: line,
+	}, nil
+}
+
+// TSRangeCommand serves both TS.RANGE and TS.REVRANGE.
+type TSRangeCommand struct {
+	Key            string
+	From           int64
+	To             int64
+	Reverse        bool
+	FilterByTS     []int64
+	MinValue       *float64 // Set by FILTER_BY_VALUE
+	MaxValue       *float64 // Set by FILTER_BY_VALUE
+	Count          int      // Zero returns all samples
+	Aggregator     string   // Empty returns raw samples
+	BucketDuration int64
+	LineMessage
+}
+
+func NewTSRangeCommand(line LineMessage) (*TSRangeCommand, error) {
+	return newTSRangeCommand(line, false)
+}
+
+func NewTSRevRangeCommand(line LineMessage) (*TSRangeCommand, error) {
+	return newTSRangeCommand(line, true)
+}
+
+// newTSRangeCommand parses TS.RANGE key fromTimestamp toTimestamp [FILTER_BY_TS ts...] [FILTER_BY_VALUE min max]
+// [COUNT count] [AGGREGATION aggregator bucketDuration], where - and + stand for the earliest and latest timestamps.
+func newTSRangeCommand(line LineMessage, reverse bool) (*TSRangeCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TSRangeCommand{Key: parts[1], Reverse: reverse, LineMessage: line}
+
+	var err error
+	if parts[2] == "-" {
+		cmd.From = 0
+	} else if cmd.From, err = parseTimestamp(parts[2]); err != nil {
+		return nil, err
+	}
+	if parts[3] == "+" {
+		cmd.To = math.MaxInt64
+	} else if cmd.To, err = parseTimestamp(parts[3]); err != nil {
+		return nil, err
+	}
+
+	for i := 4; i < len(parts); i++ {
+		args := parts[i+1:]
+		switch strings.ToUpper(parts[i]) {
+		case "FILTER_BY_TS":
+			for len(args) > 0 {
+				timestamp, err := parseTimestamp(args[0])
+				if err != nil {
+					break
+				}
+				cmd.FilterByTS = append(cmd.FilterByTS, timestamp)
+				args = args[1:]
+				i++
+			}
+			if len(cmd.FilterByTS) == 0 {
+				return nil, errors.New("FILTER_BY_TS needs at least one timestamp")
+			}
+		case "FILTER_BY_VALUE":
+			if len(args) < 2 {
+				return nil, ErrWrongArgCount
+			}
+			minValue, err := parseFiniteFloat(args[0])
+			if err != nil {
+				return nil, err
+			}
+			maxValue, err := parseFiniteFloat(args[1])
+			if err != nil {
+				return nil, err
+			}
+			cmd.MinValue, cmd.MaxValue = &minValue, &maxValue
+			i += 2
+		case "COUNT":
+			if len(args) < 1 {
+				return nil, ErrWrongArgCount
+			}
+			if cmd.Count, err = strconv.Atoi(args[0]); err != nil || cmd.Count <= 0 {
+				return nil, errors.New("invalid count")
+			}
+			i++
+		case "AGGREGATION":
+			if len(args) < 2 {
+				return nil, ErrWrongArgCount
+			}
+			cmd.Aggregator = strings.ToUpper(args[0])
+			if !aggregators[cmd.Aggregator] {
+				return nil, errors.New("unknown aggregation type")
+			}
+			if cmd.BucketDuration, err = strconv.ParseInt(args[1], 10, 64); err != nil || cmd.BucketDuration <= 0 {
+				return nil, errors.New("invalid bucket duration")
+			}
+			i += 2
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
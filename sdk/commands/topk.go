@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxTopKIncrement bounds TOPK.INCRBY, as decaying a bucket takes a step per unit of the increment.
+const maxTopKIncrement = 100000
+
+type TopKReserveCommand struct {
+	Key   string
+	K     uint
+	Width uint // Zero uses the default width
+	Depth uint // Zero uses the default depth
+	Decay float64
+	LineMessage
+}
+
+// NewTopKReserveCommand parses TOPK.RESERVE key topk [width depth decay].
+func NewTopKReserveCommand(line LineMessage) (*TopKReserveCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 && len(parts) != 6 {
+		return nil, ErrWrongArgCount
+	}
+
+	k, err := strconv.ParseUint(parts[2], 10, 0)
+	if err != nil || k == 0 || k > maxSketchCounters {
+		return nil, errors.New("invalid k")
+	}
+	cmd := &TopKReserveCommand{Key: parts[1], K: uint(k), LineMessage: line}
+	if len(parts) == 3 {
+		return cmd, nil
+	}
+
+	width, err := strconv.ParseUint(parts[3], 10, 0)
+	if err != nil || width == 0 {
+		return nil, errors.New("invalid width")
+	}
+	depth, err := strconv.ParseUint(parts[4], 10, 0)
+	if err != nil || depth == 0 {
+		return nil, errors.New("invalid depth")
+	}
+	if width > maxSketchCounters/depth {
+		return nil, errors.New("sketch is too large")
+	}
+	decay, err := strconv.ParseFloat(parts[5], 64)
+	if err != nil || decay <= 0 || decay > 1 {
+		return nil, errors.New("invalid decay")
+	}
+
+	cmd.Width, cmd.Depth, cmd.Decay = uint(width), uint(depth), decay
+	return cmd, nil
+}
+
+// TopKIncrement is an item of a Top-K sketch along with the amount to increment its count by.
+type TopKIncrement struct {
+	Item      string
+	Increment uint64
+}
+
+// TopKAddCommand serves both TOPK.ADD and TOPK.INCRBY.
+type TopKAddCommand struct {
+	Key        string
+	Increments []TopKIncrement
+	LineMessage
+}
+
+// NewTopKAddCommand parses TOPK.ADD key item [item ...], which increments each item by one.
+func NewTopKAddCommand(line LineMessage) (*TopKAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TopKAddCommand{Key: parts[1], LineMessage: line}
+	for _, item := range parts[2:] {
+		cmd.Increments = append(cmd.Increments, TopKIncrement{Item: item, Increment: 1})
+	}
+	return cmd, nil
+}
+
+// NewTopKIncrByCommand parses TOPK.INCRBY key item increment [item increment ...].
+func NewTopKIncrByCommand(line LineMessage) (*TopKAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 || len(parts)%2 != 0 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TopKAddCommand{Key: parts[1], LineMessage: line}
+	for i := 2; i < len(parts); i += 2 {
+		increment, err := strconv.ParseUint(parts[i+1], 10, 64)
+		if err != nil || increment == 0 || increment > maxTopKIncrement {
+			return nil, errors.New("increment must be between 1 and 100000")
+		}
+		cmd.Increments = append(cmd.Increments, TopKIncrement{Item: parts[i], Increment: increment})
+	}
+	return cmd, nil
+}
+
+// TopKItemsCommand serves both TOPK.QUERY and TOPK.COUNT.
+type TopKItemsCommand struct {
+	Key   string
+	Items []string
+	LineMessage
+}
+
+func NewTopKItemsCommand(line LineMessage) (*TopKItemsCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &TopKItemsCommand{
+		Key:         parts[1],
+		Items:       parts[2:],
+		LineMessage: line,
+	}, nil
+}
+
+type TopKListCommand struct {
+	Key       string
+	WithCount bool
+	LineMessage
+}
+
+// NewTopKListCommand parses TOPK.LIST key [WITHCOUNT].
+func NewTopKListCommand(line LineMessage) (*TopKListCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &TopKListCommand{Key: parts[1], LineMessage: line}
+	if len(parts) == 3 {
+		if strings.ToUpper(parts[2]) != "WITHCOUNT" {
+			return nil, errors.New("syntax error")
+		}
+		cmd.WithCount = true
+	}
+	return cmd, nil
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxVectorDim   = 32768 // Bounds the dimension of the vectors in a vector set
+	maxVectorCount = 10000 // Bounds the COUNT and EF of a similarity search
+)
+
+// parseVector parses VALUES dim v1 .. vdim from the start of parts, and returns the vector along with the parts after it.
+func parseVector(parts []string) ([]float32, []string, error) {
+	if len(parts) < 2 || strings.ToUpper(parts[0]) != "VALUES" {
+		return nil, nil, errors.New("syntax error")
+	}
+	dim, err := strconv.Atoi(parts[1])
+	if err != nil || dim < 1 || dim > maxVectorDim {
+		return nil, nil, errors.New("invalid dimension")
+	}
+	if len(parts) < 2+dim {
+		return nil, nil, ErrWrongArgCount
+	}
+
+	vector := make([]float32, dim)
+	for i, part := range parts[2 : 2+dim] {
+		v, err := strconv.ParseFloat(part, 32)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, nil, ErrorNotANumber
+		}
+		vector[i] = float32(v)
+	}
+	return vector, parts[2+dim:], nil
+}
+
+type VAddCommand struct {
+	Key        string
+	Metric     string // Empty unless given, which keeps the metric of an existing set, or uses COSINE for a new one
+	Vector     []float32
+	Element    string
+	Attributes map[string]string
+	LineMessage
+}
+
+// NewVAddCommand parses VADD key [METRIC COSINE|L2|IP] VALUES dim v1 .. vdim element [ATTR name value ...].
+func NewVAddCommand(line LineMessage) (*VAddCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 5 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &VAddCommand{Key: parts[1], LineMessage: line}
+	rest := parts[2:]
+	if strings.ToUpper(rest[0]) == "METRIC" {
+		cmd.Metric = strings.ToUpper(rest[1])
+		if cmd.Metric != "COSINE" && cmd.Metric != "L2" && cmd.Metric != "IP" {
+			return nil, errors.New("metric must be COSINE, L2 or IP")
+		}
+		rest = rest[2:]
+	}
+
+	vector, rest, err := parseVector(rest)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) == 0 {
+		return nil, ErrWrongArgCount
+	}
+	cmd.Vector, cmd.Element = vector, rest[0]
+
+	for i := 1; i < len(rest); i += 3 {
+		if strings.ToUpper(rest[i]) != "ATTR" || i+2 >= len(rest) {
+			return nil, errors.New("syntax error")
+		}
+		if cmd.Attributes == nil {
+			cmd.Attributes = make(map[string]string)
+		}
+		cmd.Attributes[rest[i+1]] = rest[i+2]
+	}
+	return cmd, nil
+}
+
+type VRemCommand struct {
+	Key     string
+	Element string
+	LineMessage
+}
+
+func NewVRemCommand(line LineMessage) (*VRemCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 3 {
+		return nil, ErrWrongArgCount
+	}
+	return &VRemCommand{Key: parts[1], Element: parts[2], LineMessage: line}, nil
+}
+
+type VSimCommand struct {
+	Key        string
+	Vector     []float32 // Nil when searching for the neighbours of Element
+	Element    string
+	Count      int
+	EF         int
+	Filter     map[string]string
+	WithScores bool
+	LineMessage
+}
+
+// NewVSimCommand parses VSIM key (VALUES dim v1 .. vdim | ELE element) [COUNT count] [EF ef]
+// [FILTER name value ...] [WITHSCORES].
+func NewVSimCommand(line LineMessage) (*VSimCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) < 4 {
+		return nil, ErrWrongArgCount
+	}
+
+	cmd := &VSimCommand{Key: parts[1], Count: 10, LineMessage: line}
+	var rest []string
+	if strings.ToUpper(parts[2]) == "ELE" {
+		cmd.Element, rest = parts[3], parts[4:]
+	} else {
+		vector, remaining, err := parseVector(parts[2:])
+		if err != nil {
+			return nil, err
+		}
+		cmd.Vector, rest = vector, remaining
+	}
+
+	for i := 0; i < len(rest); i++ {
+		switch strings.ToUpper(rest[i]) {
+		case "COUNT", "EF":
+			if i+1 >= len(rest) {
+				return nil, errors.New("syntax error")
+			}
+			n, err := strconv.Atoi(rest[i+1])
+			if err != nil || n < 1 || n > maxVectorCount {
+				return nil, errors.New("invalid " + strings.ToLower(rest[i]))
+			}
+			if strings.ToUpper(rest[i]) == "COUNT" {
+				cmd.Count = n
+			} else {
+				cmd.EF = n
+			}
+			i++
+		case "FILTER":
+			if i+2 >= len(rest) {
+				return nil, errors.New("syntax error")
+			}
+			if cmd.Filter == nil {
+				cmd.Filter = make(map[string]string)
+			}
+			cmd.Filter[rest[i+1]] = rest[i+2]
+			i += 2
+		case "WITHSCORES":
+			cmd.WithScores = true
+		default:
+			return nil, errors.New("syntax error")
+		}
+	}
+	return cmd, nil
+}
+
+type VCardCommand struct {
+	Key string
+	LineMessage
+}
+
+func NewVCardCommand(line LineMessage) (*VCardCommand, error) {
+	parts := strings.Split(line.String(), " ")
+	if len(parts) != 2 {
+		return nil, ErrWrongArgCount
+	}
+	return &VCardCommand{Key: parts[1], LineMessage: line}, nil
+}
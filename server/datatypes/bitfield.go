@@ -1 +1,162 @@
 package datatypes
+
+// BitFieldType is the encoding of an integer packed into a bitmap, such as i5 or u16.
+type BitFieldType struct {
+	Signed bool
+	Bits   uint // Between 1 and 64 for signed integers, and 1 and 63 for unsigned ones
+}
+
+// BitFieldOverflow decides what happens when a write to a bit field does not fit in its type.
+type BitFieldOverflow string
+
+const (
+	OverflowWrap BitFieldOverflow = "WRAP" // Keep the low bits of the result, as with two's complement arithmetic
+	OverflowSat  BitFieldOverflow = "SAT"  // Clamp the result to the smallest or largest value of the type
+	OverflowFail BitFieldOverflow = "FAIL" // Leave the field unchanged
+)
+
+func (t BitFieldType) min() int64 {
+	if !t.Signed {
+		return 0
+	}
+	return -1 << (t.Bits - 1)
+}
+
+func (t BitFieldType) max() int64 {
+	if !t.Signed {
+		return int64(1<<t.Bits - 1)
+	}
+	return int64(1<<(t.Bits-1) - 1)
+}
+
+// GetField returns the integer of type t stored at the bit offset.
+func (b *Bitmap) GetField(offset uint64, t BitFieldType) int64 {
+	raw := b.getBits(offset, t.Bits)
+	if t.Signed && t.Bits < 64 && raw&(1<<(t.Bits-1)) != 0 {
+		raw |= ^uint64(0) << t.Bits
+	}
+	return int64(raw)
+}
+
+// SetField stores value as an integer of type t at the bit offset, and returns the previous value.
+//
+// The write is skipped, and false returned, if the value does not fit in the type and overflow is OverflowFail.
+func (b *Bitmap) SetField(offset uint64, t BitFieldType, value int64, overflow BitFieldOverflow) (int64, bool) {
+	old := b.GetField(offset, t)
+
+	var ok bool
+	if t.Signed {
+		value, ok = t.addSigned(value, 0, overflow)
+	} else {
+		value, ok = t.addUnsigned(uint64(value), 0, overflow)
+	}
+	if !ok {
+		return old, false
+	}
+
+	b.setBits(offset, t.Bits, uint64(value))
+	return old, true
+}
+
+// IncrField adds incr to the integer of type t stored at the bit offset, and returns the new value.
+//
+// The write is skipped, and false returned, if the result does not fit in the type and overflow is OverflowFail.
+func (b *Bitmap) IncrField(offset uint64, t BitFieldType, incr int64, overflow BitFieldOverflow) (int64, bool) {
+	old := b.GetField(offset, t)
+
+	var value int64
+	var ok bool
+	if t.Signed {
+		value, ok = t.addSigned(old, incr, overflow)
+	} else {
+		value, ok = t.addUnsigned(uint64(old), incr, overflow)
+	}
+	if !ok {
+		return old, false
+	}
+
+	b.setBits(offset, t.Bits, uint64(value))
+	return value, true
+}
+
+// addUnsigned returns value+incr handled according to overflow, and false if it overflowed with OverflowFail.
+func (t BitFieldType) addUnsigned(value uint64, incr int64, overflow BitFieldOverflow) (int64, bool) {
+	max := uint64(t.max())
+	mask := max
+
+	var overflowed, underflowed bool
+	if incr > 0 {
+		overflowed = value > max || uint64(incr) > max-value
+	} else if incr < 0 {
+		underflowed = uint64(-incr) > value
+	} else {
+		overflowed = value > max
+	}
+
+	switch {
+	case !overflowed && !underflowed:
+		return int64(value + uint64(incr)), true
+	case overflow == OverflowFail:
+		return 0, false
+	case overflow == OverflowSat && overflowed:
+		return int64(max), true
+	case overflow == OverflowSat:
+		return 0, true
+	default:
+		return int64((value + uint64(incr)) & mask), true
+	}
+}
+
+// addSigned returns value+incr handled according to overflow, and false if it overflowed with OverflowFail.
+func (t BitFieldType) addSigned(value, incr int64, overflow BitFieldOverflow) (int64, bool) {
+	min, max := t.min(), t.max()
+
+	// An i64 cannot overflow when adding a value of the opposite sign, and skipping
+	// that case keeps the subtractions below from overflowing themselves.
+	var overflowed, underflowed bool
+	if value > max || (incr > 0 && (value >= 0 || t.Bits < 64) && incr > max-value) {
+		overflowed = true
+	} else if value < min || (incr < 0 && (value < 0 || t.Bits < 64) && incr < min-value) {
+		underflowed = true
+	}
+
+	switch {
+	case !overflowed && !underflowed:
+		return value + incr, true
+	case overflow == OverflowFail:
+		return 0, false
+	case overflow == OverflowSat && overflowed:
+		return max, true
+	case overflow == OverflowSat:
+		return min, true
+	default:
+		sum := uint64(value) + uint64(incr)
+		if t.Bits < 64 {
+			sum &= 1<<t.Bits - 1
+			if sum&(1<<(t.Bits-1)) != 0 {
+				sum |= ^uint64(0) << t.Bits
+			}
+		}
+		return int64(sum), true
+	}
+}
+
+// getBits reads width bits starting at the bit offset as an unsigned integer, most significant bit first.
+func (b *Bitmap) getBits(offset uint64, width uint) uint64 {
+	var v uint64
+	for i := uint64(0); i < uint64(width); i++ {
+		v <<= 1
+		if b.GetBit(offset + i) {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// setBits writes the low width bits of v starting at the bit offset, most significant bit first.
+func (b *Bitmap) setBits(offset uint64, width uint, v uint64) {
+	b.grow((offset + uint64(width) + 7) / 8)
+	for i := uint64(0); i < uint64(width); i++ {
+		b.SetBit(offset+i, v&(1<<(uint64(width)-1-i)) != 0)
+	}
+}
@@ -1 +1,105 @@
 package datatypes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBitmap_GetField(t *testing.T) {
+	b := NewBitmap()
+	// 0b10110000
+	b.SetBit(0, true)
+	b.SetBit(2, true)
+	b.SetBit(3, true)
+
+	tests := []struct {
+		offset uint64
+		t      BitFieldType
+		want   int64
+	}{
+		{0, BitFieldType{Signed: false, Bits: 4}, 11},
+		{0, BitFieldType{Signed: true, Bits: 4}, -5},
+		{1, BitFieldType{Signed: false, Bits: 3}, 3},
+		{4, BitFieldType{Signed: false, Bits: 8}, 0},
+		{0, BitFieldType{Signed: true, Bits: 64}, math.MinInt64 + 0x3000000000000000},
+	}
+	for _, tt := range tests {
+		if got := b.GetField(tt.offset, tt.t); got != tt.want {
+			t.Errorf("GetField(%d, %+v) = %d, want %d", tt.offset, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestBitmap_SetField(t *testing.T) {
+	b := NewBitmap()
+	u8 := BitFieldType{Signed: false, Bits: 8}
+
+	if old, ok := b.SetField(100, u8, 255, OverflowWrap); !ok || old != 0 {
+		t.Errorf("SetField(100, u8, 255) = %d, %v, want 0, true", old, ok)
+	}
+	if old, ok := b.SetField(100, u8, 7, OverflowWrap); !ok || old != 255 {
+		t.Errorf("SetField(100, u8, 7) = %d, %v, want 255, true", old, ok)
+	}
+	if b.Len() != 14 {
+		t.Errorf("Len() = %d, want 14", b.Len())
+	}
+
+	b.SetField(0, u8, 256, OverflowWrap)
+	if got := b.GetField(0, u8); got != 0 {
+		t.Errorf("wrapped u8 = %d, want 0", got)
+	}
+	b.SetField(0, u8, 256, OverflowSat)
+	if got := b.GetField(0, u8); got != 255 {
+		t.Errorf("saturated u8 = %d, want 255", got)
+	}
+	if _, ok := b.SetField(0, u8, 256, OverflowFail); ok {
+		t.Errorf("SetField with FAIL succeeded on an overflowing value")
+	}
+	if got := b.GetField(0, u8); got != 255 {
+		t.Errorf("u8 = %d after a failed SetField, want 255", got)
+	}
+}
+
+func TestBitmap_IncrField(t *testing.T) {
+	i8 := BitFieldType{Signed: true, Bits: 8}
+	u2 := BitFieldType{Signed: false, Bits: 2}
+	i64 := BitFieldType{Signed: true, Bits: 64}
+	u63 := BitFieldType{Signed: false, Bits: 63}
+
+	tests := []struct {
+		name     string
+		t        BitFieldType
+		initial  int64
+		incr     int64
+		overflow BitFieldOverflow
+		want     int64
+		wantOk   bool
+	}{
+		{"i8 in range", i8, 100, 27, OverflowWrap, 127, true},
+		{"i8 wrap up", i8, 100, 28, OverflowWrap, -128, true},
+		{"i8 wrap down", i8, -100, -29, OverflowWrap, 127, true},
+		{"i8 sat up", i8, 100, 100, OverflowSat, 127, true},
+		{"i8 sat down", i8, -100, -100, OverflowSat, -128, true},
+		{"i8 fail", i8, 100, 100, OverflowFail, 100, false},
+		{"u2 wrap up", u2, 3, 1, OverflowWrap, 0, true},
+		{"u2 wrap down", u2, 0, -1, OverflowWrap, 3, true},
+		{"u2 sat down", u2, 1, -5, OverflowSat, 0, true},
+		{"u2 fail down", u2, 1, -2, OverflowFail, 1, false},
+		{"i64 wrap", i64, math.MaxInt64, 1, OverflowWrap, math.MinInt64, true},
+		{"i64 sat", i64, math.MinInt64, -1, OverflowSat, math.MinInt64, true},
+		{"i64 opposite signs", i64, math.MinInt64, math.MaxInt64, OverflowFail, -1, true},
+		{"u63 sat", u63, math.MaxInt64, 1, OverflowSat, math.MaxInt64, true},
+	}
+	for _, tt := range tests {
+		b := NewBitmap()
+		b.SetField(0, tt.t, tt.initial, OverflowWrap)
+
+		got, ok := b.IncrField(0, tt.t, tt.incr, tt.overflow)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("%s: IncrField = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+		if stored := b.GetField(0, tt.t); stored != tt.want {
+			t.Errorf("%s: GetField = %d after IncrField, want %d", tt.name, stored, tt.want)
+		}
+	}
+}
@@ -1 +1,250 @@
 package datatypes
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/bits"
+)
+
+// BitOperation is a bitwise operation which combines bitmaps.
+type BitOperation string
+
+const (
+	BitAnd BitOperation = "AND"
+	BitOr  BitOperation = "OR"
+	BitXor BitOperation = "XOR"
+	BitNot BitOperation = "NOT"
+)
+
+// Bitmap is a growable array of bits, stored most significant bit first within each byte.
+//
+// Bits past the end of the bitmap read as zero, and setting one grows the bitmap to hold it.
+type Bitmap struct {
+	Name  string
+	bytes []byte
+}
+
+type bitmapJSON struct {
+	Name  string `json:"name"`
+	Bytes []byte `json:"bytes"`
+}
+
+func (b *Bitmap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&bitmapJSON{
+		Name:  b.Name,
+		Bytes: b.bytes,
+	})
+}
+
+func (b *Bitmap) UnmarshalJSON(data []byte) error {
+	var v bitmapJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	b.Name = v.Name
+	b.bytes = v.Bytes
+	return nil
+}
+
+func NewBitmap() *Bitmap {
+	return &Bitmap{Name: "bitmap"}
+}
+
+func (b *Bitmap) GetName() string {
+	return b.Name
+}
+
+// Len returns the length of the bitmap in bytes.
+func (b *Bitmap) Len() int {
+	return len(b.bytes)
+}
+
+// SetBit sets the bit at offset to value, and returns its previous value.
+func (b *Bitmap) SetBit(offset uint64, value bool) bool {
+	b.grow(offset/8 + 1)
+
+	idx, mask := offset/8, byte(0x80>>(offset%8))
+	old := b.bytes[idx]&mask != 0
+	if value {
+		b.bytes[idx] |= mask
+	} else {
+		b.bytes[idx] &^= mask
+	}
+	return old
+}
+
+// GetBit returns the value of the bit at offset.
+func (b *Bitmap) GetBit(offset uint64) bool {
+	idx := offset / 8
+	if idx >= uint64(len(b.bytes)) {
+		return false
+	}
+	return b.bytes[idx]&(0x80>>(offset%8)) != 0
+}
+
+// Count returns the number of set bits between start and end inclusive.
+//
+// The range is given in bytes, or in bits if bitMode is set, and negative
+// positions count back from the end of the bitmap.
+func (b *Bitmap) Count(start, end int64, bitMode bool) int {
+	first, last, ok := b.bitRange(start, end, bitMode)
+	if !ok {
+		return 0
+	}
+
+	firstByte, lastByte := first/8, last/8
+	if firstByte == lastByte {
+		return bits.OnesCount8(b.bytes[firstByte] & rangeMask(first%8, last%8))
+	}
+
+	count := bits.OnesCount8(b.bytes[firstByte] & rangeMask(first%8, 7))
+	count += countBits(b.bytes[firstByte+1 : lastByte])
+	count += bits.OnesCount8(b.bytes[lastByte] & rangeMask(0, last%8))
+	return count
+}
+
+// Pos returns the offset of the first bit set to value between start and end inclusive, or -1 if there is none.
+//
+// The range is given as for Count. When searching for a clear bit in a bitmap with no explicit end,
+// the bitmap is treated as padded with zeros, so the bit right after its end is returned if every bit in range is set.
+func (b *Bitmap) Pos(value bool, start, end int64, bitMode, hasEnd bool) int64 {
+	if len(b.bytes) == 0 {
+		if value {
+			return -1
+		}
+		return 0
+	}
+
+	first, last, ok := b.bitRange(start, end, bitMode)
+	if !ok {
+		return -1
+	}
+
+	var skip byte
+	if !value {
+		skip = 0xff
+	}
+
+	for offset := first; offset <= last; {
+		if offset%8 == 0 && offset+7 <= last && b.bytes[offset/8] == skip {
+			offset += 8
+			continue
+		}
+		if b.GetBit(uint64(offset)) == value {
+			return offset
+		}
+		offset++
+	}
+
+	if !value && !hasEnd {
+		return last + 1
+	}
+	return -1
+}
+
+// BitOp combines the sources with a bitwise operation, and returns the result as a new bitmap.
+//
+// Shorter sources are treated as padded with zeros to the length of the longest one. NOT takes a single source.
+func BitOp(op BitOperation, sources ...*Bitmap) *Bitmap {
+	size := 0
+	for _, src := range sources {
+		size = max(size, len(src.bytes))
+	}
+
+	result := NewBitmap()
+	if size == 0 {
+		return result
+	}
+	result.bytes = make([]byte, size)
+	copy(result.bytes, sources[0].bytes)
+
+	if op == BitNot {
+		applyWords(result.bytes, result.bytes, func(dst, src uint64) uint64 { return ^src })
+		return result
+	}
+
+	var combine func(dst, src uint64) uint64
+	switch op {
+	case BitAnd:
+		combine = func(dst, src uint64) uint64 { return dst & src }
+	case BitOr:
+		combine = func(dst, src uint64) uint64 { return dst | src }
+	case BitXor:
+		combine = func(dst, src uint64) uint64 { return dst ^ src }
+	}
+
+	for _, src := range sources[1:] {
+		applyWords(result.bytes[:len(src.bytes)], src.bytes, combine)
+		if op == BitAnd {
+			clear(result.bytes[len(src.bytes):])
+		}
+	}
+	return result
+}
+
+// applyWords sets every byte of dst to the result of combining it with the same byte of src,
+// eight bytes at a time. src must be at least as long as dst.
+func applyWords(dst, src []byte, combine func(dst, src uint64) uint64) {
+	i := 0
+	for ; i+8 <= len(dst); i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], combine(binary.LittleEndian.Uint64(dst[i:]), binary.LittleEndian.Uint64(src[i:])))
+	}
+	for ; i < len(dst); i++ {
+		dst[i] = byte(combine(uint64(dst[i]), uint64(src[i])))
+	}
+}
+
+// bitRange resolves a range given as for Count into inclusive bit offsets, and reports whether it is non-empty.
+func (b *Bitmap) bitRange(start, end int64, bitMode bool) (int64, int64, bool) {
+	size := int64(len(b.bytes))
+	if bitMode {
+		size *= 8
+	}
+
+	if start < 0 {
+		start = max(start+size, 0)
+	}
+	if end < 0 {
+		end = max(end+size, 0)
+	}
+	end = min(end, size-1)
+	if size == 0 || start > end {
+		return 0, 0, false
+	}
+
+	if bitMode {
+		return start, end, true
+	}
+	return start * 8, end*8 + 7, true
+}
+
+func (b *Bitmap) grow(size uint64) {
+	if size <= uint64(len(b.bytes)) {
+		return
+	}
+	if size <= uint64(cap(b.bytes)) {
+		b.bytes = b.bytes[:size]
+		return
+	}
+	grown := make([]byte, size, max(size, 2*uint64(cap(b.bytes))))
+	copy(grown, b.bytes)
+	b.bytes = grown
+}
+
+// rangeMask returns a byte with the bits from position first to last inclusive set, counting from the most significant bit.
+func rangeMask(first, last int64) byte {
+	return byte(0xff>>first) & byte(0xff<<(7-last))
+}
+
+func countBits(b []byte) int {
+	count := 0
+	i := 0
+	for ; i+8 <= len(b); i += 8 {
+		count += bits.OnesCount64(binary.LittleEndian.Uint64(b[i:]))
+	}
+	for ; i < len(b); i++ {
+		count += bits.OnesCount8(b[i])
+	}
+	return count
+}
@@ -1 +1,150 @@
 package datatypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBitmap_SetBit(t *testing.T) {
+	b := NewBitmap()
+
+	if b.SetBit(7, true) {
+		t.Errorf("SetBit(7, true) = true, want false")
+	}
+	if !b.SetBit(7, true) {
+		t.Errorf("SetBit(7, true) = false on the second call, want true")
+	}
+	if b.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", b.Len())
+	}
+
+	b.SetBit(100, true)
+	if b.Len() != 13 {
+		t.Errorf("Len() = %d, want 13", b.Len())
+	}
+	if !b.GetBit(100) || b.GetBit(99) || b.GetBit(1000) {
+		t.Errorf("GetBit returned unexpected values around offset 100")
+	}
+
+	if !b.SetBit(7, false) || b.GetBit(7) {
+		t.Errorf("SetBit(7, false) did not clear the bit")
+	}
+}
+
+func TestBitmap_Count(t *testing.T) {
+	b := NewBitmap()
+	// "foobar", as in the Redis documentation
+	for i, c := range []byte("foobar") {
+		for j := 0; j < 8; j++ {
+			if c&(0x80>>j) != 0 {
+				b.SetBit(uint64(i*8+j), true)
+			}
+		}
+	}
+
+	tests := []struct {
+		start, end int64
+		bitMode    bool
+		want       int
+	}{
+		{0, -1, false, 26},
+		{0, 0, false, 4},
+		{1, 1, false, 6},
+		{1, 1, true, 1},
+		{5, 30, true, 17},
+		{-2, -1, false, 7},
+		{4, 2, false, 0},
+		{10, 100, false, 0},
+	}
+	for _, tt := range tests {
+		if got := b.Count(tt.start, tt.end, tt.bitMode); got != tt.want {
+			t.Errorf("Count(%d, %d, %v) = %d, want %d", tt.start, tt.end, tt.bitMode, got, tt.want)
+		}
+	}
+}
+
+func TestBitmap_Pos(t *testing.T) {
+	b := NewBitmap()
+	for i := uint64(0); i < 16; i++ {
+		b.SetBit(i, true)
+	}
+	b.SetBit(20, true)
+
+	tests := []struct {
+		value      bool
+		start, end int64
+		bitMode    bool
+		hasEnd     bool
+		want       int64
+	}{
+		{true, 0, -1, false, false, 0},
+		{false, 0, -1, false, false, 16},
+		{true, 2, -1, false, false, 20},
+		{true, 17, 19, true, true, -1},
+		{false, 0, 1, false, true, -1},
+	}
+	for _, tt := range tests {
+		if got := b.Pos(tt.value, tt.start, tt.end, tt.bitMode, tt.hasEnd); got != tt.want {
+			t.Errorf("Pos(%v, %d, %d, %v, %v) = %d, want %d", tt.value, tt.start, tt.end, tt.bitMode, tt.hasEnd, got, tt.want)
+		}
+	}
+
+	full := NewBitmap()
+	for i := uint64(0); i < 8; i++ {
+		full.SetBit(i, true)
+	}
+	if got := full.Pos(false, 0, -1, false, false); got != 8 {
+		t.Errorf("Pos(false) on a full byte = %d, want 8", got)
+	}
+	if got := NewBitmap().Pos(true, 0, -1, false, false); got != -1 {
+		t.Errorf("Pos(true) on an empty bitmap = %d, want -1", got)
+	}
+}
+
+func TestBitOp(t *testing.T) {
+	a, b := NewBitmap(), NewBitmap()
+	for _, offset := range []uint64{1, 3, 70} {
+		a.SetBit(offset, true)
+	}
+	for _, offset := range []uint64{3, 5} {
+		b.SetBit(offset, true)
+	}
+
+	and := BitOp(BitAnd, a, b)
+	if and.Len() != a.Len() || and.Count(0, -1, false) != 1 || !and.GetBit(3) {
+		t.Errorf("AND = %v, want only bit 3 set", and.bytes)
+	}
+
+	or := BitOp(BitOr, a, b)
+	if or.Count(0, -1, false) != 4 || !or.GetBit(70) {
+		t.Errorf("OR = %v, want bits 1, 3, 5 and 70 set", or.bytes)
+	}
+
+	xor := BitOp(BitXor, a, b)
+	if xor.Count(0, -1, false) != 3 || xor.GetBit(3) {
+		t.Errorf("XOR = %v, want bits 1, 5 and 70 set", xor.bytes)
+	}
+
+	not := BitOp(BitNot, b)
+	if not.Len() != 1 || not.Count(0, -1, false) != 6 || not.GetBit(3) {
+		t.Errorf("NOT = %v, want every bit but 3 and 5 set", not.bytes)
+	}
+}
+
+func TestBitmap_JSON(t *testing.T) {
+	b := NewBitmap()
+	b.SetBit(42, true)
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &Bitmap{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetName() != "bitmap" || restored.Len() != b.Len() || !restored.GetBit(42) {
+		t.Errorf("restored bitmap does not match the original")
+	}
+}
@@ -1,86 +1,284 @@
 package datatypes
 
 import (
-	"hash"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"hash/fnv"
 	"math"
 )
 
+const (
+	DefaultBloomExpansion = 2       // How many times larger each new sub-filter of a scaling filter is than the last
+	MaxBloomFilterBits    = 1 << 30 // Bounds the number of bits across the sub-filters of a filter, as BF.RESERVE does
+	bloomTighteningRatio  = 0.5     // How much lower the error rate of each new sub-filter is than the last's
+)
+
+// ErrFilterFull is returned when adding an item to a filter which already holds its capacity and can't scale,
+// either because it is non-scaling or because another sub-filter would take it past MaxBloomFilterBits.
+var ErrFilterFull = errors.New("FilterFull")
+
+// BloomFilter is a probabilistic membership filter made of a stack of sub-filters.
+//
+// Items go into the newest sub-filter until it holds its capacity. A scaling filter then adds a sub-filter expansion
+// times larger with a tighter error rate, so that the error rate of the whole filter stays bounded as it grows,
+// while a non-scaling filter, whose expansion is zero, refuses further items.
 type BloomFilter struct {
-	bitSet        []bool
-	numHashes     uint
-	bitArraySize  uint
-	expectedItems uint
-	hashes        []hash.Hash64
+	filters       []*bloomSubFilter
+	expansion     uint
+	insertedItems uint
+	Name          string `json:"name"`
+}
+
+// bloomSubFilter is a fixed-size Bloom filter, whose bits are packed least significant bit first within each byte.
+type bloomSubFilter struct {
+	bits      []byte
+	numBits   uint64
+	numHashes uint
+	capacity  uint
+	errorRate float64
+	count     uint
 }
 
-// NewBloomFilter creates a new Bloom filter with the specified number of expected items (expectedItems)
-// and false positive probability (p).
+// NewBloomFilter creates a new scaling Bloom filter with the specified number of expected items (expectedItems)
+// and false positive probability (p) for its first sub-filter.
 func NewBloomFilter(expectedItems uint, errorRate float64) *BloomFilter {
-	bitArraySize := optimalM(expectedItems, errorRate)
-	numHashes := optimalK(expectedItems, bitArraySize)
-	hashes := make([]hash.Hash64, numHashes)
+	return NewBloomFilterWithExpansion(expectedItems, errorRate, DefaultBloomExpansion)
+}
 
-	// Initialize hash functions
-	for i := range hashes {
-		hashes[i] = fnv.New64a() // Using FNV-1a hash function
+// NewBloomFilterWithExpansion creates a new Bloom filter which grows by the given factor when it is full,
+// or which is non-scaling if expansion is zero.
+func NewBloomFilterWithExpansion(expectedItems uint, errorRate float64, expansion uint) *BloomFilter {
+	return &BloomFilter{
+		filters:   []*bloomSubFilter{newBloomSubFilter(expectedItems, errorRate)},
+		expansion: expansion,
+		Name:      "bloom",
 	}
+}
 
-	return &BloomFilter{
-		bitSet:        make([]bool, bitArraySize),
-		numHashes:     numHashes,
-		bitArraySize:  bitArraySize,
-		expectedItems: expectedItems,
-		hashes:        hashes,
+func newBloomSubFilter(capacity uint, errorRate float64) *bloomSubFilter {
+	numBits := optimalM(capacity, errorRate)
+	return &bloomSubFilter{
+		bits:      make([]byte, (numBits+7)/8),
+		numBits:   uint64(numBits),
+		numHashes: optimalK(capacity, numBits),
+		capacity:  capacity,
+		errorRate: errorRate,
 	}
 }
 
+func (bf *BloomFilter) GetName() string {
+	return bf.Name
+}
+
+type bloomFilterJSON struct {
+	Filters       []bloomSubFilterJSON `json:"filters"`
+	Expansion     uint                 `json:"expansion"`
+	InsertedItems uint                 `json:"inserted_items"`
+	Name          string               `json:"name"`
+}
+
+type bloomSubFilterJSON struct {
+	Bits      []byte  `json:"bits"`
+	Capacity  uint    `json:"capacity"`
+	ErrorRate float64 `json:"error_rate"`
+	Count     uint    `json:"count"`
+}
+
+func (bf *BloomFilter) MarshalJSON() ([]byte, error) {
+	filters := make([]bloomSubFilterJSON, 0, len(bf.filters))
+	for _, f := range bf.filters {
+		filters = append(filters, bloomSubFilterJSON{
+			Bits:      f.bits,
+			Capacity:  f.capacity,
+			ErrorRate: f.errorRate,
+			Count:     f.count,
+		})
+	}
+	return json.Marshal(&bloomFilterJSON{
+		Filters:       filters,
+		Expansion:     bf.expansion,
+		InsertedItems: bf.insertedItems,
+		Name:          bf.Name,
+	})
+}
+
+// UnmarshalJSON sizes each sub-filter from its capacity and error rate, just as when it was added, so that only
+// the bits themselves need to be stored.
+func (bf *BloomFilter) UnmarshalJSON(data []byte) error {
+	var v bloomFilterJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Filters) == 0 {
+		return errors.New("bloom filter has no sub-filters")
+	}
+
+	*bf = BloomFilter{
+		filters:       make([]*bloomSubFilter, 0, len(v.Filters)),
+		expansion:     v.Expansion,
+		insertedItems: v.InsertedItems,
+		Name:          v.Name,
+	}
+	for _, f := range v.Filters {
+		filter := newBloomSubFilter(f.Capacity, f.ErrorRate)
+		if len(f.Bits) != len(filter.bits) {
+			return errors.New("bloom filter bits do not match its capacity and error rate")
+		}
+		copy(filter.bits, f.Bits)
+		filter.count = f.Count
+		bf.filters = append(bf.filters, filter)
+	}
+	return nil
+}
+
 // optimalM calculates the optimal size of the bit array (bitArraySize) given the expected
 // number of items (expectedItems) and the desired false positive probability (p).
 func optimalM(n uint, p float64) uint {
-	return uint(math.Ceil(float64(n) * math.Log(p) / math.Log(1/math.Pow(2, math.Log(2)))))
+	return max(uint(math.Ceil(bloomBits(n, p))), 1)
+}
+
+// bloomBits returns the unrounded size of the bit array for n items at a false positive probability p, as a float
+// so that it can be checked against a limit without overflowing.
+func bloomBits(n uint, p float64) float64 {
+	return float64(n) * math.Log(p) / math.Log(1/math.Pow(2, math.Log(2)))
 }
 
 // optimalK calculates the optimal number of hash functions (numHashes) given the size
 // of the bit array (bitArraySize) and the expected number of items (expectedItems).
 func optimalK(n, m uint) uint {
-	return uint(math.Round(float64(m) / float64(n) * math.Log(2)))
+	return max(uint(math.Round(float64(m)/float64(n)*math.Log(2))), 1)
+}
+
+// bloomHashes returns two hashes of an item, from the halves of its 128-bit FNV-1a hash. Every bit for the item is
+// derived from these two by double hashing (Kirsch and Mitzenmacher), so the item is only hashed once.
+func bloomHashes(item string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(item))
+	sum := h.Sum(nil)
+	return fmix64(binary.BigEndian.Uint64(sum[:8])), fmix64(binary.BigEndian.Uint64(sum[8:]))
+}
+
+// fmix64 is the finalizer of MurmurHash3. FNV only carries differences in the last bytes of an item towards the
+// high bits, so the low bits, which decide where an index falls, need mixing for similar items to spread out.
+func fmix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
 }
 
-// Add inserts an item into the Bloom filter.
-func (bf *BloomFilter) Add(item string) {
-	for _, h := range bf.hashes {
-		bf.setBit(h, item)
+// indexes calls fn with the index of each bit for an item with the given hashes, which is h1 + i*h2 for the ith
+// hash function. A step of zero would put every bit in the same place, so it is made one instead.
+func (f *bloomSubFilter) indexes(h1, h2 uint64, fn func(index uint64) bool) {
+	index, step := h1%f.numBits, h2%f.numBits
+	if step == 0 {
+		step = 1
 	}
+	for i := uint(0); i < f.numHashes; i++ {
+		if !fn(index) {
+			return
+		}
+		index = (index + step) % f.numBits
+	}
+}
+
+func (f *bloomSubFilter) contains(h1, h2 uint64) bool {
+	found := true
+	f.indexes(h1, h2, func(index uint64) bool {
+		found = f.bits[index/8]&(1<<(index%8)) != 0
+		return found
+	})
+	return found
+}
+
+func (f *bloomSubFilter) add(h1, h2 uint64) {
+	f.indexes(h1, h2, func(index uint64) bool {
+		f.bits[index/8] |= 1 << (index % 8)
+		return true
+	})
+	f.count++
+}
+
+// Add inserts an item into the Bloom filter, adding a sub-filter first if the newest one is full.
+//
+// It returns false if the item was possibly already present, and true if it was definitely added for the first time.
+// A full filter which can't scale returns ErrFilterFull.
+func (bf *BloomFilter) Add(item string) (bool, error) {
+	h1, h2 := bloomHashes(item)
+	if bf.contains(h1, h2) {
+		return false, nil
+	}
+
+	last := bf.filters[len(bf.filters)-1]
+	if last.count >= last.capacity {
+		if bf.expansion == 0 {
+			return false, ErrFilterFull
+		}
+		capacity, errorRate := last.capacity*bf.expansion, last.errorRate*bloomTighteningRatio
+		if capacity/bf.expansion != last.capacity || float64(bf.Size())+bloomBits(capacity, errorRate) > MaxBloomFilterBits {
+			return false, ErrFilterFull
+		}
+		last = newBloomSubFilter(capacity, errorRate)
+		bf.filters = append(bf.filters, last)
+	}
+
+	last.add(h1, h2)
+	bf.insertedItems++
+	return true, nil
 }
 
 // Contains checks if an item is possibly in the Bloom filter.
 // Returns true if the item is possibly in the set, false if it is definitely not in the set.
 func (bf *BloomFilter) Contains(item string) bool {
-	for _, h := range bf.hashes {
-		if !bf.getBit(h, item) {
-			return false
+	return bf.contains(bloomHashes(item))
+}
+
+func (bf *BloomFilter) contains(h1, h2 uint64) bool {
+	for _, f := range bf.filters {
+		if f.contains(h1, h2) {
+			return true
 		}
 	}
-	return true
+	return false
+}
+
+// Capacity returns the number of items the filter can hold before it next has to scale, across its sub-filters.
+func (bf *BloomFilter) Capacity() uint {
+	var capacity uint
+	for _, f := range bf.filters {
+		capacity += f.capacity
+	}
+	return capacity
 }
 
-// setBit sets the bit corresponding to the hashed value of the item.
-func (bf *BloomFilter) setBit(h hash.Hash64, item string) {
-	index := bf.indexFor(h, item)
-	bf.bitSet[index] = true
+// Size returns the number of bits in the filter, across its sub-filters.
+func (bf *BloomFilter) Size() uint {
+	var size uint
+	for _, f := range bf.filters {
+		size += uint(f.numBits)
+	}
+	return size
 }
 
-// getBit checks if the bit corresponding to the hashed value of the item is set.
-func (bf *BloomFilter) getBit(h hash.Hash64, item string) bool {
-	index := bf.indexFor(h, item)
-	return bf.bitSet[index]
+// NumHashes returns the number of hash functions applied to items added to the newest sub-filter.
+func (bf *BloomFilter) NumHashes() uint {
+	return bf.filters[len(bf.filters)-1].numHashes
 }
 
-// indexFor calculates the index in the bit array for the given hash function and item.
-func (bf *BloomFilter) indexFor(h hash.Hash64, item string) uint {
-	h.Reset()
-	h.Write([]byte(item))
-	hashValue := h.Sum64()
-	return uint(hashValue % uint64(bf.bitArraySize))
+// NumFilters returns the number of sub-filters in the filter.
+func (bf *BloomFilter) NumFilters() uint {
+	return uint(len(bf.filters))
+}
+
+// Expansion returns how many times larger each new sub-filter is than the last, or zero if the filter doesn't scale.
+func (bf *BloomFilter) Expansion() uint {
+	return bf.expansion
+}
+
+// Count returns the number of items which have been added to the filter.
+func (bf *BloomFilter) Count() uint {
+	return bf.insertedItems
 }
@@ -1,6 +1,8 @@
 package datatypes
 
 import (
+	"encoding/json"
+	"strconv"
 	"testing"
 )
 
@@ -15,3 +17,132 @@ func TestBloomFilter(t *testing.T) {
 		t.Errorf("Bloom filter should not contain \"Something\"")
 	}
 }
+
+func TestBloomFilter_Count(t *testing.T) {
+	bf := NewBloomFilter(100, 0.01)
+
+	if added, _ := bf.Add("Hello"); !added {
+		t.Errorf("Add(\"Hello\") = false, want true")
+	}
+	if added, _ := bf.Add("Hello"); added {
+		t.Errorf("Add(\"Hello\") = true on the second insert, want false")
+	}
+	bf.Add("World")
+
+	if bf.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", bf.Count())
+	}
+	if !bf.Contains("World") {
+		t.Errorf("Bloom filter should contain \"World\"")
+	}
+}
+
+// falsePositiveRate returns the fraction of items never added to the filter which it reports as present.
+func falsePositiveRate(bf *BloomFilter) float64 {
+	const trials = 100000
+	positives := 0
+	for i := 0; i < trials; i++ {
+		if bf.Contains("absent-" + strconv.Itoa(i)) {
+			positives++
+		}
+	}
+	return float64(positives) / trials
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	bf := NewBloomFilterWithExpansion(10000, 0.01, 0)
+	for i := 0; i < 10000; i++ {
+		bf.Add(strconv.Itoa(i))
+	}
+
+	if rate := falsePositiveRate(bf); rate > 0.015 {
+		t.Errorf("false positive rate = %.4f, want about 0.01", rate)
+	}
+}
+
+func TestBloomFilter_Scaling(t *testing.T) {
+	bf := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 16000; i++ {
+		if _, err := bf.Add(strconv.Itoa(i)); err != nil {
+			t.Fatalf("Add = %v", err)
+		}
+	}
+
+	// 1000 + 2000 + 4000 + 8000 items fill four sub-filters, so the fifth holds the rest.
+	if bf.NumFilters() != 5 {
+		t.Errorf("NumFilters() = %d, want 5", bf.NumFilters())
+	}
+	if bf.Capacity() != 31000 {
+		t.Errorf("Capacity() = %d, want 31000", bf.Capacity())
+	}
+	for i := 0; i < 16000; i++ {
+		if !bf.Contains(strconv.Itoa(i)) {
+			t.Fatalf("Bloom filter should contain %d", i)
+		}
+	}
+	// The tightening error rates of the sub-filters keep the total within twice the first's.
+	if rate := falsePositiveRate(bf); rate > 0.02 {
+		t.Errorf("false positive rate = %.4f, want at most 0.02", rate)
+	}
+}
+
+func TestBloomFilter_NonScaling(t *testing.T) {
+	bf := NewBloomFilterWithExpansion(2, 0.01, 0)
+	bf.Add("a")
+	bf.Add("b")
+
+	if _, err := bf.Add("c"); err != ErrFilterFull {
+		t.Errorf("Add to a full filter = %v, want ErrFilterFull", err)
+	}
+	if added, err := bf.Add("a"); added || err != nil {
+		t.Errorf("Add of a present item to a full filter = %v, %v, want false, nil", added, err)
+	}
+	if bf.NumFilters() != 1 || bf.Count() != 2 {
+		t.Errorf("full filter has %d sub-filters and %d items", bf.NumFilters(), bf.Count())
+	}
+}
+
+func TestBloomFilter_ScalingLimit(t *testing.T) {
+	// The second sub-filter would need billions of bits, so the filter stops at its first.
+	bf := NewBloomFilterWithExpansion(10, 0.01, 1<<28)
+	for i := 0; i < 10; i++ {
+		bf.Add(strconv.Itoa(i))
+	}
+
+	if _, err := bf.Add("more"); err != ErrFilterFull {
+		t.Errorf("Add past the bits limit = %v, want ErrFilterFull", err)
+	}
+	if bf.NumFilters() != 1 {
+		t.Errorf("NumFilters() = %d, want 1", bf.NumFilters())
+	}
+}
+
+func TestBloomFilter_JSON(t *testing.T) {
+	bf := NewBloomFilter(10, 0.01)
+	for i := 0; i < 25; i++ {
+		bf.Add(strconv.Itoa(i))
+	}
+
+	data, err := json.Marshal(bf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := restored.(*BloomFilter)
+	if other.NumFilters() != bf.NumFilters() || other.Count() != bf.Count() || other.Expansion() != bf.Expansion() {
+		t.Errorf("restored filter has %d sub-filters, %d items and expansion %d, want %d, %d and %d",
+			other.NumFilters(), other.Count(), other.Expansion(), bf.NumFilters(), bf.Count(), bf.Expansion())
+	}
+	for i := 0; i < 25; i++ {
+		if !other.Contains(strconv.Itoa(i)) {
+			t.Errorf("restored filter should contain %d", i)
+		}
+	}
+	if added, _ := other.Add("24"); added {
+		t.Errorf("restored filter added an item it already had")
+	}
+}
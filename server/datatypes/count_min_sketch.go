@@ -0,0 +1,165 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+// ErrSketchDimensionMismatch is returned when merging sketches of different widths or depths.
+var ErrSketchDimensionMismatch = errors.New("SketchDimensionMismatch")
+
+// CountMinSketch estimates the frequency of items in a stream using a fixed amount of memory.
+// Estimates are never lower than the true count, and exceed it by at most a fraction of the total count.
+type CountMinSketch struct {
+	width    uint
+	depth    uint
+	counters []uint64 // depth rows of width counters each
+	count    uint64   // Total of every increment
+	Name     string   `json:"name"`
+}
+
+type countMinSketchJSON struct {
+	Width    uint     `json:"width"`
+	Depth    uint     `json:"depth"`
+	Counters []uint64 `json:"counters"`
+	Count    uint64   `json:"count"`
+	Name     string   `json:"name"`
+}
+
+// NewCountMinSketch creates an empty sketch with depth rows of width counters.
+func NewCountMinSketch(width, depth uint) *CountMinSketch {
+	return &CountMinSketch{
+		width:    width,
+		depth:    depth,
+		counters: make([]uint64, width*depth),
+		Name:     "cms",
+	}
+}
+
+// NewCountMinSketchForError creates an empty sketch whose estimates exceed the true count by more than
+// errorRate of the total count with at most the given probability.
+func NewCountMinSketchForError(errorRate, probability float64) *CountMinSketch {
+	width := uint(math.Ceil(2 / errorRate))
+	depth := uint(math.Ceil(math.Log(probability) / math.Log(0.5)))
+	return NewCountMinSketch(width, max(depth, 1))
+}
+
+func (cms *CountMinSketch) GetName() string {
+	return cms.Name
+}
+
+func (cms *CountMinSketch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&countMinSketchJSON{
+		Width:    cms.width,
+		Depth:    cms.depth,
+		Counters: cms.counters,
+		Count:    cms.count,
+		Name:     cms.Name,
+	})
+}
+
+func (cms *CountMinSketch) UnmarshalJSON(data []byte) error {
+	var v countMinSketchJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*cms = *NewCountMinSketch(v.Width, v.Depth)
+	copy(cms.counters, v.Counters)
+	cms.count = v.Count
+	return nil
+}
+
+func (cms *CountMinSketch) Width() uint {
+	return cms.width
+}
+
+func (cms *CountMinSketch) Depth() uint {
+	return cms.depth
+}
+
+// Count returns the total of every increment made to the sketch.
+func (cms *CountMinSketch) Count() uint64 {
+	return cms.count
+}
+
+// indexes returns the position of the item's counter in each row, derived from two halves of
+// a single hash, so that they are the same on every replica.
+func (cms *CountMinSketch) indexes(item string) []uint {
+	h := fnv.New64a()
+	h.Write([]byte(item))
+	sum := h.Sum64()
+	h1, h2 := uint(sum&math.MaxUint32), uint(sum>>32)|1
+
+	indexes := make([]uint, cms.depth)
+	for row := uint(0); row < cms.depth; row++ {
+		indexes[row] = row*cms.width + (h1+row*h2)%cms.width
+	}
+	return indexes
+}
+
+// IncrBy adds increment to the count of the item, and returns its new estimated count.
+// Nothing is changed if the total count would overflow.
+func (cms *CountMinSketch) IncrBy(item string, increment uint64) (uint64, error) {
+	if cms.count+increment < cms.count {
+		return 0, ErrOverflow
+	}
+	cms.count += increment
+
+	estimate := uint64(math.MaxUint64)
+	for _, idx := range cms.indexes(item) {
+		cms.counters[idx] += increment
+		estimate = min(estimate, cms.counters[idx])
+	}
+	return estimate, nil
+}
+
+// Query returns the estimated count of the item.
+func (cms *CountMinSketch) Query(item string) uint64 {
+	estimate := uint64(math.MaxUint64)
+	for _, idx := range cms.indexes(item) {
+		estimate = min(estimate, cms.counters[idx])
+	}
+	return estimate
+}
+
+// Merge replaces the counts of the sketch with the sum of the counts of the sources, each multiplied
+// by its weight. Every source must have the same width and depth as the sketch, and the sketch itself
+// may be one of the sources. Nothing is changed if the sketches don't match or a count would overflow.
+func (cms *CountMinSketch) Merge(sources []*CountMinSketch, weights []uint64) error {
+	for _, src := range sources {
+		if src.width != cms.width || src.depth != cms.depth {
+			return ErrSketchDimensionMismatch
+		}
+	}
+
+	counters := make([]uint64, len(cms.counters))
+	var count uint64
+	for i, src := range sources {
+		total, ok := mulAddUint64(count, src.count, weights[i])
+		if !ok {
+			return ErrOverflow
+		}
+		count = total
+
+		// Every counter is at most the total count, so none of them can overflow once the total doesn't.
+		for j, c := range src.counters {
+			counters[j] += c * weights[i]
+		}
+	}
+
+	cms.counters = counters
+	cms.count = count
+	return nil
+}
+
+// mulAddUint64 returns a + b*c, and false if it overflows.
+func mulAddUint64(a, b, c uint64) (uint64, bool) {
+	if b != 0 && c > math.MaxUint64/b {
+		return 0, false
+	}
+	sum := a + b*c
+	return sum, sum >= a
+}
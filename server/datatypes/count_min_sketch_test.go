@@ -0,0 +1,91 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestNewCountMinSketchForError(t *testing.T) {
+	cms := NewCountMinSketchForError(0.001, 0.01)
+	if cms.Width() != 2000 || cms.Depth() != 7 {
+		t.Errorf("dimensions = %dx%d, want 2000x7", cms.Width(), cms.Depth())
+	}
+}
+
+func TestCountMinSketch_IncrBy(t *testing.T) {
+	cms := NewCountMinSketch(2000, 5)
+
+	for i := 0; i < 1000; i++ {
+		cms.IncrBy("item"+strconv.Itoa(i), uint64(i%10)+1)
+	}
+	if got, _ := cms.IncrBy("hot", 500); got < 500 {
+		t.Errorf("IncrBy(hot, 500) = %d, want at least 500", got)
+	}
+
+	// With a total count of 6000, the error is well within 2/2000 of it.
+	if got := cms.Query("hot"); got < 500 || got > 506 {
+		t.Errorf("Query(hot) = %d, want about 500", got)
+	}
+	if got := cms.Query("item7"); got < 8 || got > 14 {
+		t.Errorf("Query(item7) = %d, want about 8", got)
+	}
+	if cms.Count() != 6000 {
+		t.Errorf("Count() = %d, want 6000", cms.Count())
+	}
+
+	if _, err := cms.IncrBy("hot", math.MaxUint64); err != ErrOverflow {
+		t.Errorf("IncrBy past the counter range = %v, want ErrOverflow", err)
+	}
+	if cms.Count() != 6000 {
+		t.Errorf("Count() = %d after a failed IncrBy", cms.Count())
+	}
+}
+
+func TestCountMinSketch_Merge(t *testing.T) {
+	a := NewCountMinSketch(100, 4)
+	b := NewCountMinSketch(100, 4)
+	a.IncrBy("x", 3)
+	b.IncrBy("x", 5)
+	b.IncrBy("y", 1)
+
+	if err := a.Merge([]*CountMinSketch{a, b}, []uint64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if a.Query("x") != 13 || a.Query("y") != 2 || a.Count() != 15 {
+		t.Errorf("merged counts x=%d y=%d total=%d, want 13, 2 and 15", a.Query("x"), a.Query("y"), a.Count())
+	}
+
+	if err := a.Merge([]*CountMinSketch{NewCountMinSketch(100, 3)}, []uint64{1}); err != ErrSketchDimensionMismatch {
+		t.Errorf("Merge of mismatched sketches = %v, want ErrSketchDimensionMismatch", err)
+	}
+	if err := a.Merge([]*CountMinSketch{b}, []uint64{math.MaxUint64}); err != ErrOverflow {
+		t.Errorf("Merge with an overflowing weight = %v, want ErrOverflow", err)
+	}
+	if a.Query("x") != 13 {
+		t.Errorf("failed Merge changed the sketch")
+	}
+}
+
+func TestCountMinSketch_JSON(t *testing.T) {
+	cms := NewCountMinSketch(10, 3)
+	cms.IncrBy("a", 4)
+
+	data, err := json.Marshal(cms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(restored)
+	if string(again) != string(data) {
+		t.Errorf("restored sketch encodes to %s, want %s", again, data)
+	}
+	if restored.(*CountMinSketch).Query("a") != 4 {
+		t.Errorf("restored sketch lost its counts")
+	}
+}
@@ -1 +1,283 @@
 package datatypes
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math/bits"
+)
+
+const (
+	DefaultCuckooBucketSize      = 4   // Number of entries per bucket
+	DefaultCuckooMaxKicks        = 500 // Maximum number of kicks to relocate an entry
+	DefaultCuckooFingerprintSize = 1   // Size of the fingerprint in bytes
+)
+
+// CuckooFilter is a probabilistic membership filter which, unlike a Bloom filter, supports deletions.
+//
+// Fingerprints are stored in a flat array of numBuckets*bucketSize slots, where an empty slot is zero.
+// Evictions pick their victim with a pseudo-random sequence stored alongside the filter, so every
+// replica applying the same inserts ends up with exactly the same slots.
+type CuckooFilter struct {
+	slots           []uint32
+	numBuckets      uint
+	bucketSize      uint
+	fingerprintSize uint
+	maxKicks        uint
+	size            uint // Number of elements in the filter
+	deleted         uint // Number of elements removed from the filter
+	seed            uint64
+	Name            string `json:"name"`
+}
+
+type cuckooFilterJSON struct {
+	Slots           []uint32 `json:"slots"`
+	NumBuckets      uint     `json:"num_buckets"`
+	BucketSize      uint     `json:"bucket_size"`
+	FingerprintSize uint     `json:"fingerprint_size"`
+	MaxKicks        uint     `json:"max_kicks"`
+	Size            uint     `json:"size"`
+	Deleted         uint     `json:"deleted"`
+	Seed            uint64   `json:"seed"`
+	Name            string   `json:"name"`
+}
+
+// NewCuckooFilter creates a new Cuckoo filter with the specified number of buckets,
+// entries per bucket and fingerprint size in bytes (between 1 and 4).
+//
+// The number of buckets is rounded up to a power of two, so that alternate bucket indexes always stay in range.
+func NewCuckooFilter(numBuckets, bucketSize, fingerprintSize uint) *CuckooFilter {
+	if numBuckets < 1 {
+		numBuckets = 1
+	}
+	numBuckets = 1 << bits.Len(numBuckets-1)
+
+	if fingerprintSize < 1 {
+		fingerprintSize = 1
+	} else if fingerprintSize > 4 {
+		fingerprintSize = 4
+	}
+
+	return &CuckooFilter{
+		slots:           make([]uint32, numBuckets*bucketSize),
+		numBuckets:      numBuckets,
+		bucketSize:      bucketSize,
+		fingerprintSize: fingerprintSize,
+		maxKicks:        DefaultCuckooMaxKicks,
+		Name:            "cuckoo",
+	}
+}
+
+// NewCuckooFilterWithCapacity creates a new Cuckoo filter with enough buckets to hold the given number of items.
+func NewCuckooFilterWithCapacity(capacity, bucketSize, fingerprintSize uint) *CuckooFilter {
+	return NewCuckooFilter(capacity/bucketSize+min(capacity%bucketSize, 1), bucketSize, fingerprintSize)
+}
+
+func (cf *CuckooFilter) GetName() string {
+	return cf.Name
+}
+
+func (cf *CuckooFilter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&cuckooFilterJSON{
+		Slots:           cf.slots,
+		NumBuckets:      cf.numBuckets,
+		BucketSize:      cf.bucketSize,
+		FingerprintSize: cf.fingerprintSize,
+		MaxKicks:        cf.maxKicks,
+		Size:            cf.size,
+		Deleted:         cf.deleted,
+		Seed:            cf.seed,
+		Name:            cf.Name,
+	})
+}
+
+func (cf *CuckooFilter) UnmarshalJSON(data []byte) error {
+	var v cuckooFilterJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*cf = CuckooFilter{
+		slots:           v.Slots,
+		numBuckets:      v.NumBuckets,
+		bucketSize:      v.BucketSize,
+		fingerprintSize: v.FingerprintSize,
+		maxKicks:        v.MaxKicks,
+		size:            v.Size,
+		deleted:         v.Deleted,
+		seed:            v.Seed,
+		Name:            v.Name,
+	}
+	return nil
+}
+
+// SetMaxKicks sets the maximum number of evictions attempted before an insert gives up.
+func (cf *CuckooFilter) SetMaxKicks(maxKicks uint) {
+	cf.maxKicks = maxKicks
+}
+
+// Insert adds an item to the Cuckoo filter.
+//
+// It returns false if the filter is too full to make room for the item, in which case the filter is left unchanged.
+func (cf *CuckooFilter) Insert(item string) bool {
+	i1, fp := cf.indexAndFingerprint(item)
+	i2 := cf.alternateIndex(i1, fp)
+
+	if cf.insertToBucket(fp, i1) || cf.insertToBucket(fp, i2) {
+		cf.size++
+		return true
+	}
+
+	// Perform cuckoo evictions, remembering every displaced fingerprint so that they can be put back on failure.
+	type eviction struct {
+		slot uint
+		fp   uint32
+	}
+	var evictions []eviction
+
+	i := i1
+	for n := uint(0); n < cf.maxKicks; n++ {
+		slot := i*cf.bucketSize + uint(cf.nextRandom()%uint64(cf.bucketSize))
+		evictions = append(evictions, eviction{slot: slot, fp: cf.slots[slot]})
+
+		cf.slots[slot], fp = fp, cf.slots[slot]
+		i = cf.alternateIndex(i, fp)
+		if cf.insertToBucket(fp, i) {
+			cf.size++
+			return true
+		}
+	}
+
+	for n := len(evictions) - 1; n >= 0; n-- {
+		cf.slots[evictions[n].slot] = evictions[n].fp
+	}
+	return false // Filter is likely full
+}
+
+// Lookup checks if an item is in the Cuckoo filter.
+func (cf *CuckooFilter) Lookup(item string) bool {
+	return cf.Count(item) > 0
+}
+
+// Count returns the number of times the fingerprint of an item is present in the filter.
+func (cf *CuckooFilter) Count(item string) int {
+	i1, fp := cf.indexAndFingerprint(item)
+	i2 := cf.alternateIndex(i1, fp)
+
+	count := cf.bucketCount(fp, i1)
+	if i2 != i1 {
+		count += cf.bucketCount(fp, i2)
+	}
+	return count
+}
+
+// Delete removes an item from the Cuckoo filter.
+func (cf *CuckooFilter) Delete(item string) bool {
+	i1, fp := cf.indexAndFingerprint(item)
+	i2 := cf.alternateIndex(i1, fp)
+
+	if cf.deleteFromBucket(fp, i1) || cf.deleteFromBucket(fp, i2) {
+		cf.size--
+		cf.deleted++
+		return true
+	}
+	return false
+}
+
+// Size returns the number of items in the filter.
+func (cf *CuckooFilter) Size() uint {
+	return cf.size
+}
+
+// Deleted returns the number of items which have been deleted from the filter.
+func (cf *CuckooFilter) Deleted() uint {
+	return cf.deleted
+}
+
+// NumBuckets returns the number of buckets in the filter.
+func (cf *CuckooFilter) NumBuckets() uint {
+	return cf.numBuckets
+}
+
+// BucketSize returns the number of entries held by each bucket.
+func (cf *CuckooFilter) BucketSize() uint {
+	return cf.bucketSize
+}
+
+// FingerprintSize returns the size of each fingerprint in bytes.
+func (cf *CuckooFilter) FingerprintSize() uint {
+	return cf.fingerprintSize
+}
+
+// MaxKicks returns the maximum number of evictions attempted by an insert.
+func (cf *CuckooFilter) MaxKicks() uint {
+	return cf.maxKicks
+}
+
+// indexAndFingerprint derives the primary bucket of an item from the low bits of its hash,
+// and its fingerprint from the high bits.
+func (cf *CuckooFilter) indexAndFingerprint(item string) (uint, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(item))
+	sum := h.Sum64()
+
+	fp := uint32(sum>>32) & cf.fingerprintMask()
+	if fp == 0 {
+		// Zero marks an empty slot
+		fp = 1
+	}
+	return uint(sum) & (cf.numBuckets - 1), fp
+}
+
+func (cf *CuckooFilter) fingerprintMask() uint32 {
+	return uint32(uint64(1)<<(8*cf.fingerprintSize) - 1)
+}
+
+func (cf *CuckooFilter) alternateIndex(i uint, fp uint32) uint {
+	// The MurmurHash2 multiplier spreads small fingerprints over the whole index space.
+	return (i ^ uint(uint64(fp)*0x5bd1e995)) & (cf.numBuckets - 1)
+}
+
+// nextRandom advances the filter's splitmix64 sequence.
+func (cf *CuckooFilter) nextRandom() uint64 {
+	cf.seed += 0x9e3779b97f4a7c15
+	z := cf.seed
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (cf *CuckooFilter) bucket(i uint) []uint32 {
+	return cf.slots[i*cf.bucketSize : (i+1)*cf.bucketSize]
+}
+
+func (cf *CuckooFilter) insertToBucket(fp uint32, i uint) bool {
+	bucket := cf.bucket(i)
+	for j := range bucket {
+		if bucket[j] == 0 {
+			bucket[j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (cf *CuckooFilter) bucketCount(fp uint32, i uint) int {
+	count := 0
+	for _, slot := range cf.bucket(i) {
+		if slot == fp {
+			count++
+		}
+	}
+	return count
+}
+
+func (cf *CuckooFilter) deleteFromBucket(fp uint32, i uint) bool {
+	bucket := cf.bucket(i)
+	for j := range bucket {
+		if bucket[j] == fp {
+			bucket[j] = 0
+			return true
+		}
+	}
+	return false
+}
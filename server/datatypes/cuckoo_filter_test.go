@@ -1 +1,75 @@
 package datatypes
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCuckooFilter(t *testing.T) {
+	cf := NewCuckooFilter(100, DefaultCuckooBucketSize, DefaultCuckooFingerprintSize)
+
+	if cf.NumBuckets() != 128 {
+		t.Errorf("NumBuckets() = %d, want 128", cf.NumBuckets())
+	}
+
+	cf.Insert("Hello")
+	cf.Insert("World")
+
+	if !cf.Lookup("Hello") {
+		t.Errorf("Cuckoo filter should contain \"Hello\"")
+	}
+	if cf.Size() != 2 {
+		t.Errorf("Size() = %d, want 2", cf.Size())
+	}
+
+	if !cf.Delete("Hello") {
+		t.Errorf("Delete(\"Hello\") = false, want true")
+	}
+	if cf.Lookup("Hello") {
+		t.Errorf("Cuckoo filter should not contain \"Hello\" after deletion")
+	}
+	if cf.Size() != 1 || cf.Deleted() != 1 {
+		t.Errorf("Size() = %d, Deleted() = %d, want 1 and 1", cf.Size(), cf.Deleted())
+	}
+}
+
+func TestCuckooFilter_Full(t *testing.T) {
+	cf := NewCuckooFilter(4, 2, 2)
+
+	inserted := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		item := fmt.Sprintf("item-%d", i)
+		if !cf.Insert(item) {
+			break
+		}
+		inserted = append(inserted, item)
+	}
+
+	if len(inserted) == 100 {
+		t.Fatalf("Cuckoo filter with 8 slots accepted 100 items")
+	}
+
+	// A failed insert must not evict anything that was previously inserted.
+	for _, item := range inserted {
+		if !cf.Lookup(item) {
+			t.Errorf("Cuckoo filter lost %q after a failed insert", item)
+		}
+	}
+}
+
+func TestCuckooFilter_Deterministic(t *testing.T) {
+	cf1 := NewCuckooFilterWithCapacity(64, 4, 1)
+	cf2 := NewCuckooFilterWithCapacity(64, 4, 1)
+
+	for i := 0; i < 64; i++ {
+		item := fmt.Sprintf("item-%d", i)
+		cf1.Insert(item)
+		cf2.Insert(item)
+	}
+
+	b1, _ := cf1.MarshalJSON()
+	b2, _ := cf2.MarshalJSON()
+	if string(b1) != string(b2) {
+		t.Errorf("Cuckoo filters diverged after identical inserts")
+	}
+}
@@ -1 +1,270 @@
 package datatypes
+
+import (
+	"math"
+	"sort"
+)
+
+// Geo indexes are sorted sets, where the score of every member is the 52-bit geohash of its position.
+//
+// As a geohash interleaves the bits of the latitude and longitude, every geohash cell maps onto
+// a contiguous score range, so that area searches become a handful of range queries on the skip list.
+const (
+	GeoLatitudeMin  = -85.05112878 // Limits of the Web Mercator projection
+	GeoLatitudeMax  = 85.05112878
+	GeoLongitudeMin = -180.0
+	GeoLongitudeMax = 180.0
+
+	geoStepMax  = 26 // Number of bits used for each of the latitude and longitude
+	earthRadius = 6372797.560856
+)
+
+const geoHashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeoPoint is a position on earth, in degrees.
+type GeoPoint struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoMatch is a member of a geo index found by a search.
+type GeoMatch struct {
+	Name     string
+	Score    float64
+	Distance float64 // Distance from the centre of the search, in metres
+	Point    GeoPoint
+}
+
+// GeoQuery describes the area of a search.
+//
+// A query with a zero Width and Height searches within Radius metres of the centre,
+// otherwise it searches a Width by Height metres box around the centre.
+type GeoQuery struct {
+	Center GeoPoint
+	Radius float64
+	Width  float64
+	Height float64
+}
+
+func (q GeoQuery) isBox() bool {
+	return q.Width > 0 || q.Height > 0
+}
+
+// IsValidGeoPoint reports whether a position can be stored in a geo index.
+func IsValidGeoPoint(p GeoPoint) bool {
+	return p.Longitude >= GeoLongitudeMin && p.Longitude <= GeoLongitudeMax &&
+		p.Latitude >= GeoLatitudeMin && p.Latitude <= GeoLatitudeMax
+}
+
+// GeoScore returns the sorted set score of a position.
+func GeoScore(p GeoPoint) float64 {
+	return float64(geoEncode(p, GeoLatitudeMin, GeoLatitudeMax, geoStepMax))
+}
+
+// GeoPointFromScore returns the position at the centre of the geohash cell stored as a score.
+func GeoPointFromScore(score float64) GeoPoint {
+	return geoDecode(uint64(score), GeoLatitudeMin, GeoLatitudeMax, geoStepMax)
+}
+
+// GeoHashString returns the standard 11 character base32 geohash of a position stored as a score.
+//
+// Standard geohashes cover latitudes from -90 to 90, so the position is encoded again over that range.
+func GeoHashString(score float64) string {
+	bits := geoEncode(GeoPointFromScore(score), -90, 90, geoStepMax)
+
+	buf := make([]byte, 11)
+	for i := range buf {
+		idx := 0
+		// 52 bits only make up 10 characters, so the last character is always zero.
+		if i < 10 {
+			idx = int((bits >> (52 - (i+1)*5)) & 0x1f)
+		}
+		buf[i] = geoHashAlphabet[idx]
+	}
+	return string(buf)
+}
+
+// GeoDistance returns the great-circle distance in metres between two positions.
+func GeoDistance(p1, p2 GeoPoint) float64 {
+	lat1 := degToRad(p1.Latitude)
+	lat2 := degToRad(p2.Latitude)
+	u := math.Sin((lat2 - lat1) / 2)
+	v := math.Sin(degToRad(p2.Longitude-p1.Longitude) / 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(u*u+math.Cos(lat1)*math.Cos(lat2)*v*v))
+}
+
+// GeoSearch returns the members of a geo index inside the area of the query, sorted by
+// ascending distance from its centre, and by name for equal distances.
+func (sl *SortedSet) GeoSearch(q GeoQuery) []GeoMatch {
+	matches := make([]GeoMatch, 0)
+	seen := make(map[string]bool)
+
+	for _, r := range geoSearchRanges(q) {
+		for _, entry := range sl.RangeByScore(r, false, 0, -1) {
+			if seen[entry.Name] {
+				continue
+			}
+			seen[entry.Name] = true
+
+			point := GeoPointFromScore(entry.Score)
+			distance, ok := q.contains(point)
+			if !ok {
+				continue
+			}
+			matches = append(matches, GeoMatch{Name: entry.Name, Score: entry.Score, Distance: distance, Point: point})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
+
+// contains returns the distance of a point from the centre of the query, and whether it lies inside the query area.
+func (q GeoQuery) contains(p GeoPoint) (float64, bool) {
+	distance := GeoDistance(q.Center, p)
+	if !q.isBox() {
+		return distance, distance <= q.Radius
+	}
+
+	// The east-west extent is measured along the latitude of the point itself.
+	latDistance := GeoDistance(GeoPoint{Longitude: p.Longitude, Latitude: q.Center.Latitude}, p)
+	lonDistance := GeoDistance(GeoPoint{Longitude: q.Center.Longitude, Latitude: p.Latitude}, p)
+	return distance, latDistance <= q.Height/2 && lonDistance <= q.Width/2
+}
+
+// geoSearchRanges returns the score ranges of the geohash cells which cover the bounding box of a query.
+func geoSearchRanges(q GeoQuery) []ScoreRange {
+	halfWidth, halfHeight := q.Radius, q.Radius
+	if q.isBox() {
+		halfWidth, halfHeight = q.Width/2, q.Height/2
+	}
+
+	latDelta := radToDeg(halfHeight / earthRadius)
+	latMin := math.Max(q.Center.Latitude-latDelta, GeoLatitudeMin)
+	latMax := math.Min(q.Center.Latitude+latDelta, GeoLatitudeMax)
+
+	// Degrees of longitude shrink towards the poles, so the widest part of the box is at the latitude closest to a pole.
+	lonRanges := [][2]float64{{GeoLongitudeMin, GeoLongitudeMax}}
+	cosLat := math.Cos(degToRad(math.Max(math.Abs(latMin), math.Abs(latMax))))
+	if lonDelta := radToDeg(halfWidth / earthRadius / cosLat); lonDelta < 180 {
+		lonMin := q.Center.Longitude - lonDelta
+		lonMax := q.Center.Longitude + lonDelta
+		switch {
+		case lonMin < GeoLongitudeMin:
+			lonRanges = [][2]float64{{lonMin + 360, GeoLongitudeMax}, {GeoLongitudeMin, lonMax}}
+		case lonMax > GeoLongitudeMax:
+			lonRanges = [][2]float64{{lonMin, GeoLongitudeMax}, {GeoLongitudeMin, lonMax - 360}}
+		default:
+			lonRanges = [][2]float64{{lonMin, lonMax}}
+		}
+	}
+
+	// Pick the finest cells which are still at least as large as the box, so that it spans at most two cells each way.
+	lonSpan := 0.0
+	for _, lonRange := range lonRanges {
+		lonSpan = math.Max(lonSpan, lonRange[1]-lonRange[0])
+	}
+	step := geoStepMax
+	for step > 0 && (geoCellSize(GeoLatitudeMin, GeoLatitudeMax, step) < latMax-latMin ||
+		geoCellSize(GeoLongitudeMin, GeoLongitudeMax, step) < lonSpan) {
+		step--
+	}
+
+	cells := make(map[uint64]bool)
+	for _, lonRange := range lonRanges {
+		for lat := geoCellIndex(latMin, GeoLatitudeMin, GeoLatitudeMax, step); lat <= geoCellIndex(latMax, GeoLatitudeMin, GeoLatitudeMax, step); lat++ {
+			for lon := geoCellIndex(lonRange[0], GeoLongitudeMin, GeoLongitudeMax, step); lon <= geoCellIndex(lonRange[1], GeoLongitudeMin, GeoLongitudeMax, step); lon++ {
+				cells[interleave(lat, lon)] = true
+			}
+		}
+	}
+
+	ranges := make([]ScoreRange, 0, len(cells))
+	shift := uint(2 * (geoStepMax - step))
+	for cell := range cells {
+		ranges = append(ranges, ScoreRange{
+			Min:          float64(cell << shift),
+			Max:          float64((cell + 1) << shift),
+			MaxExclusive: true,
+		})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Min < ranges[j].Min
+	})
+	return ranges
+}
+
+func geoEncode(p GeoPoint, latMin, latMax float64, step int) uint64 {
+	lat := geoCellIndex(p.Latitude, latMin, latMax, step)
+	lon := geoCellIndex(p.Longitude, GeoLongitudeMin, GeoLongitudeMax, step)
+	return interleave(lat, lon)
+}
+
+func geoDecode(bits uint64, latMin, latMax float64, step int) GeoPoint {
+	lat := squash(bits)
+	lon := squash(bits >> 1)
+
+	latCell := geoCellSize(latMin, latMax, step)
+	lonCell := geoCellSize(GeoLongitudeMin, GeoLongitudeMax, step)
+	return GeoPoint{
+		Longitude: math.Max(GeoLongitudeMin, math.Min(GeoLongitudeMax, GeoLongitudeMin+(float64(lon)+0.5)*lonCell)),
+		Latitude:  math.Max(latMin, math.Min(latMax, latMin+(float64(lat)+0.5)*latCell)),
+	}
+}
+
+// geoCellSize returns the size in degrees of a cell which divides the range into 2^step cells.
+func geoCellSize(min, max float64, step int) float64 {
+	return (max - min) / float64(uint64(1)<<step)
+}
+
+// geoCellIndex returns the index of the cell which holds the value, when the range is divided into 2^step cells.
+func geoCellIndex(value, min, max float64, step int) uint32 {
+	cells := uint64(1) << step
+	idx := math.Floor((value - min) / (max - min) * float64(cells))
+	if idx < 0 {
+		return 0
+	}
+	if idx >= float64(cells) {
+		return uint32(cells - 1)
+	}
+	return uint32(idx)
+}
+
+// interleave places the bits of lat in the even positions and the bits of lon in the odd positions of the result.
+func interleave(lat, lon uint32) uint64 {
+	return spread(lat) | spread(lon)<<1
+}
+
+func spread(v uint32) uint64 {
+	x := uint64(v)
+	x = (x | x<<16) & 0x0000FFFF0000FFFF
+	x = (x | x<<8) & 0x00FF00FF00FF00FF
+	x = (x | x<<4) & 0x0F0F0F0F0F0F0F0F
+	x = (x | x<<2) & 0x3333333333333333
+	x = (x | x<<1) & 0x5555555555555555
+	return x
+}
+
+// squash is the inverse of spread, collecting the even bits of x.
+func squash(x uint64) uint32 {
+	x &= 0x5555555555555555
+	x = (x | x>>1) & 0x3333333333333333
+	x = (x | x>>2) & 0x0F0F0F0F0F0F0F0F
+	x = (x | x>>4) & 0x00FF00FF00FF00FF
+	x = (x | x>>8) & 0x0000FFFF0000FFFF
+	x = (x | x>>16) & 0x00000000FFFFFFFF
+	return uint32(x)
+}
+
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
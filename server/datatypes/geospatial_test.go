@@ -1 +1,64 @@
 package datatypes
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	palermo = GeoPoint{Longitude: 13.361389, Latitude: 38.115556}
+	catania = GeoPoint{Longitude: 15.087269, Latitude: 37.502669}
+)
+
+func TestGeoScore(t *testing.T) {
+	point := GeoPointFromScore(GeoScore(palermo))
+	if math.Abs(point.Longitude-palermo.Longitude) > 1e-5 || math.Abs(point.Latitude-palermo.Latitude) > 1e-5 {
+		t.Errorf("GeoPointFromScore(GeoScore(%v)) = %v", palermo, point)
+	}
+
+	if hash := GeoHashString(GeoScore(palermo)); hash != "sqc8b49rny0" {
+		t.Errorf("GeoHashString(palermo) = %s, want sqc8b49rny0", hash)
+	}
+	if hash := GeoHashString(GeoScore(catania)); hash != "sqdtr74hyu0" {
+		t.Errorf("GeoHashString(catania) = %s, want sqdtr74hyu0", hash)
+	}
+}
+
+func TestGeoDistance(t *testing.T) {
+	distance := GeoDistance(GeoPointFromScore(GeoScore(palermo)), GeoPointFromScore(GeoScore(catania)))
+	if math.Abs(distance-166274.1516) > 1 {
+		t.Errorf("GeoDistance(palermo, catania) = %v, want about 166274.1516", distance)
+	}
+}
+
+func TestSortedSet_GeoSearch(t *testing.T) {
+	sl := NewSortedSet()
+	sl.Add("Palermo", GeoScore(palermo))
+	sl.Add("Catania", GeoScore(catania))
+	sl.Add("Edge", GeoScore(GeoPoint{Longitude: 179.9, Latitude: 0}))
+	sl.Add("Wrapped", GeoScore(GeoPoint{Longitude: -179.9, Latitude: 0}))
+
+	matches := sl.GeoSearch(GeoQuery{Center: GeoPoint{Longitude: 15, Latitude: 37}, Radius: 200000})
+	if len(matches) != 2 || matches[0].Name != "Catania" || matches[1].Name != "Palermo" {
+		t.Fatalf("GeoSearch(radius 200km) = %v, want [Catania Palermo]", matches)
+	}
+	if math.Abs(matches[0].Distance-56441.3) > 1 {
+		t.Errorf("distance to Catania = %v, want about 56441.3", matches[0].Distance)
+	}
+
+	matches = sl.GeoSearch(GeoQuery{Center: GeoPoint{Longitude: 15, Latitude: 37}, Radius: 100000})
+	if len(matches) != 1 || matches[0].Name != "Catania" {
+		t.Errorf("GeoSearch(radius 100km) = %v, want [Catania]", matches)
+	}
+
+	matches = sl.GeoSearch(GeoQuery{Center: GeoPoint{Longitude: 15, Latitude: 37}, Width: 400000, Height: 400000})
+	if len(matches) != 2 {
+		t.Errorf("GeoSearch(box 400km) = %v, want [Catania Palermo]", matches)
+	}
+
+	// Searches which cross the antimeridian have to look on both sides of it.
+	matches = sl.GeoSearch(GeoQuery{Center: GeoPoint{Longitude: 180, Latitude: 0}, Radius: 50000})
+	if len(matches) != 2 || matches[0].Name != "Edge" || matches[1].Name != "Wrapped" {
+		t.Errorf("GeoSearch(antimeridian) = %v, want [Edge Wrapped]", matches)
+	}
+}
@@ -0,0 +1,173 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrNotAnInteger = errors.New("NotAnInteger") // The value is not a 64-bit integer
+	ErrNotAFloat    = errors.New("NotAFloat")    // The value is not a finite float
+	ErrOverflow     = errors.New("Overflow")     // The result of an increment does not fit in its type
+)
+
+// Hash is a map of fields to string values.
+type Hash struct {
+	Name   string            `json:"name"`
+	Fields map[string]string `json:"fields"`
+
+	// scanOrder caches the fields in the order Scan visits them, until a field is added or removed.
+	scanOrder []hashScanEntry
+}
+
+type hashScanEntry struct {
+	hash  uint64
+	field string
+}
+
+func NewHash() *Hash {
+	return &Hash{Name: "hash", Fields: make(map[string]string)}
+}
+
+func (h *Hash) GetName() string {
+	return h.Name
+}
+
+func (h *Hash) MarshalJSON() ([]byte, error) {
+	type plain Hash
+	return json.Marshal((*plain)(h))
+}
+
+// Set sets the value of a field, and reports whether the field is new.
+func (h *Hash) Set(field, value string) bool {
+	_, exists := h.Fields[field]
+	h.Fields[field] = value
+	if !exists {
+		h.scanOrder = nil
+	}
+	return !exists
+}
+
+func (h *Hash) Get(field string) (string, bool) {
+	value, ok := h.Fields[field]
+	return value, ok
+}
+
+// Delete removes a field, and reports whether it existed.
+func (h *Hash) Delete(field string) bool {
+	_, exists := h.Fields[field]
+	delete(h.Fields, field)
+	if exists {
+		h.scanOrder = nil
+	}
+	return exists
+}
+
+func (h *Hash) Len() int {
+	return len(h.Fields)
+}
+
+// Keys returns the fields of the hash in sorted order.
+func (h *Hash) Keys() []string {
+	keys := make([]string, 0, len(h.Fields))
+	for field := range h.Fields {
+		keys = append(keys, field)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IncrBy adds incr to the integer value of a field, treating a missing field as zero, and returns the new value.
+func (h *Hash) IncrBy(field string, incr int64) (int64, error) {
+	value, ok := h.Fields[field]
+	if !ok {
+		value = "0"
+	}
+	current, err := incrInteger(value, incr)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		h.scanOrder = nil
+	}
+	h.Fields[field] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+// IncrByFloat adds incr to the float value of a field, treating a missing field as zero, and returns the new value.
+func (h *Hash) IncrByFloat(field string, incr float64) (float64, error) {
+	value, ok := h.Fields[field]
+	if !ok {
+		value = "0"
+	}
+	current, err := incrFloat(value, incr)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		h.scanOrder = nil
+	}
+	h.Fields[field] = strconv.FormatFloat(current, 'f', -1, 64)
+	return current, nil
+}
+
+// Scan returns up to count fields matching pattern, in a stable order, starting from a cursor
+// returned by a previous call or zero to start a new scan, along with the cursor to continue from.
+//
+// The returned cursor is zero once the scan is complete. Fields which exist for the whole scan
+// are returned exactly once, however the hash is modified between calls.
+func (h *Hash) Scan(cursor uint64, pattern string, count int) ([]string, uint64) {
+	entries := h.orderForScan()
+	entries = entries[sort.Search(len(entries), func(i int) bool {
+		return entries[i].hash >= cursor
+	}):]
+
+	end := min(count, len(entries))
+	// Fields with the same hash share a cursor position, so they must all be returned in the same call.
+	for end > 0 && end < len(entries) && entries[end].hash == entries[end-1].hash {
+		end++
+	}
+
+	fields := make([]string, 0, end)
+	for _, e := range entries[:end] {
+		if pattern == "" || MatchGlob(pattern, e.field) {
+			fields = append(fields, e.field)
+		}
+	}
+
+	if end == len(entries) || entries[end-1].hash == math.MaxUint64 {
+		return fields, 0
+	}
+	return fields, entries[end-1].hash + 1
+}
+
+// orderForScan returns the fields in order of the hash of their name, which does not change as other fields come
+// and go. The order is sorted once and reused by later calls until a field is added or removed.
+func (h *Hash) orderForScan() []hashScanEntry {
+	if h.scanOrder != nil {
+		return h.scanOrder
+	}
+
+	entries := make([]hashScanEntry, 0, len(h.Fields))
+	for field := range h.Fields {
+		entries = append(entries, hashScanEntry{hash: hashField(field), field: field})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].hash != entries[j].hash {
+			return entries[i].hash < entries[j].hash
+		}
+		return entries[i].field < entries[j].field
+	})
+	h.scanOrder = entries
+	return entries
+}
+
+func hashField(field string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(field))
+	return hasher.Sum64()
+}
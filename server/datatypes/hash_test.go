@@ -0,0 +1,117 @@
+package datatypes
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	h := NewHash()
+
+	if !h.Set("name", "pouch") {
+		t.Errorf("Set of a new field = false, want true")
+	}
+	if h.Set("name", "pouch2") {
+		t.Errorf("Set of an existing field = true, want false")
+	}
+	h.Set("lang", "go")
+
+	if value, ok := h.Get("name"); !ok || value != "pouch2" {
+		t.Errorf("Get(\"name\") = %q, %v, want \"pouch2\", true", value, ok)
+	}
+	if !reflect.DeepEqual(h.Keys(), []string{"lang", "name"}) {
+		t.Errorf("Keys() = %v, want [lang name]", h.Keys())
+	}
+	if !h.Delete("lang") || h.Delete("lang") || h.Len() != 1 {
+		t.Errorf("Delete did not remove exactly one field")
+	}
+}
+
+func TestHash_IncrBy(t *testing.T) {
+	h := NewHash()
+
+	if v, err := h.IncrBy("n", 5); err != nil || v != 5 {
+		t.Errorf("IncrBy on a missing field = %d, %v, want 5", v, err)
+	}
+	if v, err := h.IncrBy("n", -7); err != nil || v != -2 {
+		t.Errorf("IncrBy(-7) = %d, %v, want -2", v, err)
+	}
+
+	h.Set("big", strconv.FormatInt(math.MaxInt64, 10))
+	if _, err := h.IncrBy("big", 1); err != ErrOverflow {
+		t.Errorf("IncrBy past MaxInt64 = %v, want ErrOverflow", err)
+	}
+
+	h.Set("text", "abc")
+	if _, err := h.IncrBy("text", 1); err != ErrNotAnInteger {
+		t.Errorf("IncrBy on text = %v, want ErrNotAnInteger", err)
+	}
+	if _, err := h.IncrByFloat("text", 1); err != ErrNotAFloat {
+		t.Errorf("IncrByFloat on text = %v, want ErrNotAFloat", err)
+	}
+
+	h.Set("f", "10.5")
+	if v, err := h.IncrByFloat("f", 0.1); err != nil || v != 10.6 {
+		t.Errorf("IncrByFloat(0.1) = %v, %v, want 10.6", v, err)
+	}
+	if value, _ := h.Get("f"); value != "10.6" {
+		t.Errorf("stored float = %q, want \"10.6\"", value)
+	}
+	if _, err := h.IncrByFloat("f", math.MaxFloat64); err != nil {
+		t.Errorf("IncrByFloat(MaxFloat64) = %v", err)
+	}
+	if _, err := h.IncrByFloat("f", math.MaxFloat64); err != ErrOverflow {
+		t.Errorf("IncrByFloat to infinity = %v, want ErrOverflow", err)
+	}
+}
+
+func TestHash_Scan(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 100; i++ {
+		h.Set("field:"+strconv.Itoa(i), strconv.Itoa(i))
+	}
+
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	for calls := 0; ; calls++ {
+		if calls > 100 {
+			t.Fatalf("Scan did not complete")
+		}
+
+		var fields []string
+		fields, cursor = h.Scan(cursor, "", 7)
+		for _, field := range fields {
+			seen[field]++
+		}
+
+		// Fields added or removed during the scan must not disturb the others.
+		h.Set("extra:"+strconv.Itoa(calls), "x")
+		h.Delete("extra:" + strconv.Itoa(calls-1))
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		if field := "field:" + strconv.Itoa(i); seen[field] != 1 {
+			t.Errorf("field %s was returned %d times, want 1", field, seen[field])
+		}
+	}
+
+	fields, cursor := h.Scan(0, "field:1?", 1000)
+	sort.Strings(fields)
+	if cursor != 0 || len(fields) != 10 || fields[0] != "field:10" {
+		t.Errorf("Scan with MATCH = %v, %d, want the 10 fields from field:10 to field:19", fields, cursor)
+	}
+
+	// A field added after a scan must be visited by the next one.
+	h.Set("field:100", "100")
+	fields, _ = h.Scan(0, "field:100", 1000)
+	if len(fields) != 1 {
+		t.Errorf("Scan after adding a field = %v, want [field:100]", fields)
+	}
+}
@@ -0,0 +1,188 @@
+package datatypes
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+)
+
+const (
+	hnswM              = 16 // Neighbours kept per node on the upper layers, and twice as many on the bottom layer
+	hnswEFConstruction = 200
+	hnswMaxLevel       = 15
+)
+
+// hnswCandidate is a node of the graph along with its distance to the query.
+type hnswCandidate struct {
+	node     int
+	distance float64
+}
+
+// closer orders candidates by distance, breaking ties by node so that searches are deterministic.
+func closer(a, b hnswCandidate) bool {
+	if a.distance != b.distance {
+		return a.distance < b.distance
+	}
+	return a.node < b.node
+}
+
+// hnswQueue is a heap of candidates, holding the closest candidate on top unless farthest is set.
+type hnswQueue struct {
+	items    []hnswCandidate
+	farthest bool
+}
+
+func (q *hnswQueue) Len() int { return len(q.items) }
+func (q *hnswQueue) Less(i, j int) bool {
+	if q.farthest {
+		return closer(q.items[j], q.items[i])
+	}
+	return closer(q.items[i], q.items[j])
+}
+func (q *hnswQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *hnswQueue) Push(x interface{}) { q.items = append(q.items, x.(hnswCandidate)) }
+func (q *hnswQueue) Pop() interface{} {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// hnsw is a Hierarchical Navigable Small World graph over the vectors of a VectorSet, whose nodes
+// are the positions of the vectors in the set.
+//
+// Levels are derived from a hash of the element rather than drawn at random, so inserting the same
+// elements in the same order always builds the same graph.
+type hnsw struct {
+	neighbours [][][]int // neighbours[node][layer]
+	entry      int       // -1 while the graph is empty
+	maxLevel   int
+	between    func(a, b int) float64 // The distance between two nodes
+}
+
+func newHNSW(between func(a, b int) float64) *hnsw {
+	return &hnsw{entry: -1, between: between}
+}
+
+// hnswLevel returns the top layer of an element, which is at least l with probability 1/M^l.
+func hnswLevel(element string) int {
+	h := fnv.New64a()
+	h.Write([]byte(element))
+	// M is 16, so each layer takes 4 trailing zero bits of the hash.
+	return min(bits.TrailingZeros64(h.Sum64()|1<<63)/4, hnswMaxLevel)
+}
+
+func (h *hnsw) maxNeighbours(layer int) int {
+	if layer == 0 {
+		return 2 * hnswM
+	}
+	return hnswM
+}
+
+// insert adds the next node, which must be the position after the last node in the graph.
+func (h *hnsw) insert(node int, level int) {
+	h.neighbours = append(h.neighbours, make([][]int, level+1))
+	if h.entry == -1 {
+		h.entry, h.maxLevel = node, level
+		return
+	}
+
+	distanceTo := func(n int) float64 {
+		return h.between(node, n)
+	}
+	entries := []hnswCandidate{{node: h.entry, distance: distanceTo(h.entry)}}
+	for layer := h.maxLevel; layer > level; layer-- {
+		entries = h.searchLayer(distanceTo, entries, 1, layer)
+	}
+
+	for layer := min(level, h.maxLevel); layer >= 0; layer-- {
+		entries = h.searchLayer(distanceTo, entries, hnswEFConstruction, layer)
+
+		selected := entries[:min(len(entries), hnswM)]
+		for _, c := range selected {
+			h.neighbours[node][layer] = append(h.neighbours[node][layer], c.node)
+			h.link(c.node, node, layer)
+		}
+	}
+
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = node, level
+	}
+}
+
+// link adds an edge from node to neighbour, keeping only the closest neighbours if node has too many.
+func (h *hnsw) link(node, neighbour, layer int) {
+	links := append(h.neighbours[node][layer], neighbour)
+	if len(links) > h.maxNeighbours(layer) {
+		candidates := make([]hnswCandidate, 0, len(links))
+		for _, n := range links {
+			candidates = append(candidates, hnswCandidate{node: n, distance: h.between(node, n)})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return closer(candidates[i], candidates[j])
+		})
+
+		links = links[:0]
+		for _, c := range candidates[:h.maxNeighbours(layer)] {
+			links = append(links, c.node)
+		}
+	}
+	h.neighbours[node][layer] = links
+}
+
+// searchLayer returns the ef nodes of a layer closest to the query found from the entries, closest first.
+func (h *hnsw) searchLayer(distanceTo func(node int) float64, entries []hnswCandidate, ef int, layer int) []hnswCandidate {
+	visited := make(map[int]bool, ef*2)
+	candidates := &hnswQueue{}
+	results := &hnswQueue{farthest: true}
+	for _, e := range entries {
+		visited[e.node] = true
+		heap.Push(candidates, e)
+		heap.Push(results, e)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && closer(results.items[0], c) {
+			break
+		}
+
+		for _, n := range h.neighbours[c.node][layer] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			next := hnswCandidate{node: n, distance: distanceTo(n)}
+			if results.Len() < ef || closer(next, results.items[0]) {
+				heap.Push(candidates, next)
+				heap.Push(results, next)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	found := results.items
+	sort.Slice(found, func(i, j int) bool {
+		return closer(found[i], found[j])
+	})
+	return found
+}
+
+// search returns up to ef nodes close to the query, closest first, given the distance from the query to each node.
+func (h *hnsw) search(distanceTo func(node int) float64, ef int) []hnswCandidate {
+	if h.entry == -1 {
+		return nil
+	}
+
+	entries := []hnswCandidate{{node: h.entry, distance: distanceTo(h.entry)}}
+	for layer := h.maxLevel; layer > 0; layer-- {
+		entries = h.searchLayer(distanceTo, entries, 1, layer)
+	}
+	return h.searchLayer(distanceTo, entries, ef, 0)
+}
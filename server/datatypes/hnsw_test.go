@@ -0,0 +1,54 @@
+package datatypes
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHNSWLevel(t *testing.T) {
+	levels := make(map[int]int)
+	for i := 0; i < 10000; i++ {
+		level := hnswLevel(strconv.Itoa(i))
+		if level != hnswLevel(strconv.Itoa(i)) {
+			t.Fatalf("hnswLevel(%d) isn't stable", i)
+		}
+		levels[level]++
+	}
+
+	// Roughly one element in 16 reaches each next layer.
+	if levels[0] < 9000 || levels[1] < 400 || levels[1] > 800 {
+		t.Errorf("levels = %v, want about 9375 on layer 0 and 586 on layer 1", levels)
+	}
+}
+
+func TestHNSW_Search(t *testing.T) {
+	points := make([]float64, 3000)
+	for i := range points {
+		points[i] = float64(i*7919%3000) / 3
+	}
+	h := newHNSW(func(a, b int) float64 {
+		d := points[a] - points[b]
+		return d * d
+	})
+	for i := range points {
+		h.insert(i, hnswLevel(strconv.Itoa(i)))
+	}
+
+	for _, query := range []float64{0, 123.4, 500, 999.9} {
+		found := h.search(func(node int) float64 {
+			d := points[node] - query
+			return d * d
+		}, 5)
+		if len(found) != 5 {
+			t.Fatalf("search(%v) found %d nodes, want 5", query, len(found))
+		}
+		for i := 1; i < len(found); i++ {
+			if closer(found[i], found[i-1]) {
+				t.Errorf("search(%v) isn't ordered closest first", query)
+			}
+		}
+		if nearest := points[found[0].node]; nearest-query > 1 || query-nearest > 1 {
+			t.Errorf("search(%v) found %v nearest", query, nearest)
+		}
+	}
+}
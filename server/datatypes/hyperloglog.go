@@ -2,11 +2,17 @@ package datatypes
 
 import (
 	"encoding/json"
+	"errors"
 	"hash/fnv"
 	"math"
 	"math/bits"
 )
 
+var (
+	ErrNoHyperLogLogs    = errors.New("NoHyperLogLogs")    // No HyperLogLogs were given to merge
+	ErrPrecisionMismatch = errors.New("PrecisionMismatch") // HyperLogLogs of different precisions cannot be merged
+)
+
 // HyperLogLog represents the HLL data structure
 type HyperLogLog struct {
 	p         uint8
@@ -16,8 +22,29 @@ type HyperLogLog struct {
 	Name      string `json:"name"`
 }
 
+type hyperLogLogJSON struct {
+	P         uint8  `json:"p"`
+	Registers []byte `json:"registers"`
+	Name      string `json:"name"`
+}
+
 func (hll *HyperLogLog) MarshalJSON() ([]byte, error) {
-	return json.Marshal(hll)
+	return json.Marshal(&hyperLogLogJSON{
+		P:         hll.p,
+		Registers: hll.registers,
+		Name:      hll.Name,
+	})
+}
+
+func (hll *HyperLogLog) UnmarshalJSON(data []byte) error {
+	var v hyperLogLogJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*hll = *New(v.P)
+	copy(hll.registers, v.Registers)
+	return nil
 }
 
 func (hll *HyperLogLog) GetName() string {
@@ -116,16 +143,16 @@ func (hll *HyperLogLog) Estimate() float64 {
 
 // MergeArrayIntoNew merges an array of HyperLogLog instances into a new one
 // without modifying the originals
-func MergeArrayIntoNew(hlls []*HyperLogLog) *HyperLogLog {
+func MergeArrayIntoNew(hlls []*HyperLogLog) (*HyperLogLog, error) {
 	if len(hlls) == 0 {
-		panic("No HyperLogLogs provided for merging")
+		return nil, ErrNoHyperLogLogs
 	}
 
 	// Ensure all HyperLogLogs have the same precision
 	precision := hlls[0].p
 	for _, hll := range hlls {
 		if hll.p != precision {
-			panic("HyperLogLog precision mismatch")
+			return nil, ErrPrecisionMismatch
 		}
 	}
 
@@ -141,7 +168,7 @@ func MergeArrayIntoNew(hlls []*HyperLogLog) *HyperLogLog {
 		}
 	}
 
-	return newHLL
+	return newHLL, nil
 }
 
 // countZeroRegisters counts the number of registers that are still zero
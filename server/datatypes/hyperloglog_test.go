@@ -18,7 +18,10 @@ func TestHyperLogLogMergeArrayIntoNew(t *testing.T) {
 	hll3.Add("corge")
 
 	// Merge an array of HLLs into a new HyperLogLog
-	mergedHLL := MergeArrayIntoNew([]*HyperLogLog{hll1, hll2, hll3})
+	mergedHLL, err := MergeArrayIntoNew([]*HyperLogLog{hll1, hll2, hll3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
 	// Estimate the cardinality of the merged HLL
 	estimateMerged := mergedHLL.Estimate()
@@ -39,3 +42,15 @@ func TestHyperLogLogMergeArrayIntoNew(t *testing.T) {
 		t.Errorf("Expected estimate of hll3 to be 2, got %f", hll3.Estimate())
 	}
 }
+
+func TestHyperLogLogMergeArrayIntoNewErrors(t *testing.T) {
+	if _, err := MergeArrayIntoNew(nil); err != ErrNoHyperLogLogs {
+		t.Errorf("Expected ErrNoHyperLogLogs, got %v", err)
+	}
+
+	hll1 := New(10)
+	hll2 := New(12)
+	if _, err := MergeArrayIntoNew([]*HyperLogLog{hll1, hll2}); err != ErrPrecisionMismatch {
+		t.Errorf("Expected ErrPrecisionMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,563 @@
+package datatypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidJSON     = errors.New("InvalidJSON")     // The value is not a single JSON value
+	ErrInvalidJSONPath = errors.New("InvalidJSONPath") // The path is not a supported JSONPath
+)
+
+// JSON is a JSON document, whose parts are addressed by JSONPath.
+//
+// Objects are decoded as maps and numbers as json.Number, so that integers keep their exact value.
+type JSON struct {
+	Name  string
+	value interface{}
+}
+
+type jsonDocumentJSON struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewJSON creates a document from its encoded value.
+func NewJSON(data []byte) (*JSON, error) {
+	value, err := decodeJSONValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JSON{Name: "json", value: value}, nil
+}
+
+func (j *JSON) GetName() string {
+	return j.Name
+}
+
+func (j *JSON) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(j.value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonDocumentJSON{Name: j.Name, Value: value})
+}
+
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	var doc jsonDocumentJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	value, err := decodeJSONValue(doc.Value)
+	if err != nil {
+		return err
+	}
+	j.Name = doc.Name
+	j.value = value
+	return nil
+}
+
+// decodeJSONValue decodes data, which must hold exactly one JSON value.
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, ErrInvalidJSON
+	}
+	if len(bytes.TrimSpace(data[decoder.InputOffset():])) > 0 {
+		return nil, ErrInvalidJSON
+	}
+	return value, nil
+}
+
+// jsonPathSegment is a step of a JSONPath, selecting either a member of objects by name,
+// an element of arrays by index, or every member and element.
+type jsonPathSegment struct {
+	recursive bool // Applies the step to every descendant of the current values as well as the values themselves
+	wildcard  bool
+	isIndex   bool
+	name      string
+	index     int
+}
+
+// parseJSONPath parses a JSONPath made of $, .name, ['name'], [index], * and .. steps.
+// Paths without a leading $ are taken to be relative to the root, and . alone is the root.
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	switch {
+	case path == "." || path == "$":
+		return nil, nil
+	case strings.HasPrefix(path, "$"):
+		path = path[1:]
+	case !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "["):
+		path = "." + path
+	}
+
+	segments := make([]jsonPathSegment, 0)
+	for len(path) > 0 {
+		var seg jsonPathSegment
+
+		if path[0] == '.' {
+			path = path[1:]
+			if strings.HasPrefix(path, ".") {
+				seg.recursive = true
+				path = path[1:]
+			}
+
+			switch {
+			case strings.HasPrefix(path, "*"):
+				seg.wildcard = true
+				path = path[1:]
+				segments = append(segments, seg)
+				continue
+			case strings.HasPrefix(path, "[") && seg.recursive:
+				// The bracket is parsed below, as part of this segment.
+			default:
+				end := strings.IndexAny(path, ".[")
+				if end == -1 {
+					end = len(path)
+				}
+				if end == 0 {
+					return nil, ErrInvalidJSONPath
+				}
+				seg.name = path[:end]
+				path = path[end:]
+				segments = append(segments, seg)
+				continue
+			}
+		}
+
+		if !strings.HasPrefix(path, "[") {
+			return nil, ErrInvalidJSONPath
+		}
+
+		var err error
+		if seg, path, err = parseJSONPathBracket(seg, path); err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+// parseJSONPathBracket parses a ['name'], ["name"], [index] or [*] step at the start of path,
+// and returns the rest of the path.
+func parseJSONPathBracket(seg jsonPathSegment, path string) (jsonPathSegment, string, error) {
+	path = path[1:]
+
+	if len(path) > 0 && (path[0] == '\'' || path[0] == '"') {
+		end := strings.IndexByte(path[1:], path[0])
+		if end == -1 || !strings.HasPrefix(path[end+2:], "]") {
+			return seg, "", ErrInvalidJSONPath
+		}
+		seg.name = path[1 : end+1]
+		return seg, path[end+3:], nil
+	}
+
+	end := strings.IndexByte(path, ']')
+	if end == -1 {
+		return seg, "", ErrInvalidJSONPath
+	}
+
+	if inner := path[:end]; inner == "*" {
+		seg.wildcard = true
+	} else {
+		index, err := strconv.Atoi(inner)
+		if err != nil {
+			return seg, "", ErrInvalidJSONPath
+		}
+		seg.isIndex = true
+		seg.index = index
+	}
+	return seg, path[end+1:], nil
+}
+
+// IsValidJSONPath reports whether a path can be parsed.
+func IsValidJSONPath(path string) bool {
+	_, err := parseJSONPath(path)
+	return err == nil
+}
+
+// IsJSONRoot reports whether the path addresses the whole document.
+func IsJSONRoot(path string) bool {
+	segments, err := parseJSONPath(path)
+	return err == nil && len(segments) == 0
+}
+
+// jsonRef locates a value in the document through its parent, so that it always resolves to the
+// current value even after earlier updates replaced the arrays holding it.
+type jsonRef struct {
+	parent *jsonRef // nil for the root
+	key    string   // Name of the value in its parent, if the parent is an object
+	index  int      // Index of the value in its parent, if the parent is an array
+}
+
+func (j *JSON) load(ref *jsonRef) interface{} {
+	if ref.parent == nil {
+		return j.value
+	}
+
+	switch parent := j.load(ref.parent).(type) {
+	case map[string]interface{}:
+		return parent[ref.key]
+	case []interface{}:
+		return parent[ref.index]
+	}
+	return nil
+}
+
+func (j *JSON) store(ref *jsonRef, value interface{}) {
+	if ref.parent == nil {
+		j.value = value
+		return
+	}
+
+	switch parent := j.load(ref.parent).(type) {
+	case map[string]interface{}:
+		parent[ref.key] = value
+	case []interface{}:
+		parent[ref.index] = value
+	}
+}
+
+func (j *JSON) remove(ref *jsonRef) {
+	switch parent := j.load(ref.parent).(type) {
+	case map[string]interface{}:
+		delete(parent, ref.key)
+	case []interface{}:
+		j.store(ref.parent, append(parent[:ref.index:ref.index], parent[ref.index+1:]...))
+	}
+}
+
+// children returns the members of an object in sorted order, or the elements of an array.
+func (j *JSON) children(ref *jsonRef) []*jsonRef {
+	refs := make([]*jsonRef, 0)
+	switch value := j.load(ref).(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			refs = append(refs, &jsonRef{parent: ref, key: key})
+		}
+	case []interface{}:
+		for i := range value {
+			refs = append(refs, &jsonRef{parent: ref, index: i})
+		}
+	}
+	return refs
+}
+
+// descendants returns the value and everything nested in it, with every value before its descendants.
+func (j *JSON) descendants(ref *jsonRef) []*jsonRef {
+	refs := []*jsonRef{ref}
+	for _, child := range j.children(ref) {
+		refs = append(refs, j.descendants(child)...)
+	}
+	return refs
+}
+
+func (j *JSON) step(refs []*jsonRef, seg jsonPathSegment) []*jsonRef {
+	if seg.recursive {
+		all := make([]*jsonRef, 0, len(refs))
+		for _, ref := range refs {
+			all = append(all, j.descendants(ref)...)
+		}
+		refs = all
+	}
+
+	matches := make([]*jsonRef, 0)
+	for _, ref := range refs {
+		switch value := j.load(ref).(type) {
+		case map[string]interface{}:
+			if seg.wildcard {
+				matches = append(matches, j.children(ref)...)
+			} else if _, ok := value[seg.name]; ok && !seg.isIndex {
+				matches = append(matches, &jsonRef{parent: ref, key: seg.name})
+			}
+		case []interface{}:
+			if seg.wildcard {
+				matches = append(matches, j.children(ref)...)
+			} else if seg.isIndex {
+				index := seg.index
+				if index < 0 {
+					index += len(value)
+				}
+				if index >= 0 && index < len(value) {
+					matches = append(matches, &jsonRef{parent: ref, index: index})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func (j *JSON) evaluate(segments []jsonPathSegment) []*jsonRef {
+	refs := []*jsonRef{{}}
+	for _, seg := range segments {
+		refs = j.step(refs, seg)
+	}
+	return refs
+}
+
+func (j *JSON) query(path string) ([]*jsonRef, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return j.evaluate(segments), nil
+}
+
+// Get encodes the values matching a path as an array. Values matching several paths are encoded
+// as an object of each path to the array of its values.
+func (j *JSON) Get(paths ...string) ([]byte, error) {
+	results := make(map[string][]interface{}, len(paths))
+	for _, path := range paths {
+		values, err := j.Values(path)
+		if err != nil {
+			return nil, err
+		}
+		results[path] = values
+	}
+
+	if len(paths) == 1 {
+		return json.Marshal(results[paths[0]])
+	}
+	return json.Marshal(results)
+}
+
+// Values returns the values matching a path, decoded. They belong to the document, so must not be modified.
+func (j *JSON) Values(path string) ([]interface{}, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0, len(refs))
+	for _, ref := range refs {
+		values = append(values, j.load(ref))
+	}
+	return values, nil
+}
+
+// Set replaces the values matching a path with the encoded value. If nothing matches and the path
+// ends in a member name, the member is added to the objects matching the rest of the path instead.
+//
+// Nothing is replaced if nx is set, and nothing is added if xx is set. Set reports whether anything changed.
+func (j *JSON) Set(path string, data []byte, nx, xx bool) (bool, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return false, err
+	}
+	if _, err := decodeJSONValue(data); err != nil {
+		return false, err
+	}
+
+	refs := j.evaluate(segments)
+	if len(refs) > 0 {
+		if nx {
+			return false, nil
+		}
+	} else {
+		if xx || len(segments) == 0 {
+			return false, nil
+		}
+		last := segments[len(segments)-1]
+		if last.recursive || last.wildcard || last.isIndex {
+			return false, nil
+		}
+
+		for _, parent := range j.evaluate(segments[:len(segments)-1]) {
+			if _, ok := j.load(parent).(map[string]interface{}); ok {
+				refs = append(refs, &jsonRef{parent: parent, key: last.name})
+			}
+		}
+	}
+
+	// Deeper matches are replaced first, as replacing a value discards the values nested in it.
+	for i := len(refs) - 1; i >= 0; i-- {
+		// Every match gets its own copy, so that later updates to one don't show through the others.
+		value, _ := decodeJSONValue(data)
+		j.store(refs[i], value)
+	}
+	return len(refs) > 0, nil
+}
+
+// Delete removes the values matching a path, and returns the number of values removed.
+// The root itself can't be removed, so the caller should remove the whole document instead.
+func (j *JSON) Delete(path string) (int, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	// Later matches are removed first, so that removing an element doesn't shift the indexes of the others.
+	for i := len(refs) - 1; i >= 0; i-- {
+		if refs[i].parent != nil {
+			j.remove(refs[i])
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Type returns the type of each value matching a path.
+func (j *JSON) Type(path string) ([]string, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		types = append(types, jsonTypeOf(j.load(ref)))
+	}
+	return types, nil
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "null"
+	}
+}
+
+// ArrAppend appends the encoded values to the arrays matching a path, and returns the new length
+// of each match, or -1 for matches which are not arrays.
+func (j *JSON) ArrAppend(path string, data [][]byte) ([]int, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range data {
+		if _, err := decodeJSONValue(d); err != nil {
+			return nil, err
+		}
+	}
+
+	lengths := make([]int, len(refs))
+	for i := len(refs) - 1; i >= 0; i-- {
+		array, ok := j.load(refs[i]).([]interface{})
+		if !ok {
+			lengths[i] = -1
+			continue
+		}
+
+		for _, d := range data {
+			value, _ := decodeJSONValue(d)
+			array = append(array, value)
+		}
+		j.store(refs[i], array)
+		lengths[i] = len(array)
+	}
+	return lengths, nil
+}
+
+// NumIncrBy adds the increment to the numbers matching a path, and encodes their new values as
+// an array, with null for matches which are not numbers. The sum of two integers stays an integer
+// as long as it fits in 64 bits.
+func (j *JSON) NumIncrBy(path string, increment json.Number) ([]byte, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return nil, err
+	}
+
+	incrFloat, err := increment.Float64()
+	if err != nil || math.IsInf(incrFloat, 0) || math.IsNaN(incrFloat) {
+		return nil, ErrNotAFloat
+	}
+
+	sums := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		number, ok := j.load(ref).(json.Number)
+		if !ok {
+			continue
+		}
+
+		sum, err := addJSONNumbers(number, increment)
+		if err != nil {
+			return nil, err
+		}
+		sums[i] = sum
+	}
+
+	// Nothing is updated until every sum is known to be valid, so that a failure leaves the document unchanged.
+	for i, ref := range refs {
+		if sums[i] != nil {
+			j.store(ref, sums[i])
+		}
+	}
+	return json.Marshal(sums)
+}
+
+func addJSONNumbers(a, b json.Number) (json.Number, error) {
+	x, errX := a.Int64()
+	y, errY := b.Int64()
+	if errX == nil && errY == nil {
+		if sum := x + y; (sum > x) == (y > 0) {
+			return json.Number(strconv.FormatInt(sum, 10)), nil
+		}
+	}
+
+	fx, _ := a.Float64()
+	fy, _ := b.Float64()
+	sum := fx + fy
+	if math.IsInf(sum, 0) || math.IsNaN(sum) {
+		return "", ErrOverflow
+	}
+
+	encoded, err := json.Marshal(sum)
+	if err != nil {
+		return "", err
+	}
+	return json.Number(encoded), nil
+}
+
+// ObjKeys returns the member names, in sorted order, of each object matching a path, or nil for matches which are not objects.
+func (j *JSON) ObjKeys(path string) ([][]string, error) {
+	refs, err := j.query(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]string, 0, len(refs))
+	for _, ref := range refs {
+		object, ok := j.load(ref).(map[string]interface{})
+		if !ok {
+			keys = append(keys, nil)
+			continue
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		keys = append(keys, names)
+	}
+	return keys, nil
+}
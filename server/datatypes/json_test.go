@@ -0,0 +1,201 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func newTestJSON(t *testing.T) *JSON {
+	j, err := NewJSON([]byte(`{"name":"pouch","port":6379,"ratio":0.5,"tags":["a","b"],"nested":{"port":80,"tags":[]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestNewJSON_Invalid(t *testing.T) {
+	for _, data := range []string{``, `{`, `1 2`, `{"a":1} }`} {
+		if _, err := NewJSON([]byte(data)); err != ErrInvalidJSON {
+			t.Errorf("NewJSON(%q) = %v, want ErrInvalidJSON", data, err)
+		}
+	}
+}
+
+func TestJSON_Get(t *testing.T) {
+	j := newTestJSON(t)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.name", `["pouch"]`},
+		{"name", `["pouch"]`},
+		{"$['port']", `[6379]`},
+		{"$.tags[-1]", `["b"]`},
+		{"$.tags[*]", `["a","b"]`},
+		{"$..port", `[6379,80]`},
+		{"$.nope", `[]`},
+		{".", `[{"name":"pouch","nested":{"port":80,"tags":[]},"port":6379,"ratio":0.5,"tags":["a","b"]}]`},
+	}
+	for _, tt := range tests {
+		got, err := j.Get(tt.path)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Get(%q) = %s, %v, want %s", tt.path, got, err, tt.want)
+		}
+	}
+
+	if got, _ := j.Get("$.name", "$.port"); string(got) != `{"$.name":["pouch"],"$.port":[6379]}` {
+		t.Errorf("Get of two paths = %s", got)
+	}
+	if _, err := j.Get("$.tags["); err != ErrInvalidJSONPath {
+		t.Errorf("Get of an invalid path = %v, want ErrInvalidJSONPath", err)
+	}
+}
+
+func TestJSON_Values(t *testing.T) {
+	j := newTestJSON(t)
+
+	values, err := j.Values("$..port")
+	if err != nil || !reflect.DeepEqual(values, []interface{}{json.Number("6379"), json.Number("80")}) {
+		t.Errorf("Values($..port) = %v, %v", values, err)
+	}
+	if values, _ := j.Values("$.tags"); !reflect.DeepEqual(values, []interface{}{[]interface{}{"a", "b"}}) {
+		t.Errorf("Values($.tags) = %v", values)
+	}
+	if _, err := j.Values("$["); err != ErrInvalidJSONPath || IsValidJSONPath("$[") || !IsValidJSONPath("$..port") {
+		t.Errorf("Values of an invalid path = %v, want ErrInvalidJSONPath", err)
+	}
+}
+
+func TestJSON_Set(t *testing.T) {
+	j := newTestJSON(t)
+
+	if ok, err := j.Set("$.nested.port", []byte(`443`), false, false); !ok || err != nil {
+		t.Errorf("Set of an existing member = %v, %v", ok, err)
+	}
+	if ok, _ := j.Set("$.nested.host", []byte(`"localhost"`), false, true); ok {
+		t.Errorf("Set with XX added a member")
+	}
+	if ok, _ := j.Set("$.nested.host", []byte(`"localhost"`), false, false); !ok {
+		t.Errorf("Set did not add a member")
+	}
+	if ok, _ := j.Set("$.name", []byte(`"other"`), true, false); ok {
+		t.Errorf("Set with NX replaced a member")
+	}
+	if ok, _ := j.Set("$.missing.host", []byte(`1`), false, false); ok {
+		t.Errorf("Set added a member to a missing object")
+	}
+	if _, err := j.Set("$.name", []byte(`{`), false, false); err != ErrInvalidJSON {
+		t.Errorf("Set of invalid JSON = %v, want ErrInvalidJSON", err)
+	}
+
+	got, _ := j.Get("$.nested")
+	if string(got) != `[{"host":"localhost","port":443,"tags":[]}]` {
+		t.Errorf("nested object after Set = %s", got)
+	}
+
+	// Each match gets its own copy of the value.
+	j.Set("$..tags", []byte(`[]`), false, false)
+	j.ArrAppend("$.tags", [][]byte{[]byte(`1`)})
+	if got, _ := j.Get("$..tags"); string(got) != `[[1],[]]` {
+		t.Errorf("tags after Set and ArrAppend = %s", got)
+	}
+}
+
+func TestJSON_Delete(t *testing.T) {
+	j := newTestJSON(t)
+
+	if n, _ := j.Delete("$.tags[*]"); n != 2 {
+		t.Errorf("Delete of every element = %d, want 2", n)
+	}
+	if n, _ := j.Delete("$..port"); n != 2 {
+		t.Errorf("Delete of nested members = %d, want 2", n)
+	}
+	if n, _ := j.Delete("$"); n != 0 {
+		t.Errorf("Delete of the root = %d, want 0", n)
+	}
+	if got, _ := j.Get("$"); string(got) != `[{"name":"pouch","nested":{"tags":[]},"ratio":0.5,"tags":[]}]` {
+		t.Errorf("document after Delete = %s", got)
+	}
+	if !IsJSONRoot("$") || !IsJSONRoot(".") || IsJSONRoot("$.a") {
+		t.Errorf("IsJSONRoot is wrong")
+	}
+}
+
+func TestJSON_Type(t *testing.T) {
+	j := newTestJSON(t)
+
+	got, _ := j.Type("$.*")
+	want := []string{"string", "object", "integer", "number", "array"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Type($.*) = %v, want %v", got, want)
+	}
+}
+
+func TestJSON_ArrAppend(t *testing.T) {
+	j := newTestJSON(t)
+
+	got, err := j.ArrAppend("$..tags", [][]byte{[]byte(`"c"`), []byte(`{"d":1}`)})
+	if err != nil || !reflect.DeepEqual(got, []int{4, 2}) {
+		t.Errorf("ArrAppend = %v, %v, want [4 2]", got, err)
+	}
+	if got, _ := j.ArrAppend("$.name", [][]byte{[]byte(`1`)}); !reflect.DeepEqual(got, []int{-1}) {
+		t.Errorf("ArrAppend to a string = %v, want [-1]", got)
+	}
+	if got, _ := j.Get("$.tags"); string(got) != `[["a","b","c",{"d":1}]]` {
+		t.Errorf("tags after ArrAppend = %s", got)
+	}
+}
+
+func TestJSON_NumIncrBy(t *testing.T) {
+	j := newTestJSON(t)
+
+	if got, _ := j.NumIncrBy("$..port", "1"); string(got) != `[6380,81]` {
+		t.Errorf("NumIncrBy of integers = %s", got)
+	}
+	if got, _ := j.NumIncrBy("$.ratio", "0.25"); string(got) != `[0.75]` {
+		t.Errorf("NumIncrBy of a float = %s", got)
+	}
+	if got, _ := j.NumIncrBy("$.name", "1"); string(got) != `[null]` {
+		t.Errorf("NumIncrBy of a string = %s", got)
+	}
+	if got, _ := j.NumIncrBy("$.port", "9223372036854775807"); string(got) != `[9223372036854782000]` {
+		t.Errorf("NumIncrBy past the integer range = %s", got)
+	}
+	if _, err := j.NumIncrBy("$.ratio", "1e308"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.NumIncrBy("$.ratio", "1.7e308"); err != ErrOverflow {
+		t.Errorf("NumIncrBy to infinity = %v, want ErrOverflow", err)
+	}
+}
+
+func TestJSON_ObjKeys(t *testing.T) {
+	j := newTestJSON(t)
+
+	got, _ := j.ObjKeys("$..nested")
+	if !reflect.DeepEqual(got, [][]string{{"port", "tags"}}) {
+		t.Errorf("ObjKeys = %v", got)
+	}
+	if got, _ := j.ObjKeys("$.name"); !reflect.DeepEqual(got, [][]string{nil}) {
+		t.Errorf("ObjKeys of a string = %v", got)
+	}
+}
+
+func TestJSON_JSON(t *testing.T) {
+	j := newTestJSON(t)
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := restored.(*JSON).Get("$.port"); string(got) != `[6379]` {
+		t.Errorf("restored document has port %s", got)
+	}
+}
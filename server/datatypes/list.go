@@ -3,28 +3,94 @@ package datatypes
 import (
 	"encoding/json"
 	"errors"
+	"slices"
+	"sort"
 )
 
+// listChunkSize is the most elements a chunk of a list has room for.
+const listChunkSize = 128
+
+// List is a deque of chunks, each holding a run of elements with room to grow at either end. Pushing and popping
+// only touch the chunks at the ends of the list, and finding an element is a binary search over the chunks.
+//
+// The first chunk of a list starts with room for a single element and doubles until it has room for listChunkSize,
+// so that small lists stay small. A chunk at either end which falls under half full is merged into its neighbour
+// if they fit in one chunk, so that the chunks split by inserting elements don't stay half empty.
 type List struct {
-	head   *Element
-	tail   *Element
+	// chunks[first:] hold the elements, and the space before first lets chunks be added at the head.
+	chunks []*listChunk
+	first  int
 	length int
-	Values []string `json:"values"`
-	Name   string   `json:"name"`
+	Name   string
+}
+
+// listChunk holds its elements in values[lo:hi]. start is the position of values[lo] relative to the start of the
+// first chunk, which lets pushing and popping at the head move only the first chunk's start rather than every one.
+type listChunk struct {
+	values []string
+	lo, hi int
+	start  int
+}
+
+func newListChunk(size, lo, start int) *listChunk {
+	return &listChunk{values: make([]string, size), lo: lo, hi: lo, start: start}
+}
+
+func (c *listChunk) len() int {
+	return c.hi - c.lo
 }
 
-// Element represents a node in the linked list
-type Element struct {
-	data string
-	next *Element
+// resize gives the chunk room for size elements, moving its elements to start at lo. Moving them within a chunk
+// doesn't change start, which is the position of the first element rather than of values[0].
+func (c *listChunk) resize(size, lo int) {
+	values := c.values
+	if size != len(values) {
+		values = make([]string, size)
+	}
+	n := copy(values[lo:], c.values[c.lo:c.hi])
+	if size == len(c.values) {
+		clear(values[:lo])
+		clear(values[lo+n:])
+	}
+	c.values, c.lo, c.hi = values, lo, lo+n
+}
+
+// grow doubles the room in a chunk which isn't yet full size, adding the new room at its head or its tail.
+func (c *listChunk) grow(atHead bool) {
+	size := min(2*len(c.values), listChunkSize)
+	if atHead {
+		c.resize(size, c.lo+size-len(c.values))
+	} else {
+		c.resize(size, c.lo)
+	}
 }
 
 func (list *List) MarshalJSON() ([]byte, error) {
-	return json.Marshal(list)
+	return json.Marshal(&struct {
+		Values []string `json:"values"`
+		Name   string   `json:"name"`
+	}{
+		Values: list.LRange(0, -1),
+		Name:   list.Name,
+	})
+}
+
+func (list *List) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Values []string `json:"values"`
+		Name   string   `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*list = *NewList()
+	list.RPushAll(v.Values)
+	return nil
 }
 
 func NewList() *List {
-	return &List{Values: make([]string, 0), Name: "list"}
+	return &List{Name: "list"}
 }
 
 func (list *List) GetName() string {
@@ -32,7 +98,68 @@ func (list *List) GetName() string {
 }
 
 func (list *List) GetValues() []string {
-	return list.Values
+	return list.LRange(0, -1)
+}
+
+func (list *List) headChunk() *listChunk {
+	if list.length == 0 {
+		return nil
+	}
+	return list.chunks[list.first]
+}
+
+func (list *List) tailChunk() *listChunk {
+	if list.length == 0 {
+		return nil
+	}
+	return list.chunks[len(list.chunks)-1]
+}
+
+// prependChunk adds a chunk at the head, leaving as much room before the chunks as they take up whenever it runs
+// out, so that adding chunks at the head is amortized O(1) like appending them at the tail.
+func (list *List) prependChunk(c *listChunk) {
+	if list.first == 0 {
+		n := len(list.chunks)
+		chunks := make([]*listChunk, n+max(n, 1))
+		copy(chunks[len(chunks)-n:], list.chunks)
+		list.first = len(chunks) - n
+		list.chunks = chunks
+	}
+	list.first--
+	list.chunks[list.first] = c
+}
+
+// appendChunk adds a chunk at the tail. Once at least half of the chunks slice is room left by popping from the
+// head, the chunks are moved down to reuse it rather than growing the slice, so a queue doesn't grow forever.
+func (list *List) appendChunk(c *listChunk) {
+	if len(list.chunks) == cap(list.chunks) && list.first > 0 && list.first >= len(list.chunks)/2 {
+		n := copy(list.chunks, list.chunks[list.first:])
+		clear(list.chunks[n:])
+		list.chunks = list.chunks[:n]
+		list.first = 0
+	}
+	list.chunks = append(list.chunks, c)
+}
+
+// mergeChunks moves the elements of the chunk after the ith one into it, if they fit, and drops the emptied chunk.
+// The starts of the chunks after them are unchanged, as the merged chunk starts where the ith one did.
+func (list *List) mergeChunks(i int) {
+	c, next := list.chunks[i], list.chunks[i+1]
+	n := c.len() + next.len()
+	if n > listChunkSize {
+		return
+	}
+
+	if c.lo+n > len(c.values) {
+		c.resize(listChunkSize, min(c.lo, listChunkSize-n))
+	}
+	c.hi += copy(c.values[c.hi:], next.values[next.lo:next.hi])
+	list.chunks = slices.Delete(list.chunks, i+1, i+2)
+}
+
+// reset empties the list.
+func (list *List) reset() {
+	list.chunks, list.first, list.length = nil, 0, 0
 }
 
 func (list *List) LPushAll(values []string) {
@@ -41,16 +168,23 @@ func (list *List) LPushAll(values []string) {
 	}
 }
 
-// LPush adds a new node with the given data to the start of the list
+// LPush adds an element to the start of the list
 func (list *List) LPush(value string) {
-	newNode := &Element{data: value}
-	if list.head == nil {
-		list.head = newNode
-		list.tail = newNode
-		return
+	c := list.headChunk()
+	switch {
+	case c == nil:
+		c = newListChunk(1, 1, 0)
+		list.prependChunk(c)
+	case c.lo == 0 && len(c.values) < listChunkSize:
+		c.grow(true)
+	case c.lo == 0:
+		c = newListChunk(listChunkSize, listChunkSize, c.start)
+		list.prependChunk(c)
 	}
-	newNode.next = list.head
-	list.head = newNode
+	c.lo--
+	c.start--
+	c.values[c.lo] = value
+	list.length++
 }
 
 func (list *List) RPushAll(values []string) {
@@ -59,16 +193,22 @@ func (list *List) RPushAll(values []string) {
 	}
 }
 
-// RPush adds a new node with the given data to the end of the list
-func (list *List) RPush(data string) {
-	newNode := &Element{data: data}
-	if list.tail == nil {
-		list.head = newNode
-		list.tail = newNode
-		return
+// RPush adds an element to the end of the list
+func (list *List) RPush(value string) {
+	c := list.tailChunk()
+	switch {
+	case c == nil:
+		c = newListChunk(1, 0, 0)
+		list.appendChunk(c)
+	case c.hi == len(c.values) && len(c.values) < listChunkSize:
+		c.grow(false)
+	case c.hi == len(c.values):
+		c = newListChunk(listChunkSize, 0, c.start+c.len())
+		list.appendChunk(c)
 	}
-	list.tail.next = newNode
-	list.tail = newNode
+	c.values[c.hi] = value
+	c.hi++
+	list.length++
 }
 
 func (list *List) LPopN(n int) ([]string, error) {
@@ -85,15 +225,26 @@ func (list *List) LPopN(n int) ([]string, error) {
 
 // LPop removes and returns the first element of the list
 func (list *List) LPop() (string, bool) {
-	if list.head == nil {
+	c := list.headChunk()
+	if c == nil {
 		return "", false
 	}
-	data := list.head.data
-	list.head = list.head.next
-	if list.head == nil {
-		list.tail = nil
+
+	value := c.values[c.lo]
+	c.values[c.lo] = ""
+	c.lo++
+	c.start++
+	list.length--
+
+	if list.length == 0 {
+		list.reset()
+	} else if c.len() == 0 {
+		list.chunks[list.first] = nil
+		list.first++
+	} else if c.len() < listChunkSize/2 && list.first+1 < len(list.chunks) {
+		list.mergeChunks(list.first)
 	}
-	return data, true
+	return value, true
 }
 
 func (list *List) RPopN(n int) ([]string, error) {
@@ -110,65 +261,294 @@ func (list *List) RPopN(n int) ([]string, error) {
 
 // RPop removes and returns the last element of the list
 func (list *List) RPop() (string, bool) {
-	if list.tail == nil {
+	c := list.tailChunk()
+	if c == nil {
 		return "", false
 	}
 
-	// If there's only one element
-	if list.head == list.tail {
-		data := list.head.data
-		list.head = nil
-		list.tail = nil
-		return data, true
+	c.hi--
+	value := c.values[c.hi]
+	c.values[c.hi] = ""
+	list.length--
+
+	if list.length == 0 {
+		list.reset()
+	} else if c.len() == 0 {
+		list.chunks[len(list.chunks)-1] = nil
+		list.chunks = list.chunks[:len(list.chunks)-1]
+	} else if last := len(list.chunks) - 1; c.len() < listChunkSize/2 && last > list.first {
+		list.mergeChunks(last - 1)
 	}
+	return value, true
+}
 
-	current := list.head
-	for current.next != list.tail {
-		current = current.next
+// ErrIndexOutOfRange is returned for an index past either end of a list.
+var ErrIndexOutOfRange = errors.New("IndexOutOfRange")
+
+// LRange returns the elements from start to end inclusive, where negative indices count back from the end.
+// Indices past either end are clamped, so a range that misses the list returns no elements.
+func (list *List) LRange(start, end int) []string {
+	start, end, ok := list.clamp(start, end)
+	if !ok {
+		return []string{}
 	}
-	data := list.tail.data
-	list.tail = current
-	list.tail.next = nil
 
-	return data, true
+	result := make([]string, 0, end-start+1)
+	list.walk(start, 1, func(_ int, value string) bool {
+		result = append(result, value)
+		return len(result) < cap(result)
+	})
+	return result
 }
 
-// LRange returns a slice of elements from start to end (inclusive)
-// If end is -1, it returns elements from start to the end of the list
-func (list *List) LRange(start, end int) ([]string, error) {
+// clamp resolves negative indices of a range and clamps it to the list, returning false if it is empty.
+func (list *List) clamp(start, end int) (int, int, bool) {
+	n := list.LLen()
 	if start < 0 {
-		return nil, errors.New("start index cannot be negative")
+		start = max(n+start, 0)
+	}
+	if end < 0 {
+		end = n + end
 	}
+	end = min(end, n-1)
+	return start, end, start <= end
+}
 
-	var result []string
-	current := list.head
-	index := 0
+// resolve resolves a negative index, returning false if the index is past either end of the list.
+func (list *List) resolve(index int) (int, bool) {
+	if index < 0 {
+		index += list.length
+	}
+	return index, index >= 0 && index < list.length
+}
+
+// locate returns which chunk holds the element at an index in the list, and where in the chunk's values it is.
+func (list *List) locate(index int) (int, int) {
+	chunks := list.chunks[list.first:]
+	position := chunks[0].start + index
+	i := sort.Search(len(chunks), func(i int) bool {
+		return chunks[i].start > position
+	}) - 1
+	return list.first + i, chunks[i].lo + position - chunks[i].start
+}
+
+// renumber recomputes the starts of the chunks from the ith one, after the lengths of the ones before it change.
+func (list *List) renumber(i int) {
+	for ; i < len(list.chunks); i++ {
+		previous := list.chunks[i-1]
+		list.chunks[i].start = previous.start + previous.len()
+	}
+}
 
-	for current != nil {
-		if index >= start && (end == -1 || index <= end) {
-			result = append(result, current.data)
+// walk calls fn with each element and its index, from an index towards the tail, or towards the head if step is
+// negative, until fn returns false.
+func (list *List) walk(index, step int, fn func(index int, value string) bool) {
+	if index < 0 || index >= list.length {
+		return
+	}
+
+	i, offset := list.locate(index)
+	for {
+		c := list.chunks[i]
+		for ; offset >= c.lo && offset < c.hi; offset += step {
+			if !fn(index, c.values[offset]) {
+				return
+			}
+			index += step
 		}
-		if end != -1 && index > end {
-			break
+
+		i += step
+		if i < list.first || i >= len(list.chunks) {
+			return
 		}
-		index++
-		current = current.next
+		if step > 0 {
+			offset = list.chunks[i].lo
+		} else {
+			offset = list.chunks[i].hi - 1
+		}
+	}
+}
+
+// LIndex returns the element at an index, where negative indices count back from the end.
+func (list *List) LIndex(index int) (string, bool) {
+	index, ok := list.resolve(index)
+	if !ok {
+		return "", false
 	}
+	i, offset := list.locate(index)
+	return list.chunks[i].values[offset], true
+}
 
-	if start >= index {
-		return nil, errors.New("start index out of range")
+// LSet replaces the element at an index, where negative indices count back from the end.
+func (list *List) LSet(index int, value string) error {
+	index, ok := list.resolve(index)
+	if !ok {
+		return ErrIndexOutOfRange
 	}
+	i, offset := list.locate(index)
+	list.chunks[i].values[offset] = value
+	return nil
+}
 
-	return result, nil
+// LInsert inserts a value before or after the first element equal to pivot, and returns the new length of the
+// list, or -1 if there is no such element.
+func (list *List) LInsert(before bool, pivot, value string) int {
+	found := -1
+	list.walk(0, 1, func(index int, element string) bool {
+		if element == pivot {
+			found = index
+			return false
+		}
+		return true
+	})
+	if found < 0 {
+		return -1
+	}
+
+	if !before {
+		found++
+	}
+	list.insertAt(found, value)
+	return list.LLen()
+}
+
+// insertAt inserts a value so that it ends up at an index, growing the chunk it goes into if that has no room, or
+// splitting it if it is full size.
+func (list *List) insertAt(index int, value string) {
+	switch index {
+	case 0:
+		list.LPush(value)
+		return
+	case list.length:
+		list.RPush(value)
+		return
+	}
+
+	i, offset := list.locate(index)
+	if list.chunks[i].len() == listChunkSize {
+		list.splitChunk(i)
+		i, offset = list.locate(index)
+	}
+
+	// Make room by moving the elements after the index towards the chunk's tail, or if there is no room there, the
+	// elements before it towards the chunk's head. Either way values[lo] keeps its position, so start is unchanged.
+	c := list.chunks[i]
+	if c.lo == 0 && c.hi == len(c.values) {
+		c.grow(false)
+	}
+	if c.hi < len(c.values) {
+		copy(c.values[offset+1:c.hi+1], c.values[offset:c.hi])
+		c.hi++
+	} else {
+		copy(c.values[c.lo-1:offset-1], c.values[c.lo:offset])
+		c.lo--
+		offset--
+	}
+	c.values[offset] = value
+	list.length++
+	list.renumber(i + 1)
+}
+
+// splitChunk moves the second half of the ith chunk into a new chunk after it.
+func (list *List) splitChunk(i int) {
+	c := list.chunks[i]
+	half := c.len() / 2
+	next := newListChunk(listChunkSize, 0, c.start+half)
+	next.hi = copy(next.values, c.values[c.lo+half:c.hi])
+	clear(c.values[c.lo+half : c.hi])
+	c.hi = c.lo + half
+	list.chunks = slices.Insert(list.chunks, i+1, next)
+}
+
+// LRem removes elements equal to value and returns how many it removed. A positive count removes up to count of
+// them from the head, a negative one up to -count of them from the tail, and zero removes all of them.
+func (list *List) LRem(count int, value string) int {
+	matches := 0
+	list.walk(0, 1, func(_ int, element string) bool {
+		if element == value {
+			matches++
+		}
+		return true
+	})
+
+	// Removing from the tail is removing the last matches, so skip the ones before them.
+	skip := 0
+	switch {
+	case count > 0:
+		matches = min(matches, count)
+	case count < 0:
+		skip = max(matches+count, 0)
+		matches -= skip
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Copy the elements that are kept into new chunks, so that removals don't leave chunks mostly empty.
+	removed := 0
+	kept := NewList()
+	list.walk(0, 1, func(_ int, element string) bool {
+		if element == value && removed < matches {
+			if skip == 0 {
+				removed++
+				return true
+			}
+			skip--
+		}
+		kept.RPush(element)
+		return true
+	})
+	list.chunks, list.first, list.length = kept.chunks, kept.first, kept.length
+	return removed
+}
+
+// LTrim keeps only the elements from start to end inclusive, where negative indices count back from the end.
+func (list *List) LTrim(start, end int) {
+	start, end, ok := list.clamp(start, end)
+	if !ok {
+		list.reset()
+		return
+	}
+
+	for n := list.length - 1 - end; n > 0; n-- {
+		list.RPop()
+	}
+	for ; start > 0; start-- {
+		list.LPop()
+	}
+}
+
+// LPos returns the indices of up to count elements equal to value, or all of them if count is zero. A positive rank
+// skips the first rank-1 matches from the head, and a negative one searches from the tail, skipping -rank-1 matches.
+// A positive maxLen only compares that many elements from where the search starts.
+func (list *List) LPos(value string, rank, count, maxLen int) []int {
+	step, index := 1, 0
+	if rank < 0 {
+		step, index, rank = -1, list.length-1, -rank
+	}
+
+	var positions []int
+	compared := 0
+	list.walk(index, step, func(index int, element string) bool {
+		if maxLen > 0 && compared == maxLen {
+			return false
+		}
+		compared++
+
+		if element != value {
+			return true
+		}
+		if rank > 1 {
+			rank--
+			return true
+		}
+		positions = append(positions, index)
+		return count == 0 || len(positions) < count
+	})
+	return positions
 }
 
 // LLen returns the number of elements in the list
 func (list *List) LLen() int {
-	count := 0
-	current := list.head
-	for current != nil {
-		count++
-		current = current.next
-	}
-	return count
+	return list.length
 }
@@ -1,7 +1,279 @@
 package datatypes
 
-import "testing"
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
 
 func TestList(t *testing.T) {
 
 }
+
+func newTestList(values ...string) *List {
+	list := NewList()
+	list.RPushAll(values)
+	return list
+}
+
+func TestList_LRange(t *testing.T) {
+	list := newTestList("a", "b", "c", "d", "e")
+	tests := []struct {
+		start, end int
+		want       []string
+	}{
+		{0, -1, []string{"a", "b", "c", "d", "e"}},
+		{1, 3, []string{"b", "c", "d"}},
+		{-3, -2, []string{"c", "d"}},
+		{-100, 1, []string{"a", "b"}},
+		{3, 100, []string{"d", "e"}},
+		{3, 1, []string{}},
+		{5, 10, []string{}},
+		{0, -6, []string{}},
+	}
+	for _, tt := range tests {
+		if got := list.LRange(tt.start, tt.end); !slices.Equal(got, tt.want) {
+			t.Errorf("LRange(%d, %d) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+	if got := NewList().LRange(0, -1); len(got) != 0 {
+		t.Errorf("LRange of an empty list = %v", got)
+	}
+}
+
+func TestList_LIndex_LSet(t *testing.T) {
+	list := newTestList("a", "b", "c")
+
+	for index, want := range map[int]string{0: "a", 2: "c", -1: "c", -3: "a"} {
+		if got, ok := list.LIndex(index); !ok || got != want {
+			t.Errorf("LIndex(%d) = %q, %v, want %q", index, got, ok, want)
+		}
+	}
+	for _, index := range []int{3, -4} {
+		if _, ok := list.LIndex(index); ok {
+			t.Errorf("LIndex(%d) found an element", index)
+		}
+	}
+
+	if err := list.LSet(-1, "z"); err != nil {
+		t.Errorf("LSet(-1) = %v", err)
+	}
+	if err := list.LSet(3, "z"); err != ErrIndexOutOfRange {
+		t.Errorf("LSet(3) = %v, want ErrIndexOutOfRange", err)
+	}
+	if got := list.LRange(0, -1); !slices.Equal(got, []string{"a", "b", "z"}) {
+		t.Errorf("after LSet, list = %v", got)
+	}
+}
+
+func TestList_LInsert(t *testing.T) {
+	list := newTestList("a", "b", "c")
+
+	if n := list.LInsert(true, "a", "x"); n != 4 {
+		t.Errorf("LInsert before the head = %d, want 4", n)
+	}
+	if n := list.LInsert(false, "c", "y"); n != 5 {
+		t.Errorf("LInsert after the tail = %d, want 5", n)
+	}
+	if n := list.LInsert(true, "c", "w"); n != 6 {
+		t.Errorf("LInsert in the middle = %d, want 6", n)
+	}
+	if n := list.LInsert(true, "nope", "v"); n != -1 {
+		t.Errorf("LInsert with a missing pivot = %d, want -1", n)
+	}
+	if got := list.LRange(0, -1); !slices.Equal(got, []string{"x", "a", "b", "w", "c", "y"}) {
+		t.Errorf("after LInsert, list = %v", got)
+	}
+
+	// The tail must follow an insert after it, so that pushes go to the end.
+	list.RPush("z")
+	if got, _ := list.LIndex(-1); got != "z" {
+		t.Errorf("RPush after LInsert put %q last", got)
+	}
+}
+
+func TestList_LRem(t *testing.T) {
+	tests := []struct {
+		count   int
+		removed int
+		want    []string
+	}{
+		{0, 3, []string{"b", "c"}},
+		{2, 2, []string{"b", "c", "a"}},
+		{-2, 2, []string{"a", "b", "c"}},
+		{-10, 3, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		list := newTestList("a", "b", "a", "c", "a")
+		if n := list.LRem(tt.count, "a"); n != tt.removed {
+			t.Errorf("LRem(%d) = %d, want %d", tt.count, n, tt.removed)
+		}
+		if got := list.LRange(0, -1); !slices.Equal(got, tt.want) {
+			t.Errorf("after LRem(%d), list = %v, want %v", tt.count, got, tt.want)
+		}
+		list.RPush("end")
+		if got, _ := list.LIndex(-1); got != "end" {
+			t.Errorf("RPush after LRem(%d) put %q last", tt.count, got)
+		}
+	}
+}
+
+func TestList_LTrim(t *testing.T) {
+	list := newTestList("a", "b", "c", "d", "e")
+
+	list.LTrim(1, -2)
+	if got := list.LRange(0, -1); !slices.Equal(got, []string{"b", "c", "d"}) {
+		t.Errorf("after LTrim(1, -2), list = %v", got)
+	}
+	list.RPush("f")
+	if got := list.LRange(0, -1); !slices.Equal(got, []string{"b", "c", "d", "f"}) {
+		t.Errorf("RPush after LTrim, list = %v", got)
+	}
+
+	list.LTrim(5, 10)
+	if list.LLen() != 0 {
+		t.Errorf("LTrim past the end left %v", list.LRange(0, -1))
+	}
+}
+
+func TestList_LPos(t *testing.T) {
+	list := newTestList("a", "b", "c", "1", "2", "3", "c", "c")
+	tests := []struct {
+		rank, count, maxLen int
+		want                []int
+	}{
+		{1, 1, 0, []int{2}},
+		{1, 0, 0, []int{2, 6, 7}},
+		{2, 0, 0, []int{6, 7}},
+		{-1, 2, 0, []int{7, 6}},
+		{-3, 1, 0, []int{2}},
+		{1, 0, 5, []int{2}},
+		{-1, 0, 1, []int{7}},
+		{4, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		if got := list.LPos("c", tt.rank, tt.count, tt.maxLen); !slices.Equal(got, tt.want) {
+			t.Errorf("LPos(rank %d, count %d, maxlen %d) = %v, want %v", tt.rank, tt.count, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+// TestList_Chunks checks the list against a slice through enough operations to add, split and drop many chunks.
+func TestList_Chunks(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	list := NewList()
+	var want []string
+
+	for i := 0; i < 20000; i++ {
+		value := strconv.Itoa(i)
+		switch op := rng.IntN(10); {
+		case op < 3:
+			list.LPush(value)
+			want = slices.Insert(want, 0, value)
+		case op < 6:
+			list.RPush(value)
+			want = append(want, value)
+		case op == 6:
+			got, ok := list.LPop()
+			if ok != (len(want) > 0) || ok && got != want[0] {
+				t.Fatalf("LPop = %q, %v, want %v", got, ok, want[:min(len(want), 1)])
+			}
+			if ok {
+				want = want[1:]
+			}
+		case op == 7:
+			got, ok := list.RPop()
+			if ok != (len(want) > 0) || ok && got != want[len(want)-1] {
+				t.Fatalf("RPop = %q, %v", got, ok)
+			}
+			if ok {
+				want = want[:len(want)-1]
+			}
+		case op == 8 && len(want) > 0:
+			pivot := want[rng.IntN(len(want))]
+			list.LInsert(true, pivot, value)
+			want = slices.Insert(want, slices.Index(want, pivot), value)
+		case op == 9 && len(want) > 0:
+			index := rng.IntN(len(want))
+			if got, _ := list.LIndex(index); got != want[index] {
+				t.Fatalf("LIndex(%d) = %q, want %q", index, got, want[index])
+			}
+		}
+
+		if list.LLen() != len(want) {
+			t.Fatalf("LLen = %d, want %d", list.LLen(), len(want))
+		}
+	}
+	if got := list.LRange(0, -1); !slices.Equal(got, want) {
+		t.Fatalf("LRange doesn't match after %d elements", len(want))
+	}
+	if got := list.LPos(want[len(want)/2], -1, 1, 0); !slices.Equal(got, []int{len(want) / 2}) {
+		t.Errorf("LPos from the tail = %v, want %d", got, len(want)/2)
+	}
+}
+
+// TestList_Queue checks that a list used as a queue doesn't hold on to the chunks it has emptied.
+func TestList_Queue(t *testing.T) {
+	list := NewList()
+	for i := 0; i < 100*listChunkSize; i++ {
+		list.RPush(strconv.Itoa(i))
+		if i >= listChunkSize {
+			list.LPop()
+		}
+	}
+	if list.LLen() != listChunkSize {
+		t.Fatalf("LLen = %d, want %d", list.LLen(), listChunkSize)
+	}
+	if n := cap(list.chunks); n > 8 {
+		t.Errorf("a queue of %d elements has room for %d chunks", list.LLen(), n)
+	}
+}
+
+// TestList_ChunkSizes checks that a small list has a small chunk, and that chunks split by inserting elements are
+// merged back once popping leaves them under half full.
+func TestList_ChunkSizes(t *testing.T) {
+	list := newTestList("a", "b", "c")
+	if n := len(list.chunks[list.first].values); n != 4 {
+		t.Errorf("a list of 3 elements has room for %d", n)
+	}
+
+	list = NewList()
+	for i := 0; i < listChunkSize; i++ {
+		list.RPush(strconv.Itoa(i))
+	}
+	list.LInsert(true, "1", "x")
+	if n := len(list.chunks) - list.first; n != 2 {
+		t.Fatalf("inserting into a full chunk left %d chunks, want 2", n)
+	}
+
+	list.RPop()
+	list.RPop()
+	if n := len(list.chunks) - list.first; n != 1 {
+		t.Errorf("popping below half full left %d chunks, want 1", n)
+	}
+	want := append(append([]string{"y", "0", "x"}, list.LRange(2, -1)...), "z")
+	list.LPush("y")
+	list.RPush("z")
+	if got := list.LRange(0, -1); !slices.Equal(got, want) {
+		t.Errorf("after merging, list = %v, want %v", got, want)
+	}
+}
+
+func TestList_JSON(t *testing.T) {
+	list := newTestList("b", "c")
+	list.LPush("a")
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.(*List).LRange(0, -1); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("restored list = %v, want [a b c]", got)
+	}
+}
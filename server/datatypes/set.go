@@ -1,8 +1,11 @@
 package datatypes
 
 import (
+	"cmp"
 	"encoding/json"
+	"math/rand/v2"
 	"reflect"
+	"slices"
 )
 
 type Comparable interface {
@@ -23,7 +26,8 @@ func (s *Set[T]) GetName() string {
 }
 
 func (s *Set[T]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s)
+	type plain Set[T]
+	return json.Marshal((*plain)(s))
 }
 
 // AddMany adds the values to the set and returns the number of items that have actually been added.
@@ -43,8 +47,21 @@ func (s *Set[T]) Add(value T) int {
 	return 0
 }
 
-func (s *Set[T]) Remove(value T) {
-	delete(s.Values, value)
+// RemoveMany removes the values from the set and returns the number of items that have actually been removed.
+func (s *Set[T]) RemoveMany(values []T) int {
+	var count int
+	for _, v := range values {
+		count += s.Remove(v)
+	}
+	return count
+}
+
+func (s *Set[T]) Remove(value T) int {
+	if s.Contains(value) {
+		delete(s.Values, value)
+		return 1
+	}
+	return 0
 }
 
 func (s *Set[T]) Contains(value T) bool {
@@ -104,3 +121,35 @@ func (s *Set[T]) Copy() *Set[T] {
 	set.AddMany(s.GetMembers())
 	return set
 }
+
+// SortedMembers returns the members of a set in ascending order.
+func SortedMembers[T cmp.Ordered](s *Set[T]) []T {
+	members := s.GetMembers()
+	slices.Sort(members)
+	return members
+}
+
+// RandomMembers returns count distinct members of a set in random order, or all of them if it has fewer.
+// A negative count instead returns -count members which can repeat. Members are drawn from their sorted
+// order rather than the order of the map, so the same rng picks the same members on every replica.
+func RandomMembers[T cmp.Ordered](s *Set[T], count int, rng *rand.Rand) []T {
+	members := SortedMembers(s)
+	if len(members) == 0 {
+		return members
+	}
+
+	if count < 0 {
+		picked := make([]T, -count)
+		for i := range picked {
+			picked[i] = members[rng.IntN(len(members))]
+		}
+		return picked
+	}
+
+	count = min(count, len(members))
+	for i := 0; i < count; i++ {
+		j := i + rng.IntN(len(members)-i)
+		members[i], members[j] = members[j], members[i]
+	}
+	return members[:count]
+}
@@ -1,6 +1,11 @@
 package datatypes
 
-import "testing"
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
 
 func TestSet_Add_Remove(t *testing.T) {
 	set := NewSet[string]()
@@ -96,3 +101,68 @@ func TestSet_Union(t *testing.T) {
 		t.Errorf("intersection = %v, want %v", u, union)
 	}
 }
+
+func TestSet_RemoveMany(t *testing.T) {
+	set := NewSet[string]()
+	set.AddMany([]string{"mango", "banana", "papaya"})
+
+	if n := set.RemoveMany([]string{"mango", "kiwi", "mango"}); n != 1 {
+		t.Errorf("Set.RemoveMany() = %d, want 1", n)
+	}
+	if set.Size() != 2 || set.Contains("mango") {
+		t.Errorf("Set.RemoveMany() left %v", set.GetMembers())
+	}
+}
+
+func TestSortedMembers(t *testing.T) {
+	set := NewSet[string]()
+	set.AddMany([]string{"papaya", "banana", "mango"})
+
+	if got := SortedMembers(set); !slices.Equal(got, []string{"banana", "mango", "papaya"}) {
+		t.Errorf("SortedMembers() = %v", got)
+	}
+}
+
+func TestRandomMembers(t *testing.T) {
+	set := NewSet[string]()
+	set.AddMany([]string{"a", "b", "c", "d", "e"})
+
+	picked := RandomMembers(set, 3, rand.New(rand.NewPCG(1, 0)))
+	if len(picked) != 3 {
+		t.Fatalf("RandomMembers(3) = %v, want 3 members", picked)
+	}
+	seen := make(map[string]bool)
+	for _, m := range picked {
+		if !set.Contains(m) || seen[m] {
+			t.Errorf("RandomMembers(3) = %v, want distinct members of the set", picked)
+		}
+		seen[m] = true
+	}
+
+	if got := RandomMembers(set, 10, rand.New(rand.NewPCG(1, 0))); len(got) != 5 {
+		t.Errorf("RandomMembers(10) = %v, want all 5 members", got)
+	}
+	if got := RandomMembers(set, -20, rand.New(rand.NewPCG(1, 0))); len(got) != 20 {
+		t.Errorf("RandomMembers(-20) = %v, want 20 members", got)
+	}
+	if got := RandomMembers(NewSet[string](), 3, rand.New(rand.NewPCG(1, 0))); len(got) != 0 {
+		t.Errorf("RandomMembers of an empty set = %v", got)
+	}
+}
+
+func TestRandomMembers_Deterministic(t *testing.T) {
+	// Two replicas build the same set in different orders, which their maps iterate differently.
+	s1, s2 := NewSet[string](), NewSet[string]()
+	for i := 0; i < 100; i++ {
+		s1.Add(strconv.Itoa(i))
+		s2.Add(strconv.Itoa(99 - i))
+	}
+
+	for seed := uint64(0); seed < 10; seed++ {
+		a := RandomMembers(s1, 10, rand.New(rand.NewPCG(seed, 0)))
+		b := RandomMembers(s2, 10, rand.New(rand.NewPCG(seed, 0)))
+		if !slices.Equal(a, b) {
+			t.Errorf("seed %d picked %v and %v", seed, a, b)
+		}
+	}
+}
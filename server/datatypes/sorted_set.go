@@ -1,35 +1,128 @@
 package datatypes
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
 	"math/rand"
+	"strconv"
 )
 
 // MaxLevel is the maximum level for the skip list nodes.
-const MaxLevel = 16
+const MaxLevel = 32
 
 // Probability is the probability used to decide whether to increase the level of a node.
-const Probability = 0.5
+const Probability = 0.25
+
+// SortedSetEntry is a single member of a sorted set along with its score.
+type SortedSetEntry struct {
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+type sortedSetEntryJSON struct {
+	Name  string          `json:"name"`
+	Score json.RawMessage `json:"score"`
+}
+
+// MarshalJSON encodes an infinite score, which a JSON number can't hold, as the string "+Inf" or "-Inf".
+func (e SortedSetEntry) MarshalJSON() ([]byte, error) {
+	score, err := json.Marshal(e.Score)
+	if math.IsInf(e.Score, 0) {
+		score, err = json.Marshal(strconv.FormatFloat(e.Score, 'f', -1, 64))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&sortedSetEntryJSON{Name: e.Name, Score: score})
+}
+
+func (e *SortedSetEntry) UnmarshalJSON(data []byte) error {
+	var v sortedSetEntryJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	var infinity string
+	if err := json.Unmarshal(v.Score, &infinity); err == nil {
+		score, err := strconv.ParseFloat(infinity, 64)
+		if err != nil || !math.IsInf(score, 0) {
+			return errors.New("sorted set score is neither a number nor infinite")
+		}
+		e.Name, e.Score = v.Name, score
+		return nil
+	}
+
+	e.Name = v.Name
+	return json.Unmarshal(v.Score, &e.Score)
+}
+
+// ScoreRange describes an interval of scores, each end of which may be exclusive.
+type ScoreRange struct {
+	Min          float64
+	Max          float64
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+func (r ScoreRange) gteMin(score float64) bool {
+	if r.MinExclusive {
+		return score > r.Min
+	}
+	return score >= r.Min
+}
+
+func (r ScoreRange) lteMax(score float64) bool {
+	if r.MaxExclusive {
+		return score < r.Max
+	}
+	return score <= r.Max
+}
+
+// isEmpty reports whether no score can possibly fall inside the range.
+func (r ScoreRange) isEmpty() bool {
+	return r.Min > r.Max || (r.Min == r.Max && (r.MinExclusive || r.MaxExclusive))
+}
+
+// sortedSetLevel is a forward pointer of a node, along with the number of nodes it skips.
+type sortedSetLevel struct {
+	forward *sortedSetNode
+	span    int
+}
 
 // sortedSetNode represents a node in the skip list.
 type sortedSetNode struct {
-	name  string
-	score int
-	next  []*sortedSetNode
+	name     string
+	score    float64
+	backward *sortedSetNode
+	levels   []sortedSetLevel
+}
+
+// less reports whether the node sorts before the given (score, name) pair.
+//
+// Nodes are ordered by score, and members with equal scores are ordered lexicographically.
+func (n *sortedSetNode) less(score float64, name string) bool {
+	return n.score < score || (n.score == score && n.name < name)
 }
 
 // SortedSet represents the skip list structure.
+//
+// Every level keeps a span count of the nodes it skips, so that rank lookups
+// and index based ranges are logarithmic rather than linear.
 type SortedSet struct {
 	header *sortedSetNode
+	length int
 	level  int
 	table  map[string]*sortedSetNode // Hash table for quick lookup by name
+	Name   string
 }
 
 // newSortedSetNode creates a new node with a given name, score, and level.
-func newSortedSetNode(name string, score, level int) *sortedSetNode {
+func newSortedSetNode(name string, score float64, level int) *sortedSetNode {
 	return &sortedSetNode{
-		name:  name,
-		score: score,
-		next:  make([]*sortedSetNode, level),
+		name:   name,
+		score:  score,
+		levels: make([]sortedSetLevel, level),
 	}
 }
 
@@ -39,9 +132,40 @@ func NewSortedSet() *SortedSet {
 		header: newSortedSetNode("", 0, MaxLevel),
 		level:  1,
 		table:  make(map[string]*sortedSetNode),
+		Name:   "zset",
 	}
 }
 
+func (sl *SortedSet) GetName() string {
+	return sl.Name
+}
+
+func (sl *SortedSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Values []SortedSetEntry `json:"values"`
+		Name   string           `json:"name"`
+	}{
+		Values: sl.RangeByIndex(0, -1, false),
+		Name:   sl.Name,
+	})
+}
+
+func (sl *SortedSet) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Values []SortedSetEntry `json:"values"`
+		Name   string           `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*sl = *NewSortedSet()
+	for _, entry := range v.Values {
+		sl.Add(entry.Name, entry.Score)
+	}
+	return nil
+}
+
 // randomLevel generates a random level for a new node.
 func randomLevel() int {
 	level := 1
@@ -51,14 +175,181 @@ func randomLevel() int {
 	return level
 }
 
-// Add inserts a new element (name, score) into the skip list.
-func (sl *SortedSet) Add(name string, score int) {
-	update := make([]*sortedSetNode, MaxLevel)
+// Add inserts a new element (name, score) into the skip list, or updates the score of an existing element.
+//
+// It returns true if the element was newly added.
+func (sl *SortedSet) Add(name string, score float64) bool {
+	if node, exists := sl.table[name]; exists {
+		if node.score != score {
+			sl.delete(name, node.score)
+			sl.table[name] = sl.insert(name, score)
+		}
+		return false
+	}
+
+	sl.table[name] = sl.insert(name, score)
+	return true
+}
+
+// IncrBy increments the score of an element, adding it with the given score if it does not exist.
+//
+// It returns the new score of the element.
+func (sl *SortedSet) IncrBy(name string, delta float64) float64 {
+	score, _ := sl.GetScore(name)
+	score += delta
+	sl.Add(name, score)
+	return score
+}
+
+// Remove deletes an element by name from the skip list, and returns true if it existed.
+func (sl *SortedSet) Remove(name string) bool {
+	node, exists := sl.table[name]
+	if !exists {
+		return false
+	}
+	sl.delete(name, node.score)
+
+	// Remove the node from the hash table
+	delete(sl.table, name)
+	return true
+}
+
+// GetScore returns the score of an element by name.
+func (sl *SortedSet) GetScore(name string) (float64, bool) {
+	node, exists := sl.table[name]
+	if !exists {
+		return 0, false
+	}
+	return node.score, true
+}
+
+// Card returns the number of elements in the sorted set.
+func (sl *SortedSet) Card() int {
+	return sl.length
+}
+
+// Rank returns the 0-based position of an element, ordered from the lowest score,
+// or from the highest score if reverse is set.
+func (sl *SortedSet) Rank(name string, reverse bool) (int, bool) {
+	node, exists := sl.table[name]
+	if !exists {
+		return 0, false
+	}
+
+	rank := 0
 	current := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for current.levels[i].forward != nil && !node.less(current.levels[i].forward.score, current.levels[i].forward.name) {
+			rank += current.levels[i].span
+			current = current.levels[i].forward
+		}
+		if current == node {
+			break
+		}
+	}
+
+	if reverse {
+		return sl.length - rank, true
+	}
+	return rank - 1, true
+}
+
+// RangeByIndex returns the elements between the start and stop positions (both inclusive).
+//
+// Negative positions count backwards from the end of the set, so -1 is the last element.
+// If reverse is set, positions are counted from the highest score downwards.
+func (sl *SortedSet) RangeByIndex(start, stop int, reverse bool) []SortedSetEntry {
+	if start < 0 {
+		start += sl.length
+	}
+	if stop < 0 {
+		stop += sl.length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= sl.length {
+		stop = sl.length - 1
+	}
+	if start > stop || start >= sl.length {
+		return []SortedSetEntry{}
+	}
+
+	var current *sortedSetNode
+	if reverse {
+		current = sl.nodeByRank(sl.length - start)
+	} else {
+		current = sl.nodeByRank(start + 1)
+	}
+
+	result := make([]SortedSetEntry, 0, stop-start+1)
+	for i := start; i <= stop && current != nil; i++ {
+		result = append(result, SortedSetEntry{Name: current.name, Score: current.score})
+		if reverse {
+			current = current.backward
+		} else {
+			current = current.levels[0].forward
+		}
+	}
+	return result
+}
+
+// RangeByScore returns the elements whose scores fall within the given range.
+//
+// The first offset matching elements are skipped, and at most count elements are returned.
+// A negative count returns all remaining elements.
+func (sl *SortedSet) RangeByScore(r ScoreRange, reverse bool, offset, count int) []SortedSetEntry {
+	result := make([]SortedSetEntry, 0)
+
+	var current *sortedSetNode
+	if reverse {
+		current = sl.lastInRange(r)
+	} else {
+		current = sl.firstInRange(r)
+	}
+
+	for current != nil && offset > 0 {
+		offset--
+		if reverse {
+			current = current.backward
+		} else {
+			current = current.levels[0].forward
+		}
+	}
 
+	for current != nil && count != 0 {
+		if reverse && !r.gteMin(current.score) {
+			break
+		}
+		if !reverse && !r.lteMax(current.score) {
+			break
+		}
+
+		result = append(result, SortedSetEntry{Name: current.name, Score: current.score})
+		count--
+
+		if reverse {
+			current = current.backward
+		} else {
+			current = current.levels[0].forward
+		}
+	}
+	return result
+}
+
+// insert adds a node for the (name, score) pair, which must not already be present.
+func (sl *SortedSet) insert(name string, score float64) *sortedSetNode {
+	var update [MaxLevel]*sortedSetNode
+	var rank [MaxLevel]int
+
+	current := sl.header
 	for i := sl.level - 1; i >= 0; i-- {
-		for current.next[i] != nil && current.next[i].score < score {
-			current = current.next[i]
+		if i < sl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for current.levels[i].forward != nil && current.levels[i].forward.less(score, name) {
+			rank[i] += current.levels[i].span
+			current = current.levels[i].forward
 		}
 		update[i] = current
 	}
@@ -66,64 +357,126 @@ func (sl *SortedSet) Add(name string, score int) {
 	level := randomLevel()
 	if level > sl.level {
 		for i := sl.level; i < level; i++ {
+			rank[i] = 0
 			update[i] = sl.header
+			update[i].levels[i].span = sl.length
 		}
 		sl.level = level
 	}
 
 	newNode := newSortedSetNode(name, score, level)
 	for i := 0; i < level; i++ {
-		newNode.next[i] = update[i].next[i]
-		update[i].next[i] = newNode
+		newNode.levels[i].forward = update[i].levels[i].forward
+		update[i].levels[i].forward = newNode
+
+		newNode.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = (rank[0] - rank[i]) + 1
 	}
 
-	// Add the node to the hash table
-	sl.table[name] = newNode
-}
+	// Levels above the new node now skip over one more node.
+	for i := level; i < sl.level; i++ {
+		update[i].levels[i].span++
+	}
 
-// Remove deletes an element by name from the skip list.
-func (sl *SortedSet) Remove(name string) {
-	node, exists := sl.table[name]
-	if !exists {
-		return
+	if update[0] != sl.header {
+		newNode.backward = update[0]
+	}
+	if newNode.levels[0].forward != nil {
+		newNode.levels[0].forward.backward = newNode
 	}
-	score := node.score
 
-	update := make([]*sortedSetNode, MaxLevel)
-	current := sl.header
+	sl.length++
+	return newNode
+}
+
+// delete unlinks the node for the (name, score) pair from the skip list.
+func (sl *SortedSet) delete(name string, score float64) {
+	var update [MaxLevel]*sortedSetNode
 
+	current := sl.header
 	for i := sl.level - 1; i >= 0; i-- {
-		for current.next[i] != nil && current.next[i].score < score {
-			current = current.next[i]
+		for current.levels[i].forward != nil && current.levels[i].forward.less(score, name) {
+			current = current.levels[i].forward
 		}
 		update[i] = current
 	}
 
-	current = current.next[0]
+	current = current.levels[0].forward
+	if current == nil || current.score != score || current.name != name {
+		return
+	}
 
-	if current != nil && current.name == name {
-		for i := 0; i < sl.level; i++ {
-			if update[i].next[i] != current {
-				break
-			}
-			update[i].next[i] = current.next[i]
+	for i := 0; i < sl.level; i++ {
+		if update[i].levels[i].forward == current {
+			update[i].levels[i].span += current.levels[i].span - 1
+			update[i].levels[i].forward = current.levels[i].forward
+		} else {
+			update[i].levels[i].span--
 		}
+	}
+
+	if current.levels[0].forward != nil {
+		current.levels[0].forward.backward = current.backward
+	}
 
-		// Adjust the level of the skip list if necessary.
-		for sl.level > 1 && sl.header.next[sl.level-1] == nil {
-			sl.level--
+	// Adjust the level of the skip list if necessary.
+	for sl.level > 1 && sl.header.levels[sl.level-1].forward == nil {
+		sl.level--
+	}
+	sl.length--
+}
+
+// nodeByRank returns the node at the given 1-based rank.
+func (sl *SortedSet) nodeByRank(rank int) *sortedSetNode {
+	traversed := 0
+	current := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for current.levels[i].forward != nil && traversed+current.levels[i].span <= rank {
+			traversed += current.levels[i].span
+			current = current.levels[i].forward
+		}
+		if traversed == rank {
+			return current
 		}
 	}
+	return nil
+}
 
-	// Remove the node from the hash table
-	delete(sl.table, name)
+// firstInRange returns the lowest scored node inside the range.
+func (sl *SortedSet) firstInRange(r ScoreRange) *sortedSetNode {
+	if r.isEmpty() {
+		return nil
+	}
+
+	current := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for current.levels[i].forward != nil && !r.gteMin(current.levels[i].forward.score) {
+			current = current.levels[i].forward
+		}
+	}
+
+	current = current.levels[0].forward
+	if current == nil || !r.lteMax(current.score) {
+		return nil
+	}
+	return current
 }
 
-// GetScore returns the score of an element by name.
-func (sl *SortedSet) GetScore(name string) (int, bool) {
-	node, exists := sl.table[name]
-	if !exists {
-		return 0, false
+// lastInRange returns the highest scored node inside the range.
+func (sl *SortedSet) lastInRange(r ScoreRange) *sortedSetNode {
+	if r.isEmpty() {
+		return nil
 	}
-	return node.score, true
+
+	current := sl.header
+	for i := sl.level - 1; i >= 0; i-- {
+		for current.levels[i].forward != nil && r.lteMax(current.levels[i].forward.score) {
+			current = current.levels[i].forward
+		}
+	}
+
+	if current == sl.header || !r.gteMin(current.score) {
+		return nil
+	}
+	return current
 }
@@ -1,6 +1,9 @@
 package datatypes
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -18,11 +21,11 @@ func TestSortedSet(t *testing.T) {
 
 	// Test quick lookup by name
 	if score, found := sl.GetScore("Charlie"); !found || score != 85 {
-		t.Errorf("Expected score 85 for Charlie, got %d, found: %v", score, found)
+		t.Errorf("Expected score 85 for Charlie, got %v, found: %v", score, found)
 	}
 
 	if score, found := sl.GetScore("Eve"); found {
-		t.Errorf("Expected no entry for Eve, but found score %d", score)
+		t.Errorf("Expected no entry for Eve, but found score %v", score)
 	}
 
 	// Test removing elements
@@ -39,6 +42,108 @@ func TestSortedSet(t *testing.T) {
 	// Test adding more elements after removal
 	sl.Add("Eve", 105)
 	if score, found := sl.GetScore("Eve"); !found || score != 105 {
-		t.Errorf("Expected score 105 for Eve, got %d, found: %v", score, found)
+		t.Errorf("Expected score 105 for Eve, got %v, found: %v", score, found)
+	}
+}
+
+func TestSortedSet_Rank(t *testing.T) {
+	sl := NewSortedSet()
+	for i := 0; i < 1000; i++ {
+		sl.Add(fmt.Sprintf("member-%d", i), float64(i))
+	}
+
+	// Updating a score moves the member to its new position
+	sl.Add("member-0", 2000)
+
+	if rank, found := sl.Rank("member-1", false); !found || rank != 0 {
+		t.Errorf("Rank(member-1) = %d, want 0", rank)
+	}
+	if rank, found := sl.Rank("member-0", false); !found || rank != 999 {
+		t.Errorf("Rank(member-0) = %d, want 999", rank)
+	}
+	if rank, found := sl.Rank("member-0", true); !found || rank != 0 {
+		t.Errorf("Rank(member-0, reverse) = %d, want 0", rank)
+	}
+	if rank, found := sl.Rank("member-500", false); !found || rank != 499 {
+		t.Errorf("Rank(member-500) = %d, want 499", rank)
+	}
+
+	sl.Remove("member-250")
+	if rank, found := sl.Rank("member-500", false); !found || rank != 498 {
+		t.Errorf("Rank(member-500) = %d, want 498", rank)
+	}
+	if sl.Card() != 999 {
+		t.Errorf("Card() = %d, want 999", sl.Card())
+	}
+}
+
+func TestSortedSet_RangeByIndex(t *testing.T) {
+	sl := NewSortedSet()
+	sl.Add("a", 1)
+	sl.Add("b", 2)
+	sl.Add("c", 2)
+	sl.Add("d", 3)
+
+	entries := sl.RangeByIndex(1, -2, false)
+	if len(entries) != 2 || entries[0].Name != "b" || entries[1].Name != "c" {
+		t.Errorf("RangeByIndex(1, -2) = %v, want [b c]", entries)
+	}
+
+	entries = sl.RangeByIndex(0, 1, true)
+	if len(entries) != 2 || entries[0].Name != "d" || entries[1].Name != "c" {
+		t.Errorf("RangeByIndex(0, 1, reverse) = %v, want [d c]", entries)
+	}
+
+	if entries := sl.RangeByIndex(5, 10, false); len(entries) != 0 {
+		t.Errorf("RangeByIndex(5, 10) = %v, want []", entries)
+	}
+}
+
+func TestSortedSet_RangeByScore(t *testing.T) {
+	sl := NewSortedSet()
+	for i := 1; i <= 10; i++ {
+		sl.Add(fmt.Sprintf("m%02d", i), float64(i))
+	}
+
+	entries := sl.RangeByScore(ScoreRange{Min: 3, Max: 6, MinExclusive: true}, false, 0, -1)
+	if len(entries) != 3 || entries[0].Name != "m04" || entries[2].Name != "m06" {
+		t.Errorf("RangeByScore((3, 6]) = %v, want [m04 m05 m06]", entries)
+	}
+
+	entries = sl.RangeByScore(ScoreRange{Min: 3, Max: 6}, true, 1, 2)
+	if len(entries) != 2 || entries[0].Name != "m05" || entries[1].Name != "m04" {
+		t.Errorf("RangeByScore([3, 6], reverse, limit 1 2) = %v, want [m05 m04]", entries)
+	}
+
+	if entries := sl.RangeByScore(ScoreRange{Min: 20, Max: 30}, false, 0, -1); len(entries) != 0 {
+		t.Errorf("RangeByScore([20, 30]) = %v, want []", entries)
+	}
+}
+
+func TestSortedSet_JSON(t *testing.T) {
+	sl := NewSortedSet()
+	sl.Add("low", math.Inf(-1))
+	sl.Add("mid", 1.5)
+	sl.Add("high", math.Inf(1))
+
+	data, err := json.Marshal(sl)
+	if err != nil {
+		t.Fatalf("Marshal with infinite scores = %v", err)
+	}
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := restored.(*SortedSet)
+	for name, want := range map[string]float64{"low": math.Inf(-1), "mid": 1.5, "high": math.Inf(1)} {
+		if score, ok := other.GetScore(name); !ok || score != want {
+			t.Errorf("restored score of %s = %v, %v, want %v", name, score, ok, want)
+		}
+	}
+
+	var entry SortedSetEntry
+	if err := json.Unmarshal([]byte(`{"name":"a","score":"12"}`), &entry); err == nil {
+		t.Errorf("Unmarshal of a finite score as a string = %+v, want an error", entry)
 	}
 }
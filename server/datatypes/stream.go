@@ -0,0 +1,463 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrStreamIDTooSmall = errors.New("StreamIDTooSmall") // The ID is not greater than the last ID of the stream
+	ErrInvalidStreamID  = errors.New("InvalidStreamID")  // The ID is not of the form ms-seq
+	ErrGroupExists      = errors.New("GroupExists")      // A consumer group of the same name already exists
+	ErrNoGroup          = errors.New("NoGroup")          // The consumer group does not exist
+)
+
+// StreamID identifies an entry of a stream by the millisecond time it was added at, and a sequence
+// number to tell apart entries added in the same millisecond.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID is the largest possible stream ID.
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+// ParseStreamID parses an ID of the form ms-seq.
+func ParseStreamID(s string) (StreamID, error) {
+	ms, seq, ok := strings.Cut(s, "-")
+	if !ok {
+		return StreamID{}, ErrInvalidStreamID
+	}
+
+	var id StreamID
+	var err error
+	if id.Ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	if id.Seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	return id, nil
+}
+
+func (id StreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+func (id StreamID) Less(other StreamID) bool {
+	return id.Ms < other.Ms || (id.Ms == other.Ms && id.Seq < other.Seq)
+}
+
+// Next returns the smallest ID greater than id.
+func (id StreamID) Next() StreamID {
+	if id.Seq == math.MaxUint64 {
+		return StreamID{Ms: id.Ms + 1}
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq + 1}
+}
+
+func (id StreamID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *StreamID) UnmarshalText(text []byte) error {
+	parsed, err := ParseStreamID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// StreamEntry is an entry of a stream, whose fields are a flat list of field and value pairs.
+//
+// Fields is nil for an entry which was deleted from the stream while still pending in a consumer group.
+type StreamEntry struct {
+	ID     StreamID `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// PendingEntry tracks an entry delivered to a consumer of a group which it has not acknowledged yet.
+type PendingEntry struct {
+	ID            StreamID `json:"id"`
+	Consumer      string   `json:"consumer"`
+	DeliveryTime  int64    `json:"delivery_time"` // Unix time in milliseconds of the last delivery
+	DeliveryCount uint64   `json:"delivery_count"`
+}
+
+// ConsumerGroup tracks which entries of a stream have been delivered to its consumers, and
+// which of those are still pending acknowledgement.
+type ConsumerGroup struct {
+	LastDeliveredID StreamID                   `json:"last_delivered_id"`
+	Pending         map[StreamID]*PendingEntry `json:"pending"`
+	Consumers       map[string]int64           `json:"consumers"` // Unix time in milliseconds each consumer was last seen at
+}
+
+func newConsumerGroup(lastDeliveredID StreamID) *ConsumerGroup {
+	return &ConsumerGroup{
+		LastDeliveredID: lastDeliveredID,
+		Pending:         make(map[StreamID]*PendingEntry),
+		Consumers:       make(map[string]int64),
+	}
+}
+
+// Ack removes the IDs from the pending entries of the group, and returns the number of entries which were pending.
+func (g *ConsumerGroup) Ack(ids []StreamID) int {
+	count := 0
+	for _, id := range ids {
+		if _, ok := g.Pending[id]; ok {
+			delete(g.Pending, id)
+			count++
+		}
+	}
+	return count
+}
+
+// PendingEntries returns the pending entries of the group between start and end inclusive, sorted by ID.
+//
+// Only entries of the given consumer are returned if it is not empty, and only entries idle
+// for at least minIdle milliseconds at time now if minIdle is positive.
+func (g *ConsumerGroup) PendingEntries(start, end StreamID, count int, consumer string, minIdle, now int64) []PendingEntry {
+	entries := make([]PendingEntry, 0)
+	for _, pe := range g.sortedPending() {
+		if pe.ID.Less(start) || end.Less(pe.ID) {
+			continue
+		}
+		if consumer != "" && pe.Consumer != consumer {
+			continue
+		}
+		if minIdle > 0 && now-pe.DeliveryTime < minIdle {
+			continue
+		}
+		entries = append(entries, *pe)
+		if count > 0 && len(entries) == count {
+			break
+		}
+	}
+	return entries
+}
+
+func (g *ConsumerGroup) sortedPending() []*PendingEntry {
+	pending := make([]*PendingEntry, 0, len(g.Pending))
+	for _, pe := range g.Pending {
+		pending = append(pending, pe)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID.Less(pending[j].ID)
+	})
+	return pending
+}
+
+// ClaimOptions adjusts how XCLAIM updates the entries it claims.
+type ClaimOptions struct {
+	DeliveryTime  int64   // Sets the delivery time of claimed entries instead of now, if positive
+	DeliveryCount *uint64 // Sets the delivery count of claimed entries, if set
+	Force         bool    // Claims entries of the stream which are not pending in the group yet
+	JustID        bool    // Leaves the delivery count of claimed entries unchanged
+}
+
+// Stream is an append-only log of entries, sorted by ID, with consumer groups to share the entries between consumers.
+type Stream struct {
+	Name         string
+	entries      []StreamEntry
+	head         int // Index of the first entry which has not been trimmed
+	lastID       StreamID
+	entriesAdded uint64
+	groups       map[string]*ConsumerGroup
+}
+
+type streamJSON struct {
+	Name         string                    `json:"name"`
+	Entries      []StreamEntry             `json:"entries"`
+	LastID       StreamID                  `json:"last_id"`
+	EntriesAdded uint64                    `json:"entries_added"`
+	Groups       map[string]*ConsumerGroup `json:"groups"`
+}
+
+func NewStream() *Stream {
+	return &Stream{
+		Name:    "stream",
+		entries: make([]StreamEntry, 0),
+		groups:  make(map[string]*ConsumerGroup),
+	}
+}
+
+func (s *Stream) GetName() string {
+	return s.Name
+}
+
+func (s *Stream) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&streamJSON{
+		Name:         s.Name,
+		Entries:      s.entries[s.head:],
+		LastID:       s.lastID,
+		EntriesAdded: s.entriesAdded,
+		Groups:       s.groups,
+	})
+}
+
+func (s *Stream) UnmarshalJSON(data []byte) error {
+	var v streamJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*s = *NewStream()
+	s.entries = append(s.entries, v.Entries...)
+	s.lastID = v.LastID
+	s.entriesAdded = v.EntriesAdded
+	for name, g := range v.Groups {
+		group := newConsumerGroup(g.LastDeliveredID)
+		for id, pe := range g.Pending {
+			group.Pending[id] = pe
+		}
+		for consumer, seen := range g.Consumers {
+			group.Consumers[consumer] = seen
+		}
+		s.groups[name] = group
+	}
+	return nil
+}
+
+// Len returns the number of entries in the stream.
+func (s *Stream) Len() int {
+	return len(s.entries) - s.head
+}
+
+func (s *Stream) LastID() StreamID {
+	return s.lastID
+}
+
+// NextID returns the smallest ID which can be added to the stream with a time of at least ms.
+func (s *Stream) NextID(ms uint64) StreamID {
+	if ms > s.lastID.Ms {
+		return StreamID{Ms: ms}
+	}
+	return s.lastID.Next()
+}
+
+// Add appends an entry to the stream. The ID must be greater than the last ID of the stream.
+func (s *Stream) Add(id StreamID, fields []string) error {
+	if !s.lastID.Less(id) {
+		return ErrStreamIDTooSmall
+	}
+
+	s.entries = append(s.entries, StreamEntry{ID: id, Fields: fields})
+	s.lastID = id
+	s.entriesAdded++
+	return nil
+}
+
+// Range returns up to count entries between start and end inclusive, from the newest if reverse is set.
+// A count of zero returns all entries in the range.
+func (s *Stream) Range(start, end StreamID, count int, reverse bool) []StreamEntry {
+	live := s.entries[s.head:]
+	lo := sort.Search(len(live), func(i int) bool {
+		return !live[i].ID.Less(start)
+	})
+	hi := sort.Search(len(live), func(i int) bool {
+		return end.Less(live[i].ID)
+	})
+
+	entries := make([]StreamEntry, 0)
+	if lo >= hi {
+		return entries
+	}
+
+	if reverse {
+		for i := hi - 1; i >= lo && (count == 0 || len(entries) < count); i-- {
+			entries = append(entries, live[i])
+		}
+	} else {
+		for i := lo; i < hi && (count == 0 || len(entries) < count); i++ {
+			entries = append(entries, live[i])
+		}
+	}
+	return entries
+}
+
+// TrimMaxLen removes the oldest entries until at most maxLen remain, and returns the number of entries removed.
+func (s *Stream) TrimMaxLen(maxLen int) int {
+	removed := max(s.Len()-maxLen, 0)
+	s.dropHead(removed)
+	return removed
+}
+
+// TrimMinID removes the entries with an ID smaller than minID, and returns the number of entries removed.
+func (s *Stream) TrimMinID(minID StreamID) int {
+	live := s.entries[s.head:]
+	removed := sort.Search(len(live), func(i int) bool {
+		return !live[i].ID.Less(minID)
+	})
+	s.dropHead(removed)
+	return removed
+}
+
+func (s *Stream) dropHead(n int) {
+	clear(s.entries[s.head : s.head+n])
+	s.head += n
+
+	// Compact once most of the backing array is trimmed, so that trimming stays cheap on average.
+	if s.head > len(s.entries)/2 {
+		s.entries = append(make([]StreamEntry, 0, s.Len()), s.entries[s.head:]...)
+		s.head = 0
+	}
+}
+
+// entry returns the entry with the given ID, and false if it is not in the stream.
+func (s *Stream) entry(id StreamID) (StreamEntry, bool) {
+	live := s.entries[s.head:]
+	idx := sort.Search(len(live), func(i int) bool {
+		return !live[i].ID.Less(id)
+	})
+	if idx < len(live) && live[idx].ID == id {
+		return live[idx], true
+	}
+	return StreamEntry{}, false
+}
+
+// CreateGroup creates a consumer group which delivers the entries after lastDeliveredID.
+func (s *Stream) CreateGroup(name string, lastDeliveredID StreamID) error {
+	if _, ok := s.groups[name]; ok {
+		return ErrGroupExists
+	}
+	s.groups[name] = newConsumerGroup(lastDeliveredID)
+	return nil
+}
+
+func (s *Stream) Group(name string) (*ConsumerGroup, error) {
+	g, ok := s.groups[name]
+	if !ok {
+		return nil, ErrNoGroup
+	}
+	return g, nil
+}
+
+// ReadGroup delivers up to count entries which no consumer of the group has seen yet to the consumer,
+// at time now. The entries are added to the pending entries of the group, unless noAck is set.
+// A count of zero delivers all new entries.
+func (s *Stream) ReadGroup(g *ConsumerGroup, consumer string, count int, noAck bool, now int64) []StreamEntry {
+	g.Consumers[consumer] = now
+
+	entries := s.Range(g.LastDeliveredID.Next(), MaxStreamID, count, false)
+	if g.LastDeliveredID == MaxStreamID {
+		entries = entries[:0]
+	}
+
+	for _, e := range entries {
+		g.LastDeliveredID = e.ID
+		if !noAck {
+			g.Pending[e.ID] = &PendingEntry{ID: e.ID, Consumer: consumer, DeliveryTime: now, DeliveryCount: 1}
+		}
+	}
+	return entries
+}
+
+// ReadGroupHistory returns up to count entries pending for the consumer with an ID greater than after,
+// without delivering them again. A count of zero returns all of them.
+func (s *Stream) ReadGroupHistory(g *ConsumerGroup, consumer string, after StreamID, count int, now int64) []StreamEntry {
+	g.Consumers[consumer] = now
+
+	entries := make([]StreamEntry, 0)
+	for _, pe := range g.sortedPending() {
+		if pe.Consumer != consumer || !after.Less(pe.ID) {
+			continue
+		}
+		e, _ := s.entry(pe.ID)
+		entries = append(entries, StreamEntry{ID: pe.ID, Fields: e.Fields})
+		if count > 0 && len(entries) == count {
+			break
+		}
+	}
+	return entries
+}
+
+// Claim transfers the pending entries with the given IDs which have been idle for at least minIdle
+// milliseconds at time now to the consumer. It returns the entries claimed, and drops entries which
+// are no longer in the stream from the pending entries.
+func (s *Stream) Claim(g *ConsumerGroup, consumer string, minIdle int64, ids []StreamID, now int64, opts ClaimOptions) []StreamEntry {
+	g.Consumers[consumer] = now
+
+	claimed := make([]StreamEntry, 0)
+	for _, id := range ids {
+		e, exists := s.entry(id)
+		pe, pending := g.Pending[id]
+
+		if !pending && opts.Force && exists {
+			pe = &PendingEntry{ID: id}
+			g.Pending[id] = pe
+			pending = true
+		}
+		if !pending {
+			continue
+		}
+		if !exists {
+			delete(g.Pending, id)
+			continue
+		}
+		if minIdle > 0 && now-pe.DeliveryTime < minIdle {
+			continue
+		}
+
+		s.claim(pe, consumer, now, opts)
+		claimed = append(claimed, e)
+	}
+	return claimed
+}
+
+// AutoClaim claims up to count pending entries from start onwards which have been idle for at least
+// minIdle milliseconds at time now, as Claim does. It returns the ID to continue from, or 0-0 once
+// every pending entry has been scanned, the entries claimed, and the IDs dropped from the pending
+// entries as they are no longer in the stream.
+func (s *Stream) AutoClaim(g *ConsumerGroup, consumer string, minIdle int64, start StreamID, count int, now int64, justID bool) (StreamID, []StreamEntry, []StreamID) {
+	g.Consumers[consumer] = now
+
+	claimed := make([]StreamEntry, 0)
+	deleted := make([]StreamID, 0)
+	// Deleted entries count towards the scan too, so that a call does a bounded amount of work.
+	attempts := count * 10
+
+	for _, pe := range g.sortedPending() {
+		if pe.ID.Less(start) {
+			continue
+		}
+		if len(claimed) == count || attempts == 0 {
+			return pe.ID, claimed, deleted
+		}
+		attempts--
+
+		e, exists := s.entry(pe.ID)
+		if !exists {
+			delete(g.Pending, pe.ID)
+			deleted = append(deleted, pe.ID)
+			continue
+		}
+		if minIdle > 0 && now-pe.DeliveryTime < minIdle {
+			continue
+		}
+
+		s.claim(pe, consumer, now, ClaimOptions{JustID: justID})
+		claimed = append(claimed, e)
+	}
+	return StreamID{}, claimed, deleted
+}
+
+func (s *Stream) claim(pe *PendingEntry, consumer string, now int64, opts ClaimOptions) {
+	pe.Consumer = consumer
+	pe.DeliveryTime = now
+	if opts.DeliveryTime > 0 {
+		pe.DeliveryTime = opts.DeliveryTime
+	}
+
+	switch {
+	case opts.DeliveryCount != nil:
+		pe.DeliveryCount = *opts.DeliveryCount
+	case !opts.JustID:
+		pe.DeliveryCount++
+	}
+}
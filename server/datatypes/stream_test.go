@@ -0,0 +1,182 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseStreamID(t *testing.T) {
+	id, err := ParseStreamID("1700000000000-5")
+	if err != nil || id != (StreamID{Ms: 1700000000000, Seq: 5}) {
+		t.Errorf("ParseStreamID = %v, %v, want 1700000000000-5", id, err)
+	}
+	if id.String() != "1700000000000-5" {
+		t.Errorf("String() = %s, want 1700000000000-5", id.String())
+	}
+
+	for _, s := range []string{"", "12", "a-1", "1-b", "-1-2"} {
+		if _, err := ParseStreamID(s); err != ErrInvalidStreamID {
+			t.Errorf("ParseStreamID(%q) = %v, want ErrInvalidStreamID", s, err)
+		}
+	}
+}
+
+func TestStream_Add(t *testing.T) {
+	s := NewStream()
+
+	if id := s.NextID(100); id != (StreamID{Ms: 100}) {
+		t.Errorf("NextID(100) on an empty stream = %v, want 100-0", id)
+	}
+	if err := s.Add(StreamID{}, []string{"a", "1"}); err != ErrStreamIDTooSmall {
+		t.Errorf("Add(0-0) = %v, want ErrStreamIDTooSmall", err)
+	}
+	if err := s.Add(StreamID{Ms: 100}, []string{"a", "1"}); err != nil {
+		t.Fatalf("Add(100-0) = %v", err)
+	}
+
+	// The clock going backwards must not break the ordering of IDs.
+	if id := s.NextID(50); id != (StreamID{Ms: 100, Seq: 1}) {
+		t.Errorf("NextID(50) = %v, want 100-1", id)
+	}
+	if err := s.Add(StreamID{Ms: 100}, nil); err != ErrStreamIDTooSmall {
+		t.Errorf("Add of a duplicate ID = %v, want ErrStreamIDTooSmall", err)
+	}
+}
+
+func newTestStream(t *testing.T, n int) *Stream {
+	s := NewStream()
+	for i := 1; i <= n; i++ {
+		if err := s.Add(StreamID{Ms: uint64(i)}, []string{"n", string(rune('a' + i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func ids(entries []StreamEntry) []uint64 {
+	result := make([]uint64, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.ID.Ms)
+	}
+	return result
+}
+
+func TestStream_Range(t *testing.T) {
+	s := newTestStream(t, 5)
+
+	if got := ids(s.Range(StreamID{}, MaxStreamID, 0, false)); !reflect.DeepEqual(got, []uint64{1, 2, 3, 4, 5}) {
+		t.Errorf("full Range = %v", got)
+	}
+	if got := ids(s.Range(StreamID{Ms: 2}, StreamID{Ms: 4}, 2, true)); !reflect.DeepEqual(got, []uint64{4, 3}) {
+		t.Errorf("reverse Range with count = %v, want [4 3]", got)
+	}
+	if got := s.Range(StreamID{Ms: 4}, StreamID{Ms: 2}, 0, false); len(got) != 0 {
+		t.Errorf("empty Range = %v", got)
+	}
+}
+
+func TestStream_Trim(t *testing.T) {
+	s := newTestStream(t, 10)
+
+	if removed := s.TrimMaxLen(7); removed != 3 || s.Len() != 7 {
+		t.Errorf("TrimMaxLen(7) removed %d, leaving %d entries", removed, s.Len())
+	}
+	if removed := s.TrimMinID(StreamID{Ms: 6}); removed != 2 || s.Len() != 5 {
+		t.Errorf("TrimMinID(6) removed %d, leaving %d entries", removed, s.Len())
+	}
+	if got := ids(s.Range(StreamID{}, MaxStreamID, 0, false)); !reflect.DeepEqual(got, []uint64{6, 7, 8, 9, 10}) {
+		t.Errorf("Range after trimming = %v", got)
+	}
+	if s.LastID() != (StreamID{Ms: 10}) {
+		t.Errorf("LastID() = %v after trimming, want 10-0", s.LastID())
+	}
+}
+
+func TestStream_ConsumerGroups(t *testing.T) {
+	s := newTestStream(t, 5)
+
+	if err := s.CreateGroup("g", StreamID{Ms: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateGroup("g", StreamID{}); err != ErrGroupExists {
+		t.Errorf("CreateGroup of an existing group = %v, want ErrGroupExists", err)
+	}
+	if _, err := s.Group("nope"); err != ErrNoGroup {
+		t.Errorf("Group(\"nope\") = %v, want ErrNoGroup", err)
+	}
+	g, _ := s.Group("g")
+
+	if got := ids(s.ReadGroup(g, "alice", 2, false, 1000)); !reflect.DeepEqual(got, []uint64{2, 3}) {
+		t.Errorf("ReadGroup for alice = %v, want [2 3]", got)
+	}
+	if got := ids(s.ReadGroup(g, "bob", 0, false, 2000)); !reflect.DeepEqual(got, []uint64{4, 5}) {
+		t.Errorf("ReadGroup for bob = %v, want [4 5]", got)
+	}
+	if got := s.ReadGroup(g, "bob", 0, false, 2000); len(got) != 0 {
+		t.Errorf("ReadGroup with nothing new = %v", got)
+	}
+
+	if got := ids(s.ReadGroupHistory(g, "alice", StreamID{}, 0, 3000)); !reflect.DeepEqual(got, []uint64{2, 3}) {
+		t.Errorf("ReadGroupHistory for alice = %v, want [2 3]", got)
+	}
+
+	if n := g.Ack([]StreamID{{Ms: 2}, {Ms: 99}}); n != 1 {
+		t.Errorf("Ack = %d, want 1", n)
+	}
+
+	pending := g.PendingEntries(StreamID{}, MaxStreamID, 0, "", 0, 3000)
+	if len(pending) != 3 || pending[0].ID.Ms != 3 || pending[0].Consumer != "alice" {
+		t.Errorf("PendingEntries = %+v", pending)
+	}
+	if idle := g.PendingEntries(StreamID{}, MaxStreamID, 0, "", 1500, 3000); len(idle) != 1 {
+		t.Errorf("PendingEntries idle for 1500ms = %+v, want only entry 3", idle)
+	}
+
+	// Alice crashed, so bob takes over her entry once it has been idle long enough.
+	if claimed := s.Claim(g, "bob", 5000, []StreamID{{Ms: 3}}, 3000, ClaimOptions{}); len(claimed) != 0 {
+		t.Errorf("Claim of a fresh entry = %v, want nothing", claimed)
+	}
+	claimed := s.Claim(g, "bob", 1000, []StreamID{{Ms: 3}}, 3000, ClaimOptions{})
+	if len(claimed) != 1 || g.Pending[StreamID{Ms: 3}].Consumer != "bob" || g.Pending[StreamID{Ms: 3}].DeliveryCount != 2 {
+		t.Errorf("Claim = %v, pending = %+v", claimed, g.Pending[StreamID{Ms: 3}])
+	}
+
+	s.TrimMaxLen(1)
+	next, claimed, deleted := s.AutoClaim(g, "carol", 0, StreamID{}, 10, 4000, false)
+	if next != (StreamID{}) || !reflect.DeepEqual(ids(claimed), []uint64{5}) || len(deleted) != 2 {
+		t.Errorf("AutoClaim = %v, %v, %v, want 0-0, [5] and two deleted IDs", next, ids(claimed), deleted)
+	}
+	if len(g.Pending) != 1 {
+		t.Errorf("%d entries pending after AutoClaim, want 1", len(g.Pending))
+	}
+}
+
+func TestStream_JSON(t *testing.T) {
+	s := newTestStream(t, 3)
+	s.CreateGroup("g", StreamID{})
+	g, _ := s.Group("g")
+	s.ReadGroup(g, "alice", 1, false, 1000)
+	s.TrimMaxLen(2)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &Stream{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := json.Marshal(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("restored stream encodes to %s, want %s", again, data)
+	}
+	if rg, err := restored.Group("g"); err != nil || rg.Pending[StreamID{Ms: 1}].Consumer != "alice" {
+		t.Errorf("restored group lost its pending entries")
+	}
+}
@@ -1,6 +1,16 @@
 package datatypes
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+)
+
+// MaxStringLength bounds the number of bytes APPEND and SETRANGE can grow a string to.
+const MaxStringLength = 512 << 20
+
+var ErrStringTooLong = errors.New("StringTooLong") // A write would grow a string past MaxStringLength
 
 type String struct {
 	Value string `json:"value"`
@@ -8,7 +18,8 @@ type String struct {
 }
 
 func (s *String) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s)
+	type plain String
+	return json.Marshal((*plain)(s))
 }
 
 func NewString(s string) *String {
@@ -22,3 +33,148 @@ func (s *String) GetName() string {
 func (s *String) GetValue() string {
 	return s.Value
 }
+
+// Len returns the length of the string in bytes.
+func (s *String) Len() int {
+	return len(s.Value)
+}
+
+// Append appends a value to the string and returns its new length.
+func (s *String) Append(value string) (int, error) {
+	if len(s.Value)+len(value) > MaxStringLength {
+		return 0, ErrStringTooLong
+	}
+	s.Value += value
+	return len(s.Value), nil
+}
+
+// GetRange returns the bytes from start to end inclusive, where negative offsets count back from the end.
+// Offsets past either end are clamped, so a range that misses the string returns an empty string.
+func (s *String) GetRange(start, end int) string {
+	n := len(s.Value)
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if end < 0 {
+		end = n + end
+	}
+	end = min(end, n-1)
+	if start > end {
+		return ""
+	}
+	return s.Value[start : end+1]
+}
+
+// SetRange overwrites the string with a value from a byte offset, padding it with zero bytes if it
+// is shorter than the offset, and returns its new length.
+func (s *String) SetRange(offset int, value string) (int, error) {
+	if offset+len(value) > MaxStringLength {
+		return 0, ErrStringTooLong
+	}
+	if value == "" {
+		return len(s.Value), nil
+	}
+
+	b := []byte(s.Value)
+	if end := offset + len(value); end > len(b) {
+		b = append(b, make([]byte, end-len(b))...)
+	}
+	copy(b[offset:], value)
+	s.Value = string(b)
+	return len(s.Value), nil
+}
+
+// IncrBy adds incr to the integer value of the string and returns the new value.
+func (s *String) IncrBy(incr int64) (int64, error) {
+	value, err := incrInteger(s.Value, incr)
+	if err != nil {
+		return 0, err
+	}
+	s.Value = strconv.FormatInt(value, 10)
+	return value, nil
+}
+
+// IncrByFloat adds incr to the float value of the string and returns the new value.
+func (s *String) IncrByFloat(incr float64) (float64, error) {
+	value, err := incrFloat(s.Value, incr)
+	if err != nil {
+		return 0, err
+	}
+	s.Value = strconv.FormatFloat(value, 'f', -1, 64)
+	return value, nil
+}
+
+// ThrottleResult is the outcome of a rate limited request, with durations in microseconds.
+type ThrottleResult struct {
+	Allowed    bool
+	Limit      int64 // The most requests allowed at once, which is one more than the burst
+	Remaining  int64 // How many more requests would be allowed right away
+	RetryAfter int64 // How long until the request would be allowed, or -1 if it was, or it never would be
+	ResetAfter int64 // How long until the limit is back to allowing its full burst
+}
+
+// Throttle rate limits a request of quantity units with the generic cell rate algorithm (GCRA). The string holds
+// the theoretical arrival time (TAT) of the next request as a Unix time in microseconds, which the request pushes
+// back by interval per unit if it is allowed. A request is allowed if that leaves the TAT no more than maxBurst+1
+// intervals after now, and if it is denied the string is left unchanged.
+//
+// now is a Unix time in microseconds, and interval is the positive time in microseconds it takes to earn back
+// one unit. maxBurst and quantity must not be negative.
+func (s *String) Throttle(now, interval, maxBurst, quantity int64) (ThrottleResult, error) {
+	tat, err := strconv.ParseInt(s.Value, 10, 64)
+	if err != nil {
+		return ThrottleResult{}, ErrNotAnInteger
+	}
+	tat = max(tat, now)
+
+	limit := maxBurst + 1
+	if limit > math.MaxInt64/interval || quantity > math.MaxInt64/interval {
+		return ThrottleResult{}, ErrOverflow
+	}
+	tolerance, increment := interval*limit, interval*quantity
+	if tat > math.MaxInt64-increment {
+		return ThrottleResult{}, ErrOverflow
+	}
+	newTat := tat + increment
+
+	result := ThrottleResult{Limit: limit, RetryAfter: -1}
+	if allowAt := newTat - tolerance; now < allowAt {
+		// A request for more than the burst would never be allowed, so there is no point retrying it.
+		if increment <= tolerance {
+			result.RetryAfter = allowAt - now
+		}
+		newTat = tat
+	} else {
+		result.Allowed = true
+		s.Value = strconv.FormatInt(newTat, 10)
+	}
+
+	result.ResetAfter = newTat - now
+	result.Remaining = max((tolerance-result.ResetAfter)/interval, 0)
+	return result, nil
+}
+
+// incrInteger adds incr to a value holding a 64-bit integer.
+func incrInteger(value string, incr int64) (int64, error) {
+	current, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrNotAnInteger
+	}
+	if (incr > 0 && current > math.MaxInt64-incr) || (incr < 0 && current < math.MinInt64-incr) {
+		return 0, ErrOverflow
+	}
+	return current + incr, nil
+}
+
+// incrFloat adds incr to a value holding a finite float.
+func incrFloat(value string, incr float64) (float64, error) {
+	current, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, ErrNotAFloat
+	}
+	current += incr
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return 0, ErrOverflow
+	}
+	return current, nil
+}
@@ -1 +1,149 @@
 package datatypes
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestString_IncrBy(t *testing.T) {
+	s := NewString("10")
+	if v, err := s.IncrBy(5); err != nil || v != 15 {
+		t.Errorf("IncrBy(5) = %d, %v, want 15", v, err)
+	}
+	if v, err := s.IncrBy(-20); err != nil || v != -5 || s.GetValue() != "-5" {
+		t.Errorf("IncrBy(-20) = %d, %v, stored %q, want -5", v, err, s.GetValue())
+	}
+
+	s = NewString(strconv.FormatInt(math.MinInt64, 10))
+	if _, err := s.IncrBy(-1); err != ErrOverflow {
+		t.Errorf("IncrBy past MinInt64 = %v, want ErrOverflow", err)
+	}
+	if s.GetValue() != strconv.FormatInt(math.MinInt64, 10) {
+		t.Errorf("a failed IncrBy changed the value to %q", s.GetValue())
+	}
+
+	for _, value := range []string{"abc", "1.5", " 1", "", "99999999999999999999"} {
+		if _, err := NewString(value).IncrBy(1); err != ErrNotAnInteger {
+			t.Errorf("IncrBy on %q = %v, want ErrNotAnInteger", value, err)
+		}
+	}
+}
+
+func TestString_IncrByFloat(t *testing.T) {
+	s := NewString("10")
+	if v, err := s.IncrByFloat(0.5); err != nil || v != 10.5 || s.GetValue() != "10.5" {
+		t.Errorf("IncrByFloat(0.5) = %v, %v, stored %q, want 10.5", v, err, s.GetValue())
+	}
+	if v, err := s.IncrByFloat(-0.5); err != nil || s.GetValue() != "10" {
+		t.Errorf("IncrByFloat(-0.5) = %v, %v, stored %q, want \"10\"", v, err, s.GetValue())
+	}
+
+	for _, value := range []string{"abc", "inf", "NaN", ""} {
+		if _, err := NewString(value).IncrByFloat(1); err != ErrNotAFloat {
+			t.Errorf("IncrByFloat on %q = %v, want ErrNotAFloat", value, err)
+		}
+	}
+
+	s = NewString(strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64))
+	if _, err := s.IncrByFloat(math.MaxFloat64); err != ErrOverflow {
+		t.Errorf("IncrByFloat to infinity = %v, want ErrOverflow", err)
+	}
+}
+
+func TestString_Append(t *testing.T) {
+	s := NewString("hello")
+	if n, err := s.Append(" world"); err != nil || n != 11 || s.GetValue() != "hello world" {
+		t.Errorf("Append = %d, %v, stored %q, want 11", n, err, s.GetValue())
+	}
+	if s.Len() != 11 {
+		t.Errorf("Len = %d, want 11", s.Len())
+	}
+
+	s = NewString(strings.Repeat("a", MaxStringLength))
+	if _, err := s.Append("b"); err != ErrStringTooLong {
+		t.Errorf("Append past MaxStringLength = %v, want ErrStringTooLong", err)
+	}
+}
+
+func TestString_GetRange(t *testing.T) {
+	s := NewString("This is a string")
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{0, 3, "This"},
+		{-3, -1, "ing"},
+		{0, -1, "This is a string"},
+		{10, 100, "string"},
+		{-100, 3, "This"},
+		{5, 4, ""},
+		{100, 200, ""},
+		{-1, -5, ""},
+	}
+	for _, tt := range tests {
+		if got := s.GetRange(tt.start, tt.end); got != tt.want {
+			t.Errorf("GetRange(%d, %d) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+	if got := NewString("").GetRange(0, -1); got != "" {
+		t.Errorf("GetRange of an empty string = %q", got)
+	}
+}
+
+func TestString_SetRange(t *testing.T) {
+	s := NewString("Hello World")
+	if n, err := s.SetRange(6, "Redis"); err != nil || n != 11 || s.GetValue() != "Hello Redis" {
+		t.Errorf("SetRange(6) = %d, %v, stored %q, want \"Hello Redis\"", n, err, s.GetValue())
+	}
+
+	s = NewString("ab")
+	if n, err := s.SetRange(4, "cd"); err != nil || n != 6 || s.GetValue() != "ab\x00\x00cd" {
+		t.Errorf("SetRange past the end = %d, %v, stored %q, want zero padding", n, err, s.GetValue())
+	}
+	if n, err := s.SetRange(100, ""); err != nil || n != 6 {
+		t.Errorf("SetRange of nothing = %d, %v, want the length unchanged", n, err)
+	}
+
+	if _, err := s.SetRange(MaxStringLength, "x"); err != ErrStringTooLong {
+		t.Errorf("SetRange past MaxStringLength = %v, want ErrStringTooLong", err)
+	}
+}
+
+func TestString_Throttle(t *testing.T) {
+	const now, interval = 1_000_000, 1000
+	s := NewString("0")
+
+	// A burst of 2 allows 3 requests at once, and then one more per interval.
+	tests := []struct {
+		now, quantity int64
+		want          ThrottleResult
+	}{
+		{now, 1, ThrottleResult{Allowed: true, Limit: 3, Remaining: 2, RetryAfter: -1, ResetAfter: 1000}},
+		{now, 1, ThrottleResult{Allowed: true, Limit: 3, Remaining: 1, RetryAfter: -1, ResetAfter: 2000}},
+		{now, 1, ThrottleResult{Allowed: true, Limit: 3, Remaining: 0, RetryAfter: -1, ResetAfter: 3000}},
+		{now, 1, ThrottleResult{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: 1000, ResetAfter: 3000}},
+		{now + 1000, 1, ThrottleResult{Allowed: true, Limit: 3, Remaining: 0, RetryAfter: -1, ResetAfter: 3000}},
+		{now + 1500, 0, ThrottleResult{Allowed: true, Limit: 3, Remaining: 0, RetryAfter: -1, ResetAfter: 2500}},
+		{now + 1500, 4, ThrottleResult{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: -1, ResetAfter: 2500}},
+		{now + 1_000_000, 2, ThrottleResult{Allowed: true, Limit: 3, Remaining: 1, RetryAfter: -1, ResetAfter: 2000}},
+	}
+	for i, tt := range tests {
+		before := s.GetValue()
+		got, err := s.Throttle(tt.now, interval, 2, tt.quantity)
+		if err != nil || got != tt.want {
+			t.Errorf("request %d: Throttle = %+v, %v, want %+v", i, got, err, tt.want)
+		}
+		if !got.Allowed && s.GetValue() != before {
+			t.Errorf("request %d: a denied request changed the TAT from %s to %s", i, before, s.GetValue())
+		}
+	}
+
+	if _, err := NewString("abc").Throttle(now, interval, 2, 1); err != ErrNotAnInteger {
+		t.Errorf("Throttle on a non-integer = %v, want ErrNotAnInteger", err)
+	}
+	if _, err := NewString("0").Throttle(now, math.MaxInt64/2, 2, 1); err != ErrOverflow {
+		t.Errorf("Throttle with an overflowing tolerance = %v, want ErrOverflow", err)
+	}
+}
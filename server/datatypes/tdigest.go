@@ -1 +1,384 @@
 package datatypes
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// DefaultTDigestCompression is the compression used when none is given, which bounds the digest to a few hundred centroids.
+const DefaultTDigestCompression = 100
+
+// centroid is the mean of a cluster of samples, weighted by the number of samples in it.
+type centroid struct {
+	Mean   float64
+	Weight float64
+}
+
+func (c centroid) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{c.Mean, c.Weight})
+}
+
+func (c *centroid) UnmarshalJSON(data []byte) error {
+	var v [2]float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.Mean, c.Weight = v[0], v[1]
+	return nil
+}
+
+// TDigest is a mergeable sketch of a distribution, which answers quantile and rank queries
+// with an accuracy that is highest towards the tails.
+//
+// Samples are buffered and merged into the centroids once the buffer fills up. Queries never
+// modify the digest, so every replica holds exactly the same centroids after the same writes.
+type TDigest struct {
+	compression       float64
+	centroids         []centroid // Merged centroids, sorted by mean
+	unmerged          []centroid
+	min               float64
+	max               float64
+	observations      uint64
+	totalCompressions uint64
+	Name              string `json:"name"`
+}
+
+type tDigestJSON struct {
+	Compression       float64    `json:"compression"`
+	Centroids         []centroid `json:"centroids"`
+	Unmerged          []centroid `json:"unmerged"`
+	Min               float64    `json:"min"`
+	Max               float64    `json:"max"`
+	Observations      uint64     `json:"observations"`
+	TotalCompressions uint64     `json:"total_compressions"`
+	Name              string     `json:"name"`
+}
+
+// NewTDigest creates an empty t-digest with the given compression.
+func NewTDigest(compression float64) *TDigest {
+	return &TDigest{
+		compression: compression,
+		centroids:   make([]centroid, 0),
+		unmerged:    make([]centroid, 0),
+		Name:        "tdigest",
+	}
+}
+
+func (td *TDigest) GetName() string {
+	return td.Name
+}
+
+func (td *TDigest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&tDigestJSON{
+		Compression:       td.compression,
+		Centroids:         td.centroids,
+		Unmerged:          td.unmerged,
+		Min:               td.min,
+		Max:               td.max,
+		Observations:      td.observations,
+		TotalCompressions: td.totalCompressions,
+		Name:              td.Name,
+	})
+}
+
+func (td *TDigest) UnmarshalJSON(data []byte) error {
+	var v tDigestJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*td = TDigest{
+		compression:       v.Compression,
+		centroids:         v.Centroids,
+		unmerged:          v.Unmerged,
+		min:               v.Min,
+		max:               v.Max,
+		observations:      v.Observations,
+		totalCompressions: v.TotalCompressions,
+		Name:              v.Name,
+	}
+	return nil
+}
+
+// Compression returns the compression parameter of the digest.
+func (td *TDigest) Compression() float64 {
+	return td.compression
+}
+
+// Capacity returns the number of samples buffered before they are merged into the centroids.
+func (td *TDigest) Capacity() int {
+	return int(math.Ceil(td.compression*5)) + 10
+}
+
+// Add adds samples to the digest.
+func (td *TDigest) Add(values ...float64) {
+	for _, value := range values {
+		td.add(centroid{Mean: value, Weight: 1}, value, value)
+	}
+}
+
+// Merge adds the contents of other digests into this one.
+func (td *TDigest) Merge(others ...*TDigest) {
+	for _, other := range others {
+		if other.observations == 0 {
+			continue
+		}
+		for _, c := range other.merged() {
+			td.add(c, other.min, other.max)
+		}
+	}
+}
+
+func (td *TDigest) add(c centroid, min, max float64) {
+	if td.observations == 0 || min < td.min {
+		td.min = min
+	}
+	if td.observations == 0 || max > td.max {
+		td.max = max
+	}
+	td.observations += uint64(c.Weight)
+
+	td.unmerged = append(td.unmerged, c)
+	if len(td.unmerged) >= td.Capacity() {
+		td.compress()
+	}
+}
+
+// compress merges the buffered samples into the centroids.
+func (td *TDigest) compress() {
+	if len(td.unmerged) == 0 {
+		return
+	}
+	td.centroids = td.mergeCentroids()
+	td.unmerged = td.unmerged[:0]
+	td.totalCompressions++
+}
+
+// merged returns the centroids including any buffered samples, without modifying the digest.
+func (td *TDigest) merged() []centroid {
+	if len(td.unmerged) == 0 {
+		return td.centroids
+	}
+	return td.mergeCentroids()
+}
+
+// mergeCentroids combines the centroids and buffered samples into a new list of centroids, where
+// adjacent centroids are merged for as long as they fit inside a single unit of the k1 scale function.
+func (td *TDigest) mergeCentroids() []centroid {
+	all := make([]centroid, 0, len(td.centroids)+len(td.unmerged))
+	all = append(all, td.centroids...)
+	all = append(all, td.unmerged...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Mean < all[j].Mean
+	})
+
+	var total float64
+	for _, c := range all {
+		total += c.Weight
+	}
+
+	result := make([]centroid, 0, len(td.centroids)+1)
+	current := all[0]
+	weightSoFar := 0.0
+	qLimit := td.qFromK(td.kFromQ(0) + 1)
+
+	for _, next := range all[1:] {
+		if (weightSoFar+current.Weight+next.Weight)/total <= qLimit {
+			current.Mean = weightedMean(current.Mean, current.Weight, next.Mean, next.Weight)
+			current.Weight += next.Weight
+			continue
+		}
+
+		weightSoFar += current.Weight
+		result = append(result, current)
+		qLimit = td.qFromK(td.kFromQ(weightSoFar/total) + 1)
+		current = next
+	}
+	return append(result, current)
+}
+
+// kFromQ is the k1 scale function, which keeps centroids small near the tails of the distribution.
+func (td *TDigest) kFromQ(q float64) float64 {
+	return td.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// qFromK is the inverse of kFromQ.
+func (td *TDigest) qFromK(k float64) float64 {
+	if k >= td.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/td.compression) + 1) / 2
+}
+
+// Min returns the smallest sample added to the digest, or NaN if it is empty.
+func (td *TDigest) Min() float64 {
+	if td.observations == 0 {
+		return math.NaN()
+	}
+	return td.min
+}
+
+// Max returns the largest sample added to the digest, or NaN if it is empty.
+func (td *TDigest) Max() float64 {
+	if td.observations == 0 {
+		return math.NaN()
+	}
+	return td.max
+}
+
+// Quantile returns an estimate of the value below which the fraction q of samples fall, or NaN if the digest is empty.
+func (td *TDigest) Quantile(q float64) float64 {
+	if td.observations == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return td.min
+	}
+	if q >= 1 {
+		return td.max
+	}
+
+	centroids := td.merged()
+	n := len(centroids)
+	if n == 1 {
+		return centroids[0].Mean
+	}
+
+	index := q * float64(td.observations)
+
+	// Interpolate between the minimum and the centre of the first centroid.
+	if index < centroids[0].Weight/2 {
+		z := index / (centroids[0].Weight / 2)
+		return weightedMean(td.min, 1-z, centroids[0].Mean, z)
+	}
+
+	weightSoFar := centroids[0].Weight / 2
+	for i := 0; i < n-1; i++ {
+		dw := (centroids[i].Weight + centroids[i+1].Weight) / 2
+		if weightSoFar+dw > index {
+			z := (index - weightSoFar) / dw
+			return weightedMean(centroids[i].Mean, 1-z, centroids[i+1].Mean, z)
+		}
+		weightSoFar += dw
+	}
+
+	// Interpolate between the centre of the last centroid and the maximum.
+	last := centroids[n-1]
+	z := math.Min((index-weightSoFar)/(last.Weight/2), 1)
+	return weightedMean(last.Mean, 1-z, td.max, z)
+}
+
+// CDF returns an estimate of the fraction of samples which are less than or equal to x, or NaN if the digest is empty.
+func (td *TDigest) CDF(x float64) float64 {
+	if td.observations == 0 {
+		return math.NaN()
+	}
+	if x < td.min {
+		return 0
+	}
+	if x >= td.max {
+		return 1
+	}
+
+	centroids := td.merged()
+	n := len(centroids)
+	total := float64(td.observations)
+	if n == 1 {
+		return fraction(x, td.min, td.max)
+	}
+
+	if x < centroids[0].Mean {
+		return centroids[0].Weight / 2 * fraction(x, td.min, centroids[0].Mean) / total
+	}
+
+	weightSoFar := centroids[0].Weight / 2
+	for i := 0; i < n-1; i++ {
+		dw := (centroids[i].Weight + centroids[i+1].Weight) / 2
+		if x < centroids[i+1].Mean {
+			return (weightSoFar + dw*fraction(x, centroids[i].Mean, centroids[i+1].Mean)) / total
+		}
+		weightSoFar += dw
+	}
+
+	last := centroids[n-1]
+	return (weightSoFar + last.Weight/2*fraction(x, last.Mean, td.max)) / total
+}
+
+// TrimmedMean returns the mean of the samples between the low and high quantiles, or NaN if there are none.
+func (td *TDigest) TrimmedMean(lowQuantile, highQuantile float64) float64 {
+	total := float64(td.observations)
+	low := lowQuantile * total
+	high := highQuantile * total
+
+	var mean, weight, weightSoFar float64
+	for _, c := range td.merged() {
+		overlap := math.Min(weightSoFar+c.Weight, high) - math.Max(weightSoFar, low)
+		if overlap > 0 {
+			mean = weightedMean(mean, weight, c.Mean, overlap)
+			weight += overlap
+		}
+		weightSoFar += c.Weight
+	}
+
+	if weight == 0 {
+		return math.NaN()
+	}
+	return mean
+}
+
+// MergedNodes returns the number of merged centroids.
+func (td *TDigest) MergedNodes() int {
+	return len(td.centroids)
+}
+
+// UnmergedNodes returns the number of buffered samples.
+func (td *TDigest) UnmergedNodes() int {
+	return len(td.unmerged)
+}
+
+// MergedWeight returns the total weight of the merged centroids.
+func (td *TDigest) MergedWeight() float64 {
+	var weight float64
+	for _, c := range td.centroids {
+		weight += c.Weight
+	}
+	return weight
+}
+
+// UnmergedWeight returns the total weight of the buffered samples.
+func (td *TDigest) UnmergedWeight() float64 {
+	var weight float64
+	for _, c := range td.unmerged {
+		weight += c.Weight
+	}
+	return weight
+}
+
+// Observations returns the number of samples added to the digest.
+func (td *TDigest) Observations() uint64 {
+	return td.observations
+}
+
+// TotalCompressions returns the number of times buffered samples have been merged into the centroids.
+func (td *TDigest) TotalCompressions() uint64 {
+	return td.totalCompressions
+}
+
+// fraction returns how far x lies between lo and hi, as a value between 0 and 1. The values are halved first, so
+// that the distances between them can't overflow.
+func fraction(x, lo, hi float64) float64 {
+	if hi <= lo {
+		return 1
+	}
+	return (x/2 - lo/2) / (hi/2 - lo/2)
+}
+
+// weightedMean returns the mean of a and b weighted by wa and wb. It weights each value by its share of the total
+// rather than scaling their difference, which overflows for large values of opposite signs, and clamps the result
+// between them against rounding.
+func weightedMean(a, wa, b, wb float64) float64 {
+	total := wa + wb
+	mean := a*(wa/total) + b*(wb/total)
+	return math.Max(math.Min(mean, math.Max(a, b)), math.Min(a, b))
+}
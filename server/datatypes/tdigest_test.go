@@ -1,129 +1,123 @@
 package datatypes
 
 import (
-	"hash/fnv"
-	"math/rand"
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
 )
 
-const (
-	bucketSize      = 4   // Number of entries per bucket
-	maxKicks        = 500 // Maximum number of kicks to relocate an entry
-	fingerprintSize = 1   // Size of the fingerprint in bytes
-)
-
-type CuckooFilter struct {
-	buckets [][bucketSize][]byte
-	size    uint // Number of elements in the filter
-}
+func TestTDigest_Quantile(t *testing.T) {
+	td := NewTDigest(DefaultTDigestCompression)
+	for i := 1; i <= 10000; i++ {
+		td.Add(float64(i))
+	}
 
-// NewCuckooFilter creates a new Cuckoo filter with the specified number of buckets.
-func NewCuckooFilter(numBuckets uint) *CuckooFilter {
-	return &CuckooFilter{
-		buckets: make([][bucketSize][]byte, numBuckets),
-		size:    0,
+	if td.Min() != 1 || td.Max() != 10000 {
+		t.Errorf("Min(), Max() = %v, %v, want 1, 10000", td.Min(), td.Max())
 	}
-}
 
-// Insert adds an item to the Cuckoo filter.
-func (cf *CuckooFilter) Insert(item string) bool {
-	fp := cf.fingerprint(item)
-	i1 := cf.hash(item)
-	i2 := cf.alternateIndex(i1, fp)
-
-	if cf.insertToBucket(fp, i1) || cf.insertToBucket(fp, i2) {
-		cf.size++
-		return true
-	}
-
-	// Perform cuckoo evictions
-	i := i1
-	for n := 0; n < maxKicks; n++ {
-		j := rand.Intn(bucketSize)
-		evictedFp := cf.buckets[i][j]
-		cf.buckets[i][j] = fp
-		fp = evictedFp
-		i = cf.alternateIndex(i, fp)
-		if cf.insertToBucket(fp, i) {
-			cf.size++
-			return true
+	for _, q := range []float64{0.01, 0.5, 0.99, 0.999} {
+		got := td.Quantile(q)
+		want := q * 10000
+		if math.Abs(got-want)/want > 0.01 {
+			t.Errorf("Quantile(%v) = %v, want about %v", q, got, want)
 		}
 	}
 
-	return false // Filter is likely full
-}
+	if cdf := td.CDF(2500); math.Abs(cdf-0.25) > 0.01 {
+		t.Errorf("CDF(2500) = %v, want about 0.25", cdf)
+	}
 
-// Lookup checks if an item is in the Cuckoo filter.
-func (cf *CuckooFilter) Lookup(item string) bool {
-	fp := cf.fingerprint(item)
-	i1 := cf.hash(item)
-	i2 := cf.alternateIndex(i1, fp)
+	if mean := td.TrimmedMean(0.1, 0.9); math.Abs(mean-5000) > 50 {
+		t.Errorf("TrimmedMean(0.1, 0.9) = %v, want about 5000", mean)
+	}
 
-	return cf.bucketContains(fp, i1) || cf.bucketContains(fp, i2)
+	if td.MergedNodes() > 200 {
+		t.Errorf("MergedNodes() = %d, want at most 200", td.MergedNodes())
+	}
 }
 
-// Delete removes an item from the Cuckoo filter.
-func (cf *CuckooFilter) Delete(item string) bool {
-	fp := cf.fingerprint(item)
-	i1 := cf.hash(item)
-	i2 := cf.alternateIndex(i1, fp)
-
-	if cf.deleteFromBucket(fp, i1) || cf.deleteFromBucket(fp, i2) {
-		cf.size--
-		return true
+func TestTDigest_Empty(t *testing.T) {
+	td := NewTDigest(DefaultTDigestCompression)
+	if !math.IsNaN(td.Quantile(0.5)) || !math.IsNaN(td.Min()) || !math.IsNaN(td.CDF(1)) {
+		t.Errorf("queries on an empty digest should return NaN")
 	}
-	return false
 }
 
-// Size returns the number of items in the filter.
-func (cf *CuckooFilter) Size() uint {
-	return cf.size
-}
+func TestTDigest_Merge(t *testing.T) {
+	low := NewTDigest(DefaultTDigestCompression)
+	high := NewTDigest(DefaultTDigestCompression)
+	for i := 1; i <= 5000; i++ {
+		low.Add(float64(i))
+		high.Add(float64(i + 5000))
+	}
 
-func (cf *CuckooFilter) fingerprint(item string) []byte {
-	h := fnv.New64a()
-	h.Write([]byte(item))
-	fp := make([]byte, fingerprintSize)
-	copy(fp, h.Sum(nil)[:fingerprintSize])
-	return fp
-}
+	merged := NewTDigest(DefaultTDigestCompression)
+	merged.Merge(low, high)
 
-func (cf *CuckooFilter) hash(item string) uint {
-	h := fnv.New64a()
-	h.Write([]byte(item))
-	return uint(h.Sum64() % uint64(len(cf.buckets)))
+	if merged.Observations() != 10000 {
+		t.Errorf("Observations() = %d, want 10000", merged.Observations())
+	}
+	if median := merged.Quantile(0.5); math.Abs(median-5000) > 100 {
+		t.Errorf("Quantile(0.5) = %v, want about 5000", median)
+	}
 }
 
-func (cf *CuckooFilter) alternateIndex(i uint, fp []byte) uint {
-	h := fnv.New64a()
-	h.Write(fp)
-	return uint(i) ^ uint(h.Sum64()%uint64(len(cf.buckets)))
-}
+func TestTDigest_JSON(t *testing.T) {
+	td := NewTDigest(50)
+	for i := 0; i < 1000; i++ {
+		td.Add(float64(i % 97))
+	}
 
-func (cf *CuckooFilter) insertToBucket(fp []byte, i uint) bool {
-	for j := 0; j < bucketSize; j++ {
-		if len(cf.buckets[i][j]) == 0 {
-			cf.buckets[i][j] = fp
-			return true
-		}
+	b1, err := json.Marshal(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &TDigest{}
+	if err := json.Unmarshal(b1, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	b2, err := json.Marshal(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("t-digest did not round trip through JSON")
+	}
+	if restored.Quantile(0.5) != td.Quantile(0.5) {
+		t.Errorf("Quantile(0.5) = %v after restoring, want %v", restored.Quantile(0.5), td.Quantile(0.5))
 	}
-	return false
 }
 
-func (cf *CuckooFilter) bucketContains(fp []byte, i uint) bool {
-	for j := 0; j < bucketSize; j++ {
-		if string(cf.buckets[i][j]) == string(fp) {
-			return true
+func TestTDigest_LargeValues(t *testing.T) {
+	td := NewTDigest(DefaultTDigestCompression)
+	for i := 0; i < 1000; i++ {
+		td.Add(-1e308, 1e308)
+	}
+	td.compress()
+
+	// A centroid merging values of opposite signs must not overflow, or the digest can't be snapshotted.
+	for _, c := range td.centroids {
+		if math.IsNaN(c.Mean) || math.IsInf(c.Mean, 0) {
+			t.Fatalf("centroid mean = %v", c.Mean)
 		}
 	}
-	return false
-}
+	if _, err := json.Marshal(td); err != nil {
+		t.Errorf("json.Marshal = %v", err)
+	}
 
-func (cf *CuckooFilter) deleteFromBucket(fp []byte, i uint) bool {
-	for j := 0; j < bucketSize; j++ {
-		if string(cf.buckets[i][j]) == string(fp) {
-			cf.buckets[i][j] = nil
-			return true
+	for _, q := range []float64{0.25, 0.5, 0.75} {
+		if got := td.Quantile(q); math.IsNaN(got) || math.IsInf(got, 0) {
+			t.Errorf("Quantile(%v) = %v", q, got)
 		}
 	}
-	return false
+	if got := td.CDF(0); got <= 0 || got >= 1 {
+		t.Errorf("CDF(0) = %v, want between 0 and 1", got)
+	}
+	if got := td.TrimmedMean(0, 1); math.IsNaN(got) || math.IsInf(got, 0) {
+		t.Errorf("TrimmedMean(0, 1) = %v", got)
+	}
 }
@@ -1 +1,321 @@
 package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+var (
+	ErrDuplicateSample = errors.New("DuplicateSample") // A sample already exists at the timestamp and the duplicate policy is BLOCK
+	ErrSampleTooOld    = errors.New("SampleTooOld")    // The timestamp is older than the retention window of the series
+)
+
+// DuplicatePolicy decides how a sample is merged with an existing sample at the same timestamp.
+type DuplicatePolicy string
+
+const (
+	DuplicateBlock DuplicatePolicy = "BLOCK" // Reject the new sample
+	DuplicateFirst DuplicatePolicy = "FIRST" // Keep the existing sample
+	DuplicateLast  DuplicatePolicy = "LAST"  // Replace the existing sample
+	DuplicateMin   DuplicatePolicy = "MIN"   // Keep the smaller value
+	DuplicateMax   DuplicatePolicy = "MAX"   // Keep the larger value
+	DuplicateSum   DuplicatePolicy = "SUM"   // Add the values together
+)
+
+// Aggregator reduces the samples in a time bucket to a single value.
+type Aggregator string
+
+const (
+	AggregateAvg   Aggregator = "AVG"
+	AggregateSum   Aggregator = "SUM"
+	AggregateMin   Aggregator = "MIN"
+	AggregateMax   Aggregator = "MAX"
+	AggregateRange Aggregator = "RANGE" // Difference between the largest and smallest value
+	AggregateCount Aggregator = "COUNT"
+	AggregateFirst Aggregator = "FIRST"
+	AggregateLast  Aggregator = "LAST"
+	AggregateStdP  Aggregator = "STD.P" // Population standard deviation
+	AggregateStdS  Aggregator = "STD.S" // Sample standard deviation
+	AggregateVarP  Aggregator = "VAR.P" // Population variance
+	AggregateVarS  Aggregator = "VAR.S" // Sample variance
+)
+
+// Sample is a value recorded at a timestamp, in milliseconds.
+type Sample struct {
+	Timestamp int64
+	Value     float64
+}
+
+func (s Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{float64(s.Timestamp), s.Value})
+}
+
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	var v [2]float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.Timestamp, s.Value = int64(v[0]), v[1]
+	return nil
+}
+
+// RangeQuery selects and aggregates the samples returned by TimeSeries.Range.
+type RangeQuery struct {
+	From        int64
+	To          int64
+	Timestamps  []int64  // Only return samples at these timestamps, if not empty
+	MinValue    *float64 // Only return samples with a value of at least MinValue, if set
+	MaxValue    *float64 // Only return samples with a value of at most MaxValue, if set
+	Aggregator  Aggregator
+	BucketWidth int64 // Width of the aggregation buckets in milliseconds, if Aggregator is set
+	Reverse     bool
+	Count       int // Zero returns all samples
+}
+
+// TimeSeries is a list of samples sorted by timestamp.
+//
+// Samples older than the retention window are trimmed relative to the newest sample rather
+// than the clock, so that every replica trims exactly the same samples after the same writes.
+type TimeSeries struct {
+	Name            string
+	retention       int64 // Retention window in milliseconds, or zero to keep samples forever
+	duplicatePolicy DuplicatePolicy
+	samples         []Sample
+}
+
+type timeSeriesJSON struct {
+	Name            string          `json:"name"`
+	Retention       int64           `json:"retention"`
+	DuplicatePolicy DuplicatePolicy `json:"duplicate_policy"`
+	Samples         []Sample        `json:"samples"`
+}
+
+func NewTimeSeries(retention int64, duplicatePolicy DuplicatePolicy) *TimeSeries {
+	return &TimeSeries{
+		Name:            "timeseries",
+		retention:       retention,
+		duplicatePolicy: duplicatePolicy,
+		samples:         make([]Sample, 0),
+	}
+}
+
+func (ts *TimeSeries) GetName() string {
+	return ts.Name
+}
+
+func (ts *TimeSeries) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&timeSeriesJSON{
+		Name:            ts.Name,
+		Retention:       ts.retention,
+		DuplicatePolicy: ts.duplicatePolicy,
+		Samples:         ts.samples,
+	})
+}
+
+func (ts *TimeSeries) UnmarshalJSON(data []byte) error {
+	var v timeSeriesJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*ts = *NewTimeSeries(v.Retention, v.DuplicatePolicy)
+	ts.samples = append(ts.samples, v.Samples...)
+	return nil
+}
+
+func (ts *TimeSeries) Retention() int64 {
+	return ts.retention
+}
+
+func (ts *TimeSeries) DuplicatePolicy() DuplicatePolicy {
+	return ts.duplicatePolicy
+}
+
+// Len returns the number of samples in the series.
+func (ts *TimeSeries) Len() int {
+	return len(ts.samples)
+}
+
+// Last returns the newest sample, and false if the series is empty.
+func (ts *TimeSeries) Last() (Sample, bool) {
+	if len(ts.samples) == 0 {
+		return Sample{}, false
+	}
+	return ts.samples[len(ts.samples)-1], true
+}
+
+// Add records a sample, merging it with any sample at the same timestamp according to policy,
+// or the duplicate policy of the series if policy is empty. A SUM which is not finite returns ErrOverflow.
+func (ts *TimeSeries) Add(timestamp int64, value float64, policy DuplicatePolicy) error {
+	if policy == "" {
+		policy = ts.duplicatePolicy
+	}
+
+	if last, ok := ts.Last(); ok && ts.retention > 0 && timestamp < last.Timestamp-ts.retention {
+		return ErrSampleTooOld
+	}
+
+	idx := sort.Search(len(ts.samples), func(i int) bool {
+		return ts.samples[i].Timestamp >= timestamp
+	})
+
+	if idx < len(ts.samples) && ts.samples[idx].Timestamp == timestamp {
+		existing := &ts.samples[idx]
+		switch policy {
+		case DuplicateBlock:
+			return ErrDuplicateSample
+		case DuplicateLast:
+			existing.Value = value
+		case DuplicateMin:
+			existing.Value = math.Min(existing.Value, value)
+		case DuplicateMax:
+			existing.Value = math.Max(existing.Value, value)
+		case DuplicateSum:
+			sum := existing.Value + value
+			if math.IsInf(sum, 0) {
+				return ErrOverflow
+			}
+			existing.Value = sum
+		}
+		return nil
+	}
+
+	ts.samples = append(ts.samples, Sample{})
+	copy(ts.samples[idx+1:], ts.samples[idx:])
+	ts.samples[idx] = Sample{Timestamp: timestamp, Value: value}
+
+	ts.trim()
+	return nil
+}
+
+// trim drops the samples which fell out of the retention window.
+func (ts *TimeSeries) trim() {
+	if ts.retention == 0 || len(ts.samples) == 0 {
+		return
+	}
+
+	oldest := ts.samples[len(ts.samples)-1].Timestamp - ts.retention
+	idx := sort.Search(len(ts.samples), func(i int) bool {
+		return ts.samples[i].Timestamp >= oldest
+	})
+	if idx > 0 {
+		ts.samples = append(ts.samples[:0], ts.samples[idx:]...)
+	}
+}
+
+// Range returns the samples between q.From and q.To inclusive which pass the filters of the query,
+// aggregated into buckets if the query has an aggregator.
+func (ts *TimeSeries) Range(q RangeQuery) []Sample {
+	lo := sort.Search(len(ts.samples), func(i int) bool {
+		return ts.samples[i].Timestamp >= q.From
+	})
+	hi := sort.Search(len(ts.samples), func(i int) bool {
+		return ts.samples[i].Timestamp > q.To
+	})
+
+	var timestamps map[int64]bool
+	if len(q.Timestamps) > 0 {
+		timestamps = make(map[int64]bool, len(q.Timestamps))
+		for _, t := range q.Timestamps {
+			timestamps[t] = true
+		}
+	}
+
+	samples := make([]Sample, 0)
+	for _, s := range ts.samples[lo:max(lo, hi)] {
+		if timestamps != nil && !timestamps[s.Timestamp] {
+			continue
+		}
+		if (q.MinValue != nil && s.Value < *q.MinValue) || (q.MaxValue != nil && s.Value > *q.MaxValue) {
+			continue
+		}
+		samples = append(samples, s)
+	}
+
+	if q.Aggregator != "" {
+		samples = aggregate(samples, q.Aggregator, q.BucketWidth)
+	}
+
+	if q.Reverse {
+		for i, j := 0, len(samples)-1; i < j; i, j = i+1, j-1 {
+			samples[i], samples[j] = samples[j], samples[i]
+		}
+	}
+	if q.Count > 0 && q.Count < len(samples) {
+		samples = samples[:q.Count]
+	}
+	return samples
+}
+
+// aggregate reduces sorted samples into one sample per bucket, timestamped at the start of the bucket.
+func aggregate(samples []Sample, aggregator Aggregator, bucketWidth int64) []Sample {
+	buckets := make([]Sample, 0)
+	for start := 0; start < len(samples); {
+		bucket := samples[start].Timestamp - samples[start].Timestamp%bucketWidth
+		end := start
+		for end < len(samples) && samples[end].Timestamp < bucket+bucketWidth {
+			end++
+		}
+
+		buckets = append(buckets, Sample{Timestamp: bucket, Value: reduce(samples[start:end], aggregator)})
+		start = end
+	}
+	return buckets
+}
+
+func reduce(samples []Sample, aggregator Aggregator) float64 {
+	switch aggregator {
+	case AggregateCount:
+		return float64(len(samples))
+	case AggregateFirst:
+		return samples[0].Value
+	case AggregateLast:
+		return samples[len(samples)-1].Value
+	}
+
+	sum, lo, hi := 0.0, math.Inf(1), math.Inf(-1)
+	for _, s := range samples {
+		sum += s.Value
+		lo = math.Min(lo, s.Value)
+		hi = math.Max(hi, s.Value)
+	}
+
+	n := float64(len(samples))
+	switch aggregator {
+	case AggregateSum:
+		return sum
+	case AggregateMin:
+		return lo
+	case AggregateMax:
+		return hi
+	case AggregateRange:
+		return hi - lo
+	case AggregateAvg:
+		return sum / n
+	}
+
+	mean := sum / n
+	squares := 0.0
+	for _, s := range samples {
+		squares += (s.Value - mean) * (s.Value - mean)
+	}
+
+	switch aggregator {
+	case AggregateVarP:
+		return squares / n
+	case AggregateStdP:
+		return math.Sqrt(squares / n)
+	case AggregateVarS, AggregateStdS:
+		if n < 2 {
+			return 0
+		}
+		if aggregator == AggregateVarS {
+			return squares / (n - 1)
+		}
+		return math.Sqrt(squares / (n - 1))
+	default:
+		return math.NaN()
+	}
+}
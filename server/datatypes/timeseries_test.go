@@ -1 +1,140 @@
 package datatypes
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTimeSeries_Add(t *testing.T) {
+	ts := NewTimeSeries(0, DuplicateBlock)
+
+	for _, s := range []Sample{{30, 3}, {10, 1}, {20, 2}} {
+		if err := ts.Add(s.Timestamp, s.Value, ""); err != nil {
+			t.Fatalf("Add(%d) = %v", s.Timestamp, err)
+		}
+	}
+
+	want := []Sample{{10, 1}, {20, 2}, {30, 3}}
+	if got := ts.Range(RangeQuery{From: 0, To: math.MaxInt64}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Range = %v, want %v", got, want)
+	}
+
+	if err := ts.Add(20, 5, ""); err != ErrDuplicateSample {
+		t.Errorf("Add of a duplicate with BLOCK = %v, want ErrDuplicateSample", err)
+	}
+
+	tests := []struct {
+		policy DuplicatePolicy
+		value  float64
+		want   float64
+	}{
+		{DuplicateFirst, 5, 2},
+		{DuplicateLast, 5, 5},
+		{DuplicateMin, 4, 4},
+		{DuplicateMax, 1, 4},
+		{DuplicateSum, 6, 10},
+	}
+	for _, tt := range tests {
+		if err := ts.Add(20, tt.value, tt.policy); err != nil {
+			t.Fatalf("Add with %s = %v", tt.policy, err)
+		}
+		if got := ts.Range(RangeQuery{From: 20, To: 20}); got[0].Value != tt.want {
+			t.Errorf("value after Add with %s = %v, want %v", tt.policy, got[0].Value, tt.want)
+		}
+	}
+}
+
+func TestTimeSeries_SumOverflow(t *testing.T) {
+	ts := NewTimeSeries(0, DuplicateSum)
+	ts.Add(10, math.MaxFloat64, "")
+
+	if err := ts.Add(10, math.MaxFloat64, ""); err != ErrOverflow {
+		t.Errorf("Add of a sum past MaxFloat64 = %v, want ErrOverflow", err)
+	}
+	if got := ts.Range(RangeQuery{From: 10, To: 10}); got[0].Value != math.MaxFloat64 {
+		t.Errorf("value after an overflowing Add = %v, want MaxFloat64", got[0].Value)
+	}
+}
+
+func TestTimeSeries_Retention(t *testing.T) {
+	ts := NewTimeSeries(100, DuplicateLast)
+
+	for _, timestamp := range []int64{0, 50, 100, 150, 200} {
+		ts.Add(timestamp, 1, "")
+	}
+	if ts.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", ts.Len())
+	}
+	if err := ts.Add(99, 1, ""); err != ErrSampleTooOld {
+		t.Errorf("Add of an expired sample = %v, want ErrSampleTooOld", err)
+	}
+	if err := ts.Add(120, 1, ""); err != nil {
+		t.Errorf("Add inside the retention window = %v", err)
+	}
+}
+
+func TestTimeSeries_Range(t *testing.T) {
+	ts := NewTimeSeries(0, DuplicateBlock)
+	for i, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		ts.Add(int64(i*10), v, "")
+	}
+
+	minValue, maxValue := 4.0, 5.0
+	got := ts.Range(RangeQuery{From: 0, To: 100, MinValue: &minValue, MaxValue: &maxValue, Reverse: true, Count: 2})
+	if want := []Sample{{50, 5}, {40, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered Range = %v, want %v", got, want)
+	}
+
+	got = ts.Range(RangeQuery{From: 0, To: 100, Timestamps: []int64{10, 70, 75}})
+	if want := []Sample{{10, 4}, {70, 9}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range filtered by timestamps = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		aggregator Aggregator
+		want       []float64
+	}{
+		{AggregateAvg, []float64{3.5, 6.5}},
+		{AggregateSum, []float64{14, 26}},
+		{AggregateMin, []float64{2, 5}},
+		{AggregateMax, []float64{4, 9}},
+		{AggregateRange, []float64{2, 4}},
+		{AggregateCount, []float64{4, 4}},
+		{AggregateFirst, []float64{2, 5}},
+		{AggregateLast, []float64{4, 9}},
+		{AggregateVarP, []float64{0.75, 2.75}},
+	}
+	for _, tt := range tests {
+		got := ts.Range(RangeQuery{From: 0, To: 100, Aggregator: tt.aggregator, BucketWidth: 40})
+		want := []Sample{{0, tt.want[0]}, {40, tt.want[1]}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Range aggregated by %s = %v, want %v", tt.aggregator, got, want)
+		}
+	}
+
+	got = ts.Range(RangeQuery{From: 0, To: 100, Aggregator: AggregateStdP, BucketWidth: 100})
+	if len(got) != 1 || got[0].Value != 2 {
+		t.Errorf("Range aggregated by STD.P = %v, want a single bucket of 2", got)
+	}
+}
+
+func TestTimeSeries_JSON(t *testing.T) {
+	ts := NewTimeSeries(1000, DuplicateSum)
+	ts.Add(1700000000000, 1.5, "")
+	ts.Add(1700000000100, -2, "")
+
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &TimeSeries{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, ts) {
+		t.Errorf("restored series = %+v, want %+v", restored, ts)
+	}
+}
@@ -0,0 +1,259 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Defaults of TOPK.RESERVE when only k is given.
+const (
+	DefaultTopKWidth = 8
+	DefaultTopKDepth = 7
+	DefaultTopKDecay = 0.9
+)
+
+// TopKItem is an item of a Top-K sketch along with its estimated count.
+type TopKItem struct {
+	Item  string
+	Count uint64
+}
+
+func (i TopKItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]interface{}{i.Item, i.Count})
+}
+
+func (i *TopKItem) UnmarshalJSON(data []byte) error {
+	var v [2]json.RawMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(v[0], &i.Item); err != nil {
+		return err
+	}
+	return json.Unmarshal(v[1], &i.Count)
+}
+
+// TopK tracks the k most frequent items of a stream with a HeavyKeeper sketch.
+//
+// Each of the depth rows of the sketch holds width buckets of a fingerprint and a count. An item
+// colliding with the fingerprint of another item decays its count with a probability that falls
+// exponentially with the count, so that heavy hitters keep their buckets while rare items don't.
+// The random draws come from a generator whose state is part of the sketch, so every replica
+// applying the same writes makes the same draws and ends up with the same sketch.
+type TopK struct {
+	k            uint
+	width        uint
+	depth        uint
+	decay        float64
+	fingerprints []uint32
+	counts       []uint64
+	top          []TopKItem // The heavy hitters, unordered
+	rng          uint64
+	Name         string `json:"name"`
+}
+
+type topKJSON struct {
+	K            uint       `json:"k"`
+	Width        uint       `json:"width"`
+	Depth        uint       `json:"depth"`
+	Decay        float64    `json:"decay"`
+	Fingerprints []uint32   `json:"fingerprints"`
+	Counts       []uint64   `json:"counts"`
+	Top          []TopKItem `json:"top"`
+	RNG          uint64     `json:"rng"`
+	Name         string     `json:"name"`
+}
+
+func NewTopK(k, width, depth uint, decay float64) *TopK {
+	return &TopK{
+		k:            k,
+		width:        width,
+		depth:        depth,
+		decay:        decay,
+		fingerprints: make([]uint32, width*depth),
+		counts:       make([]uint64, width*depth),
+		top:          make([]TopKItem, 0, k),
+		Name:         "topk",
+	}
+}
+
+func (tk *TopK) GetName() string {
+	return tk.Name
+}
+
+func (tk *TopK) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&topKJSON{
+		K:            tk.k,
+		Width:        tk.width,
+		Depth:        tk.depth,
+		Decay:        tk.decay,
+		Fingerprints: tk.fingerprints,
+		Counts:       tk.counts,
+		Top:          tk.top,
+		RNG:          tk.rng,
+		Name:         tk.Name,
+	})
+}
+
+func (tk *TopK) UnmarshalJSON(data []byte) error {
+	var v topKJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*tk = *NewTopK(v.K, v.Width, v.Depth, v.Decay)
+	copy(tk.fingerprints, v.Fingerprints)
+	copy(tk.counts, v.Counts)
+	tk.top = append(tk.top, v.Top...)
+	tk.rng = v.RNG
+	return nil
+}
+
+func (tk *TopK) K() uint {
+	return tk.k
+}
+
+// random returns a uniformly distributed number in [0, 1) from a SplitMix64 generator.
+func (tk *TopK) random() float64 {
+	tk.rng += 0x9e3779b97f4a7c15
+	z := tk.rng
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return float64(z>>11) / (1 << 53)
+}
+
+// decayProbability returns decay^count. It only multiplies, so that it rounds the same way on every platform.
+func (tk *TopK) decayProbability(count uint64) float64 {
+	p, base := 1.0, tk.decay
+	for count > 0 && p > 0 {
+		if count&1 == 1 {
+			p *= base
+		}
+		base *= base
+		count >>= 1
+	}
+	return p
+}
+
+// locate returns the fingerprint of the item and the index of its bucket in each row.
+func (tk *TopK) locate(item string) (uint32, []uint) {
+	h32 := fnv.New32a()
+	h32.Write([]byte(item))
+	fingerprint := h32.Sum32()
+
+	h64 := fnv.New64a()
+	h64.Write([]byte(item))
+	sum := h64.Sum64()
+	h1, h2 := uint(sum&math.MaxUint32), uint(sum>>32)|1
+
+	indexes := make([]uint, tk.depth)
+	for row := uint(0); row < tk.depth; row++ {
+		indexes[row] = row*tk.width + (h1+row*h2)%tk.width
+	}
+	return fingerprint, indexes
+}
+
+// IncrBy adds increment to the count of the item. It returns the item expelled from the top k
+// to make room for this one, and false if no item was expelled.
+func (tk *TopK) IncrBy(item string, increment uint64) (string, bool) {
+	fingerprint, indexes := tk.locate(item)
+
+	var maxCount uint64
+	for _, idx := range indexes {
+		switch {
+		case tk.counts[idx] == 0:
+			tk.fingerprints[idx] = fingerprint
+			tk.counts[idx] = increment
+		case tk.fingerprints[idx] == fingerprint:
+			tk.counts[idx] += increment
+		default:
+			for remaining := increment; remaining > 0; remaining-- {
+				if tk.random() >= tk.decayProbability(tk.counts[idx]) {
+					continue
+				}
+				tk.counts[idx]--
+				if tk.counts[idx] == 0 {
+					tk.fingerprints[idx] = fingerprint
+					tk.counts[idx] = remaining
+					break
+				}
+			}
+		}
+
+		if tk.fingerprints[idx] == fingerprint {
+			maxCount = max(maxCount, tk.counts[idx])
+		}
+	}
+
+	for i := range tk.top {
+		if tk.top[i].Item == item {
+			tk.top[i].Count = maxCount
+			return "", false
+		}
+	}
+
+	if uint(len(tk.top)) < tk.k {
+		if maxCount > 0 {
+			tk.top = append(tk.top, TopKItem{Item: item, Count: maxCount})
+		}
+		return "", false
+	}
+
+	smallest := tk.smallest()
+	if maxCount <= tk.top[smallest].Count {
+		return "", false
+	}
+	expelled := tk.top[smallest].Item
+	tk.top[smallest] = TopKItem{Item: item, Count: maxCount}
+	return expelled, true
+}
+
+// smallest returns the index of the heavy hitter with the lowest count, breaking ties by name.
+func (tk *TopK) smallest() int {
+	smallest := 0
+	for i, ti := range tk.top {
+		s := tk.top[smallest]
+		if ti.Count < s.Count || (ti.Count == s.Count && ti.Item < s.Item) {
+			smallest = i
+		}
+	}
+	return smallest
+}
+
+// Query reports whether the item is one of the top k.
+func (tk *TopK) Query(item string) bool {
+	for _, ti := range tk.top {
+		if ti.Item == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the estimated count of the item, which may be lower than its true count.
+func (tk *TopK) Count(item string) uint64 {
+	fingerprint, indexes := tk.locate(item)
+
+	var count uint64
+	for _, idx := range indexes {
+		if tk.fingerprints[idx] == fingerprint {
+			count = max(count, tk.counts[idx])
+		}
+	}
+	return count
+}
+
+// List returns the top k items, from the highest count to the lowest, breaking ties by name.
+func (tk *TopK) List() []TopKItem {
+	items := append([]TopKItem(nil), tk.top...)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Item < items[j].Item
+	})
+	return items
+}
@@ -0,0 +1,86 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestTopK_IncrBy(t *testing.T) {
+	tk := NewTopK(3, 50, 4, DefaultTopKDecay)
+
+	for i := 0; i < 200; i++ {
+		tk.IncrBy("noise"+strconv.Itoa(i), 1)
+		tk.IncrBy("a", 5)
+		tk.IncrBy("b", 3)
+		tk.IncrBy("c", 2)
+	}
+
+	list := tk.List()
+	if len(list) != 3 || list[0].Item != "a" || list[1].Item != "b" || list[2].Item != "c" {
+		t.Fatalf("List() = %v, want a, b and c", list)
+	}
+	if list[0].Count < 900 {
+		t.Errorf("count of a = %d, want about 1000", list[0].Count)
+	}
+	if !tk.Query("a") || tk.Query("noise1") {
+		t.Errorf("Query reports the wrong heavy hitters")
+	}
+	if tk.Count("b") < 500 {
+		t.Errorf("Count(b) = %d, want about 600", tk.Count("b"))
+	}
+
+	expelled, ok := tk.IncrBy("d", 10000)
+	if !ok || expelled != "c" {
+		t.Errorf("IncrBy(d, 10000) expelled %q, %v, want c", expelled, ok)
+	}
+	if _, ok := tk.IncrBy("d", 1); ok {
+		t.Errorf("IncrBy of a heavy hitter expelled an item")
+	}
+}
+
+func TestTopK_Deterministic(t *testing.T) {
+	a := NewTopK(5, 8, 3, DefaultTopKDecay)
+	b := NewTopK(5, 8, 3, DefaultTopKDecay)
+	for i := 0; i < 500; i++ {
+		item := strconv.Itoa(i * i % 37)
+		a.IncrBy(item, uint64(i%4)+1)
+		b.IncrBy(item, uint64(i%4)+1)
+	}
+
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	if string(da) != string(db) {
+		t.Errorf("sketches given the same writes differ")
+	}
+}
+
+func TestTopK_JSON(t *testing.T) {
+	tk := NewTopK(2, 8, 3, 0.8)
+	tk.IncrBy("x", 3)
+	tk.IncrBy("y", 2)
+	tk.IncrBy("z", 1)
+
+	data, err := json.Marshal(tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(restored)
+	if string(again) != string(data) {
+		t.Errorf("restored sketch encodes to %s, want %s", again, data)
+	}
+
+	// The restored generator carries on from the same state.
+	tk.IncrBy("w", 5)
+	restored.(*TopK).IncrBy("w", 5)
+	da, _ := json.Marshal(tk)
+	db, _ := json.Marshal(restored)
+	if string(da) != string(db) {
+		t.Errorf("restored sketch diverged after a write")
+	}
+}
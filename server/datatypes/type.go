@@ -1,8 +1,65 @@
 package datatypes
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type Type interface {
 	GetName() string
 	json.Marshaler
 }
+
+// UnmarshalType decodes a value encoded by the MarshalJSON method of a Type,
+// using its name to pick the concrete type to decode into.
+func UnmarshalType(data []byte) (Type, error) {
+	var header struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var t Type
+	switch header.Name {
+	case "string":
+		t = &String{}
+	case "list":
+		t = NewList()
+	case "hash":
+		t = NewHash()
+	case "json":
+		t = &JSON{}
+	case "set":
+		t = NewSet[string]()
+	case "zset":
+		t = NewSortedSet()
+	case "bitmap":
+		t = NewBitmap()
+	case "hll":
+		t = &HyperLogLog{}
+	case "bloom":
+		t = &BloomFilter{}
+	case "cuckoo":
+		t = &CuckooFilter{}
+	case "cms":
+		t = &CountMinSketch{}
+	case "topk":
+		t = &TopK{}
+	case "tdigest":
+		t = &TDigest{}
+	case "timeseries":
+		t = &TimeSeries{}
+	case "stream":
+		t = NewStream()
+	case "vectorset":
+		t = &VectorSet{}
+	default:
+		return nil, fmt.Errorf("unknown data type %q", header.Name)
+	}
+
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
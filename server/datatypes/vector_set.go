@@ -0,0 +1,296 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+)
+
+var (
+	ErrDimensionMismatch = errors.New("DimensionMismatch") // The vector has a different dimension from the vectors in the set
+	ErrZeroVector        = errors.New("ZeroVector")        // The vector has no direction, so it has no cosine similarity
+	ErrMetricMismatch    = errors.New("MetricMismatch")    // The metric differs from the metric of the set
+)
+
+// VectorMetric is the measure of similarity between the vectors of a set.
+type VectorMetric string
+
+const (
+	MetricCosine VectorMetric = "COSINE"
+	MetricL2     VectorMetric = "L2"
+	MetricIP     VectorMetric = "IP" // Inner product
+)
+
+const (
+	hnswThreshold   = 1000 // The number of elements from which a set is searched through an HNSW graph rather than exhaustively
+	DefaultVectorEF = 100  // The number of candidates explored by a search through the graph unless asked otherwise
+)
+
+// VectorElement is an element of a vector set, with its vector and the attributes it can be filtered on.
+type VectorElement struct {
+	Name       string            `json:"name"`
+	Vector     []float32         `json:"vector"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// VectorMatch is an element found by a similarity search, with its cosine similarity,
+// inner product or L2 distance to the query.
+type VectorMatch struct {
+	Name  string
+	Score float64
+}
+
+// VectorSet holds float32 vectors of a single dimension under element names, and finds the
+// elements nearest to a query vector.
+//
+// Only the elements are replicated, in the order they were added. The HNSW graph used to search
+// large sets is derived from them, and rebuilt from scratch whenever an element is removed, so
+// every replica and every snapshot restored from them searches exactly the same graph.
+type VectorSet struct {
+	Name      string
+	metric    VectorMetric
+	dim       int
+	elements  []VectorElement
+	positions map[string]int
+	norms     []float64
+	graph     *hnsw
+	stale     bool // The graph no longer matches the elements
+}
+
+type vectorSetJSON struct {
+	Name     string          `json:"name"`
+	Metric   VectorMetric    `json:"metric"`
+	Dim      int             `json:"dim"`
+	Elements []VectorElement `json:"elements"`
+}
+
+func NewVectorSet(metric VectorMetric, dim int) *VectorSet {
+	return &VectorSet{
+		Name:      "vectorset",
+		metric:    metric,
+		dim:       dim,
+		elements:  make([]VectorElement, 0),
+		positions: make(map[string]int),
+		norms:     make([]float64, 0),
+	}
+}
+
+func (vs *VectorSet) GetName() string {
+	return vs.Name
+}
+
+func (vs *VectorSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&vectorSetJSON{
+		Name:     vs.Name,
+		Metric:   vs.metric,
+		Dim:      vs.dim,
+		Elements: vs.elements,
+	})
+}
+
+// UnmarshalJSON adds the elements back in their original order, so that the graph is rebuilt exactly as it was.
+func (vs *VectorSet) UnmarshalJSON(data []byte) error {
+	var v vectorSetJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*vs = *NewVectorSet(v.Metric, v.Dim)
+	for _, e := range v.Elements {
+		if _, err := vs.Add(e.Name, e.Vector, e.Attributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vs *VectorSet) Metric() VectorMetric {
+	return vs.metric
+}
+
+func (vs *VectorSet) Dim() int {
+	return vs.dim
+}
+
+func (vs *VectorSet) Len() int {
+	return len(vs.elements)
+}
+
+// dot returns the inner product of two vectors. Each product is converted explicitly, which stops
+// the compiler from fusing it with the sum on some platforms, so that every replica rounds alike.
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(float64(a[i]) * float64(b[i]))
+	}
+	return sum
+}
+
+func squaredL2(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += float64(d * d)
+	}
+	return sum
+}
+
+// distance returns how far the query is from the element at a position, where smaller is nearer.
+func (vs *VectorSet) distance(query []float32, queryNorm float64, pos int) float64 {
+	vector := vs.elements[pos].Vector
+	switch vs.metric {
+	case MetricL2:
+		return squaredL2(query, vector)
+	case MetricIP:
+		return -dot(query, vector)
+	default:
+		return 1 - dot(query, vector)/(queryNorm*vs.norms[pos])
+	}
+}
+
+// score converts a distance to the similarity or distance reported for the metric.
+func (vs *VectorSet) score(distance float64) float64 {
+	switch vs.metric {
+	case MetricL2:
+		return math.Sqrt(distance)
+	case MetricIP:
+		return -distance
+	default:
+		return 1 - distance
+	}
+}
+
+func (vs *VectorSet) checkVector(vector []float32) (float64, error) {
+	if len(vector) != vs.dim {
+		return 0, ErrDimensionMismatch
+	}
+	norm := math.Sqrt(dot(vector, vector))
+	if vs.metric == MetricCosine && norm == 0 {
+		return 0, ErrZeroVector
+	}
+	return norm, nil
+}
+
+// Add sets the vector and attributes of an element, and reports whether the element is new.
+// An element which already exists is moved to the end of the set.
+func (vs *VectorSet) Add(name string, vector []float32, attributes map[string]string) (bool, error) {
+	norm, err := vs.checkVector(vector)
+	if err != nil {
+		return false, err
+	}
+
+	_, exists := vs.positions[name]
+	if exists {
+		vs.Remove(name)
+	}
+
+	vs.positions[name] = len(vs.elements)
+	vs.elements = append(vs.elements, VectorElement{Name: name, Vector: vector, Attributes: attributes})
+	vs.norms = append(vs.norms, norm)
+
+	if vs.graph != nil && !vs.stale {
+		vs.graph.insert(len(vs.elements)-1, hnswLevel(name))
+	}
+	return !exists, nil
+}
+
+// Remove removes an element, and reports whether it existed.
+func (vs *VectorSet) Remove(name string) bool {
+	pos, ok := vs.positions[name]
+	if !ok {
+		return false
+	}
+
+	vs.elements = append(vs.elements[:pos], vs.elements[pos+1:]...)
+	vs.norms = append(vs.norms[:pos], vs.norms[pos+1:]...)
+	delete(vs.positions, name)
+	for i := pos; i < len(vs.elements); i++ {
+		vs.positions[vs.elements[i].Name] = i
+	}
+	vs.stale = true
+	return true
+}
+
+// Element returns an element of the set, and false if it doesn't exist.
+func (vs *VectorSet) Element(name string) (VectorElement, bool) {
+	pos, ok := vs.positions[name]
+	if !ok {
+		return VectorElement{}, false
+	}
+	return vs.elements[pos], true
+}
+
+// ensureGraph builds the graph for a large set, inserting the elements in order, if it isn't built or is stale.
+func (vs *VectorSet) ensureGraph() {
+	if len(vs.elements) < hnswThreshold {
+		vs.graph, vs.stale = nil, false
+		return
+	}
+	if vs.graph != nil && !vs.stale {
+		return
+	}
+
+	vs.graph = newHNSW(func(a, b int) float64 {
+		return vs.distance(vs.elements[a].Vector, vs.norms[a], b)
+	})
+	vs.stale = false
+	for i, e := range vs.elements {
+		vs.graph.insert(i, hnswLevel(e.Name))
+	}
+}
+
+func (e VectorElement) matches(filter map[string]string) bool {
+	for name, value := range filter {
+		if e.Attributes[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns up to count elements nearest to the query, nearest first, among the elements whose
+// attributes have all the values in the filter. Large sets are searched approximately through the
+// graph, exploring at least ef candidates, and exhaustively if that finds too few elements matching the filter.
+func (vs *VectorSet) Search(query []float32, count, ef int, filter map[string]string) ([]VectorMatch, error) {
+	queryNorm, err := vs.checkVector(query)
+	if err != nil {
+		return nil, err
+	}
+
+	vs.ensureGraph()
+
+	var candidates []hnswCandidate
+	if vs.graph != nil {
+		candidates = vs.graph.search(func(node int) float64 {
+			return vs.distance(query, queryNorm, node)
+		}, max(ef, count))
+		if len(filter) > 0 {
+			filtered := candidates[:0]
+			for _, c := range candidates {
+				if vs.elements[c.node].matches(filter) {
+					filtered = append(filtered, c)
+				}
+			}
+			candidates = filtered
+		}
+	}
+
+	if vs.graph == nil || len(candidates) < count {
+		candidates = make([]hnswCandidate, 0, len(vs.elements))
+		for pos, e := range vs.elements {
+			if e.matches(filter) {
+				candidates = append(candidates, hnswCandidate{node: pos, distance: vs.distance(query, queryNorm, pos)})
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return closer(candidates[i], candidates[j])
+		})
+	}
+
+	matches := make([]VectorMatch, 0, min(count, len(candidates)))
+	for _, c := range candidates[:min(count, len(candidates))] {
+		matches = append(matches, VectorMatch{Name: vs.elements[c.node].Name, Score: vs.score(c.distance)})
+	}
+	return matches, nil
+}
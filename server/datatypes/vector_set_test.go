@@ -0,0 +1,192 @@
+package datatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestVectorSet_Add(t *testing.T) {
+	vs := NewVectorSet(MetricCosine, 2)
+
+	if added, err := vs.Add("a", []float32{1, 0}, nil); err != nil || !added {
+		t.Fatalf("Add(a) = %v, %v, want true", added, err)
+	}
+	if added, err := vs.Add("a", []float32{0, 1}, map[string]string{"colour": "red"}); err != nil || added {
+		t.Fatalf("Add(a) again = %v, %v, want false", added, err)
+	}
+	if e, _ := vs.Element("a"); e.Vector[1] != 1 || e.Attributes["colour"] != "red" {
+		t.Errorf("Element(a) = %v, want the updated vector and attributes", e)
+	}
+
+	if _, err := vs.Add("b", []float32{1, 0, 0}, nil); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Add of a 3 dimensional vector returned %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := vs.Add("b", []float32{0, 0}, nil); !errors.Is(err, ErrZeroVector) {
+		t.Errorf("Add of a zero vector returned %v, want ErrZeroVector", err)
+	}
+
+	if !vs.Remove("a") || vs.Remove("a") || vs.Len() != 0 {
+		t.Errorf("Remove(a) didn't remove a exactly once")
+	}
+}
+
+func TestVectorSet_Search(t *testing.T) {
+	tests := []struct {
+		metric VectorMetric
+		want   []VectorMatch
+	}{
+		{MetricCosine, []VectorMatch{{"east", 1}, {"far-east", 1}, {"north-east", math.Sqrt(0.5)}}},
+		{MetricL2, []VectorMatch{{"east", 0}, {"north-east", math.Sqrt(math.Pow(1-math.Sqrt(0.5), 2) + 0.5)}, {"north", math.Sqrt(2)}}},
+		{MetricIP, []VectorMatch{{"far-east", 5}, {"east", 1}, {"north-east", math.Sqrt(0.5)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			vs := NewVectorSet(tt.metric, 2)
+			vs.Add("east", []float32{1, 0}, map[string]string{"kind": "near"})
+			vs.Add("north", []float32{0, 1}, map[string]string{"kind": "near"})
+			vs.Add("north-east", []float32{float32(math.Sqrt(0.5)), float32(math.Sqrt(0.5))}, map[string]string{"kind": "near"})
+			vs.Add("far-east", []float32{5, 0}, map[string]string{"kind": "far"})
+
+			got, err := vs.Search([]float32{1, 0}, 3, 10, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Name != tt.want[i].Name || math.Abs(got[i].Score-tt.want[i].Score) > 1e-6 {
+					t.Errorf("Search()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVectorSet_SearchFilter(t *testing.T) {
+	vs := NewVectorSet(MetricL2, 1)
+	for i := 0; i < 10; i++ {
+		vs.Add(strconv.Itoa(i), []float32{float32(i)}, map[string]string{"parity": strconv.Itoa(i % 2)})
+	}
+
+	got, _ := vs.Search([]float32{4}, 2, 10, map[string]string{"parity": "1"})
+	if len(got) != 2 || got[0].Name != "3" || got[1].Name != "5" {
+		t.Errorf("Search() = %v, want 3 and 5", got)
+	}
+	if got, _ := vs.Search([]float32{4}, 2, 10, map[string]string{"parity": "2"}); len(got) != 0 {
+		t.Errorf("Search() = %v, want no matches", got)
+	}
+}
+
+// testVectors returns n pseudo-random vectors, so that tests don't depend on math/rand.
+func testVectors(n, dim int) [][]float32 {
+	state := uint64(1)
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			state = state*6364136223846793005 + 1442695040888963407
+			vectors[i][j] = float32(state>>40)/float32(1<<24) - 0.5
+		}
+	}
+	return vectors
+}
+
+func TestVectorSet_SearchGraph(t *testing.T) {
+	vectors := testVectors(1500, 8)
+	vs := NewVectorSet(MetricCosine, 8)
+	for i, v := range vectors {
+		vs.Add(strconv.Itoa(i), v, nil)
+	}
+
+	// Searching through the graph should find nearly all of the exact nearest neighbours.
+	found, total := 0, 0
+	for _, query := range testVectors(20, 8) {
+		got, err := vs.Search(query, 10, 50, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vs.graph == nil {
+			t.Fatal("Search() of a large set didn't build the graph")
+		}
+
+		want := vs.searchExhaustive(query, 10)
+		exact := make(map[string]bool)
+		for _, m := range want {
+			exact[m.Name] = true
+		}
+		for _, m := range got {
+			if exact[m.Name] {
+				found++
+			}
+		}
+		total += len(want)
+	}
+	if found < total*9/10 {
+		t.Errorf("graph search found %d of the %d nearest neighbours", found, total)
+	}
+}
+
+// searchExhaustive finds the nearest elements without the graph.
+func (vs *VectorSet) searchExhaustive(query []float32, count int) []VectorMatch {
+	graph := vs.graph
+	vs.graph, vs.stale = nil, false
+	defer func() {
+		vs.graph = graph
+	}()
+
+	queryNorm, _ := vs.checkVector(query)
+	best := make([]VectorMatch, 0, count)
+	for pos, e := range vs.elements {
+		m := VectorMatch{Name: e.Name, Score: vs.score(vs.distance(query, queryNorm, pos))}
+		best = append(best, m)
+		for i := len(best) - 1; i > 0 && best[i].Score > best[i-1].Score; i-- {
+			best[i], best[i-1] = best[i-1], best[i]
+		}
+		if len(best) > count {
+			best = best[:count]
+		}
+	}
+	return best
+}
+
+func TestVectorSet_JSON(t *testing.T) {
+	vectors := testVectors(1200, 4)
+	vs := NewVectorSet(MetricL2, 4)
+	for i, v := range vectors {
+		vs.Add(strconv.Itoa(i), v, map[string]string{"group": strconv.Itoa(i % 3)})
+	}
+	vs.Remove("7")
+	vs.Add("3", vectors[5], nil)
+
+	data, err := json.Marshal(vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalType(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(restored)
+	if string(again) != string(data) {
+		t.Errorf("restored set encodes differently")
+	}
+
+	// The restored set rebuilds the same graph, so approximate searches return exactly the same elements.
+	for _, query := range testVectors(10, 4) {
+		want, _ := vs.Search(query, 5, 10, nil)
+		got, _ := restored.(*VectorSet).Search(query, 5, 10, nil)
+		if len(got) != len(want) {
+			t.Fatalf("restored Search() = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("restored Search()[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
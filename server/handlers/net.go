@@ -7,9 +7,7 @@ import (
 	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/store"
 	"go.uber.org/zap"
-	"io"
 	"net"
-	"strings"
 )
 
 func StartTcpListener(node *store.RaftNode) {
@@ -75,24 +73,13 @@ func handleNetConnection(conn net.Conn, node *store.RaftNode) {
 		return
 	}
 
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		line = strings.TrimSpace(line)
-		cmd, err := commands.ParseStringIntoCommand(line)
-		if err != nil {
-			continue
-		}
-
-		response := node.ApplyCmd(cmd)
-
-		writer.WriteString(response + "\n")
-		writer.Flush()
-	}
+	newSession(node).serve(
+		func() (string, error) {
+			return reader.ReadString('\n')
+		},
+		func(response string) {
+			writer.WriteString(response + "\n")
+			writer.Flush()
+		},
+	)
 }
@@ -3,12 +3,9 @@ package handlers
 import (
 	"bufio"
 	"context"
-	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/store"
 	"github.com/quic-go/quic-go"
 	"go.uber.org/zap"
-	"io"
-	"strings"
 )
 
 func StartQuicListener(node *store.RaftNode) {
@@ -56,24 +53,13 @@ func handleQuicConnection(conn quic.Connection, node *store.RaftNode) {
 	reader := bufio.NewReader(stream)
 	writer := bufio.NewWriter(stream)
 
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		line = strings.TrimSpace(line)
-		cmd, err := commands.ParseStringIntoCommand(line)
-		if err != nil {
-			continue
-		}
-
-		response := node.ApplyCmd(cmd)
-
-		writer.WriteString(response + "\n")
-		writer.Flush()
-	}
+	newSession(node).serve(
+		func() (string, error) {
+			return reader.ReadString('\n')
+		},
+		func(response string) {
+			writer.WriteString(response + "\n")
+			writer.Flush()
+		},
+	)
 }
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/store"
+	"strings"
+)
+
+// session is the state of a client connection, which lasts as long as the connection does.
+// Its context is done once the client goes away, which stops any command blocked on its behalf.
+type session struct {
+	node   *store.RaftNode
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newSession(node *store.RaftNode) *session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &session{node: node, ctx: ctx, cancel: cancel}
+}
+
+// serve responds to each line read from the client until reading fails. Lines are read while a command
+// blocks, so that the session ends as soon as the client goes away rather than once the command returns.
+func (s *session) serve(read func() (string, error), write func(response string)) {
+	defer s.cancel()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer s.cancel()
+		for {
+			line, err := read()
+			if err != nil {
+				return
+			}
+			select {
+			case lines <- line:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for line := range lines {
+		cmd, err := commands.ParseStringIntoCommand(strings.TrimSpace(line))
+		if err != nil {
+			continue
+		}
+		write(s.node.ApplyCmdContext(s.ctx, cmd))
+	}
+}
@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/store"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 	"log"
 	"net/http"
-	"strings"
 )
 
 func StartWsListener(node *store.RaftNode) {
@@ -50,24 +48,14 @@ func handleWsRequest(upgrader websocket.Upgrader, node *store.RaftNode) http.Han
 			return
 		}
 		defer c.Close()
-		for {
-			mt, message, err := c.ReadMessage()
-			if err != nil {
-				continue
-			}
-			if mt == websocket.CloseMessage {
-				break
-			}
-
-			line := strings.TrimSpace(string(message))
-			cmd, err := commands.ParseStringIntoCommand(line)
-			if err != nil {
-				continue
-			}
-
-			response := node.ApplyCmd(cmd)
-
-			c.WriteMessage(websocket.TextMessage, []byte(response+"\n"))
-		}
+		newSession(node).serve(
+			func() (string, error) {
+				_, message, err := c.ReadMessage()
+				return string(message), err
+			},
+			func(response string) {
+				c.WriteMessage(websocket.TextMessage, []byte(response+"\n"))
+			},
+		)
 	})
 }
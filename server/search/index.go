@@ -0,0 +1,328 @@
+package search
+
+import (
+	"github.com/c16a/pouch/server/datatypes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The BM25 parameters, which are the usual defaults.
+const (
+	bm25K1 = 1.2  // How quickly repeating a term stops adding to the score
+	bm25B  = 0.75 // How much longer documents are penalised
+)
+
+// IndexOn is the data type of the values an index covers.
+type IndexOn string
+
+const (
+	OnHash   IndexOn = "HASH"
+	OnJSON   IndexOn = "JSON"
+	OnString IndexOn = "STRING"
+)
+
+// StringField is the name of the single field a string value is indexed under.
+const StringField = "value"
+
+// tagSeparator separates the tags in a value of a tag field.
+const tagSeparator = ","
+
+type FieldType string
+
+const (
+	FieldText    FieldType = "TEXT"    // Words, which are searched for by the terms of a query
+	FieldTag     FieldType = "TAG"     // Exact values, which are matched case insensitively
+	FieldNumeric FieldType = "NUMERIC" // A number, which is matched by ranges
+	FieldGeo     FieldType = "GEO"     // A "longitude,latitude" position, which is matched by radius
+)
+
+// Field is a field of the values an index covers. Queries refer to it by Name, whereas Path is the
+// member of a hash or the JSONPath of a document it is read from, if that differs.
+type Field struct {
+	Name string    `json:"name"`
+	Path string    `json:"path,omitempty"`
+	Type FieldType `json:"type"`
+}
+
+// Source returns the member of a hash or the JSONPath of a document the field is read from.
+func (f Field) Source() string {
+	if f.Path == "" {
+		return f.Name
+	}
+	return f.Path
+}
+
+// Definition is what an index was created with, which is all that needs to be replicated,
+// as the index itself can be rebuilt from the keyspace.
+type Definition struct {
+	Name   string  `json:"name"`
+	On     IndexOn `json:"on"`
+	Prefix string  `json:"prefix"`
+	Schema []Field `json:"schema"`
+}
+
+// Document is the value of a key as an index sees it, from field names to their values.
+// A field can have several values, such as the elements of a JSON array.
+type Document map[string][]string
+
+type document struct {
+	length int                 // The number of terms in the document
+	terms  []string            // The distinct terms in the document, to remove it from their postings
+	tags   map[string][]string // The tags of each tag field, to remove the document from them
+}
+
+// Result is a key matching a query, along with its BM25 score.
+type Result struct {
+	Key   string
+	Score float64
+}
+
+// Index indexes the fields of the keys starting with a prefix. Text fields share an inverted index,
+// tag fields map each tag to its keys, and numeric and geo fields hold their keys in sorted sets.
+type Index struct {
+	def         Definition
+	postings    map[string]map[string][]int // The positions of each term in each key it appears in
+	docs        map[string]*document
+	totalLength int
+	tags        map[string]map[string]map[string]bool // The keys with each tag of each tag field
+	numbers     map[string]*datatypes.SortedSet       // The keys of each numeric field, scored by their value
+	points      map[string]*datatypes.SortedSet       // The keys of each geo field, scored by their position
+}
+
+func NewIndex(def Definition) *Index {
+	ix := &Index{
+		def:      def,
+		postings: make(map[string]map[string][]int),
+		docs:     make(map[string]*document),
+		tags:     make(map[string]map[string]map[string]bool),
+		numbers:  make(map[string]*datatypes.SortedSet),
+		points:   make(map[string]*datatypes.SortedSet),
+	}
+	for _, f := range def.Schema {
+		switch f.Type {
+		case FieldTag:
+			ix.tags[f.Name] = make(map[string]map[string]bool)
+		case FieldNumeric:
+			ix.numbers[f.Name] = datatypes.NewSortedSet()
+		case FieldGeo:
+			ix.points[f.Name] = datatypes.NewSortedSet()
+		}
+	}
+	return ix
+}
+
+// Field returns the field of the schema with a name, and false if there is none.
+func (ix *Index) Field(name string) (Field, bool) {
+	for _, f := range ix.def.Schema {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func (ix *Index) Definition() Definition {
+	return ix.def
+}
+
+// Covers reports whether a key falls under the prefix of the index.
+func (ix *Index) Covers(key string) bool {
+	return strings.HasPrefix(key, ix.def.Prefix)
+}
+
+// Len returns the number of keys in the index.
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// splitTags splits the values of a tag field into its distinct tags, which are trimmed and lower cased.
+func splitTags(values []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, tag := range strings.Split(value, tagSeparator) {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// parseNumber returns the first value of a numeric field which is a finite number.
+func parseNumber(values []string) (float64, bool) {
+	for _, value := range values {
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// parsePoint returns the first value of a geo field which is a valid "longitude,latitude" position.
+func parsePoint(values []string) (datatypes.GeoPoint, bool) {
+	for _, value := range values {
+		lon, lat, ok := strings.Cut(value, ",")
+		if !ok {
+			continue
+		}
+		p := datatypes.GeoPoint{}
+		var errLon, errLat error
+		p.Longitude, errLon = strconv.ParseFloat(strings.TrimSpace(lon), 64)
+		p.Latitude, errLat = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		if errLon == nil && errLat == nil && datatypes.IsValidGeoPoint(p) {
+			return p, true
+		}
+	}
+	return datatypes.GeoPoint{}, false
+}
+
+// Add indexes the fields of a document under a key, replacing whatever the key was indexed with before.
+// Each text value is numbered on from the last, leaving a gap so that phrases don't match across values.
+// Values which aren't numbers or positions are left out of numeric and geo fields.
+func (ix *Index) Add(key string, doc Document) {
+	ix.Remove(key)
+
+	positions := make(map[string][]int)
+	var terms []string
+	position := 0
+	tags := make(map[string][]string)
+	for _, field := range ix.def.Schema {
+		switch field.Type {
+		case FieldText:
+			for _, value := range doc[field.Name] {
+				for _, term := range Tokenize(value) {
+					if _, ok := positions[term]; !ok {
+						terms = append(terms, term)
+					}
+					positions[term] = append(positions[term], position)
+					position++
+				}
+				position++
+			}
+		case FieldTag:
+			tags[field.Name] = splitTags(doc[field.Name])
+			for _, tag := range tags[field.Name] {
+				keys, ok := ix.tags[field.Name][tag]
+				if !ok {
+					keys = make(map[string]bool)
+					ix.tags[field.Name][tag] = keys
+				}
+				keys[key] = true
+			}
+		case FieldNumeric:
+			if n, ok := parseNumber(doc[field.Name]); ok {
+				ix.numbers[field.Name].Add(key, n)
+			}
+		case FieldGeo:
+			if p, ok := parsePoint(doc[field.Name]); ok {
+				ix.points[field.Name].Add(key, datatypes.GeoScore(p))
+			}
+		}
+	}
+
+	length := 0
+	for _, term := range terms {
+		postings, ok := ix.postings[term]
+		if !ok {
+			postings = make(map[string][]int)
+			ix.postings[term] = postings
+		}
+		postings[key] = positions[term]
+		length += len(positions[term])
+	}
+	ix.docs[key] = &document{length: length, terms: terms, tags: tags}
+	ix.totalLength += length
+}
+
+// Remove removes a key from the index, if it is indexed.
+func (ix *Index) Remove(key string) {
+	doc, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+
+	for _, term := range doc.terms {
+		postings := ix.postings[term]
+		delete(postings, key)
+		if len(postings) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	for field, tags := range doc.tags {
+		for _, tag := range tags {
+			keys := ix.tags[field][tag]
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(ix.tags[field], tag)
+			}
+		}
+	}
+	for _, numbers := range ix.numbers {
+		numbers.Remove(key)
+	}
+	for _, points := range ix.points {
+		points.Remove(key)
+	}
+	delete(ix.docs, key)
+	ix.totalLength -= doc.length
+}
+
+// score returns the BM25 score of a key for the terms of a query.
+func (ix *Index) score(key string, terms []string) float64 {
+	n := float64(len(ix.docs))
+	averageLength := float64(ix.totalLength) / n
+	length := float64(ix.docs[key].length)
+
+	var score float64
+	for _, term := range terms {
+		postings := ix.postings[term]
+		tf := float64(len(postings[key]))
+		if tf == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		norm := bm25K1 * (1 - bm25B + float64(bm25B*length)/averageLength)
+		score += float64(idf * float64(tf*(bm25K1+1)) / (tf + norm))
+	}
+	return score
+}
+
+// Search returns the number of keys matching a query, along with up to limit of them from an offset,
+// from the highest BM25 score to the lowest, and by key among equal scores. Keys matched only by
+// tag, numeric or geo predicates score zero, so they come in key order.
+func (ix *Index) Search(q Query, offset, limit int) (int, []Result) {
+	matched := q.match(ix)
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range q.terms(ix, nil) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	results := make([]Result, 0, len(matched))
+	for key := range matched {
+		results = append(results, Result{Key: key, Score: ix.score(key, terms)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Key < results[j].Key
+	})
+
+	total := len(results)
+	if offset >= total {
+		return total, []Result{}
+	}
+	return total, results[offset:min(total, offset+limit)]
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"testing"
+)
+
+func newTestIndex() *Index {
+	ix := NewIndex(Definition{
+		Name:   "books",
+		On:     OnHash,
+		Prefix: "book:",
+		Schema: []Field{
+			{Name: "title", Type: FieldText},
+			{Name: "body", Type: FieldText},
+			{Name: "genre", Type: FieldTag},
+			{Name: "pages", Type: FieldNumeric},
+			{Name: "shop", Path: "$.shop", Type: FieldGeo},
+		},
+	})
+	ix.Add("book:1", Document{
+		"title": {"The quick brown fox"},
+		"body":  {"jumps over the lazy dog"},
+		"genre": {"Fiction, Animals"},
+		"pages": {"120"},
+		"shop":  {"-0.1276,51.5072"}, // London
+	})
+	ix.Add("book:2", Document{
+		"title": {"Lazy dogs"},
+		"body":  {"Dogs sleeping all day, dogs dreaming of foxes"},
+		"genre": {"animals", "poetry"},
+		"pages": {"not a number", "48"},
+		"shop":  {"2.3522,48.8566"}, // Paris
+	})
+	ix.Add("book:3", Document{
+		"title":  {"Brown bread"},
+		"body":   {"Baking bread at home"},
+		"author": {"fox"},
+		"genre":  {"cooking"},
+		"pages":  {"300"},
+		"shop":   {"north"},
+	})
+	return ix
+}
+
+func searchKeys(t *testing.T, ix *Index, query string) []string {
+	t.Helper()
+	q, err := ParseQuery(query, ix.Definition().Schema)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q) returned %v", query, err)
+	}
+	_, results := ix.Search(q, 0, 10)
+	keys := make([]string, 0, len(results))
+	for _, r := range results {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
+func TestIndex_Search(t *testing.T) {
+	ix := newTestIndex()
+
+	if !ix.Covers("book:4") || ix.Covers("books") {
+		t.Errorf("Covers doesn't match the prefix")
+	}
+
+	// book:2 mentions dogs three times in a document of about the same length, so it ranks first.
+	if got := searchKeys(t, ix, "dog"); len(got) != 2 || got[0] != "book:2" || got[1] != "book:1" {
+		t.Errorf("Search(dog) = %v, want book:2 then book:1", got)
+	}
+	// Fields outside the schema aren't indexed.
+	if got := searchKeys(t, ix, "fox"); len(got) != 2 || got[0] != "book:1" {
+		t.Errorf("Search(fox) = %v, want book:1 and book:2", got)
+	}
+
+	total, page := ix.Search(&termQuery{term: "dog"}, 1, 10)
+	if total != 2 || len(page) != 1 || page[0].Key != "book:1" {
+		t.Errorf("Search from offset 1 = %d, %v, want book:1 of 2", total, page)
+	}
+	if total, page := ix.Search(&termQuery{term: "dog"}, 5, 10); total != 2 || len(page) != 0 {
+		t.Errorf("Search past the end = %d, %v, want no results of 2", total, page)
+	}
+}
+
+func TestIndex_AddRemove(t *testing.T) {
+	ix := newTestIndex()
+
+	ix.Add("book:1", Document{"title": {"Bread and butter"}, "genre": {"cooking"}})
+	if got := searchKeys(t, ix, "fox"); len(got) != 1 || got[0] != "book:2" {
+		t.Errorf("Search(fox) after replacing book:1 = %v, want book:2", got)
+	}
+	if got := searchKeys(t, ix, "bread"); len(got) != 2 {
+		t.Errorf("Search(bread) after replacing book:1 = %v, want 2 keys", got)
+	}
+
+	ix.Remove("book:1")
+	ix.Remove("book:1")
+	if ix.Len() != 2 || len(searchKeys(t, ix, "butter")) != 0 {
+		t.Errorf("book:1 is still indexed after Remove")
+	}
+	if _, ok := ix.postings["butter"]; ok {
+		t.Errorf("postings of removed terms are kept")
+	}
+	if len(ix.tags["genre"]["cooking"]) != 1 || ix.numbers["pages"].Card() != 2 || ix.points["shop"].Card() != 1 {
+		t.Errorf("book:1 is still in the tag, numeric or geo fields after Remove")
+	}
+}
@@ -0,0 +1,563 @@
+package search
+
+import (
+	"errors"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidQuery = errors.New("InvalidQuery")
+	ErrUnknownField = errors.New("UnknownField") // A predicate names a field which isn't in the schema
+)
+
+// maxPrefixExpansions bounds the number of terms a prefix matches, taking the first in order.
+const maxPrefixExpansions = 200
+
+// Query is a parsed search query, which matches a set of keys in an index.
+type Query interface {
+	match(ix *Index) map[string]bool
+	// terms appends the terms that matching keys are scored by.
+	terms(ix *Index, terms []string) []string
+}
+
+type termQuery struct {
+	term string
+}
+
+func (q *termQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool, len(ix.postings[q.term]))
+	for key := range ix.postings[q.term] {
+		keys[key] = true
+	}
+	return keys
+}
+
+func (q *termQuery) terms(_ *Index, terms []string) []string {
+	return append(terms, q.term)
+}
+
+// prefixQuery matches the words starting with a prefix, which isn't stemmed.
+type prefixQuery struct {
+	prefix string
+}
+
+func (q *prefixQuery) expand(ix *Index) []string {
+	var expanded []string
+	for term := range ix.postings {
+		if strings.HasPrefix(term, q.prefix) {
+			expanded = append(expanded, term)
+		}
+	}
+	sort.Strings(expanded)
+	return expanded[:min(len(expanded), maxPrefixExpansions)]
+}
+
+func (q *prefixQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for _, term := range q.expand(ix) {
+		for key := range ix.postings[term] {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (q *prefixQuery) terms(ix *Index, terms []string) []string {
+	return append(terms, q.expand(ix)...)
+}
+
+// phraseQuery matches the keys with its terms next to each other, in order.
+type phraseQuery struct {
+	phrase []string
+}
+
+func (q *phraseQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for key, starts := range ix.postings[q.phrase[0]] {
+		for _, start := range starts {
+			if q.matchesAt(ix, key, start) {
+				keys[key] = true
+				break
+			}
+		}
+	}
+	return keys
+}
+
+func (q *phraseQuery) matchesAt(ix *Index, key string, start int) bool {
+	for i, term := range q.phrase[1:] {
+		positions := ix.postings[term][key]
+		j := sort.SearchInts(positions, start+i+1)
+		if j == len(positions) || positions[j] != start+i+1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *phraseQuery) terms(_ *Index, terms []string) []string {
+	return append(terms, q.phrase...)
+}
+
+type andQuery struct {
+	children []Query
+}
+
+func (q *andQuery) match(ix *Index) map[string]bool {
+	keys := q.children[0].match(ix)
+	for _, child := range q.children[1:] {
+		matched := child.match(ix)
+		for key := range keys {
+			if !matched[key] {
+				delete(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func (q *andQuery) terms(ix *Index, terms []string) []string {
+	for _, child := range q.children {
+		terms = child.terms(ix, terms)
+	}
+	return terms
+}
+
+type orQuery struct {
+	children []Query
+}
+
+func (q *orQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for _, child := range q.children {
+		for key := range child.match(ix) {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (q *orQuery) terms(ix *Index, terms []string) []string {
+	for _, child := range q.children {
+		terms = child.terms(ix, terms)
+	}
+	return terms
+}
+
+// notQuery matches every key its child doesn't, and adds nothing to their scores.
+type notQuery struct {
+	child Query
+}
+
+func (q *notQuery) match(ix *Index) map[string]bool {
+	excluded := q.child.match(ix)
+	keys := make(map[string]bool, len(ix.docs))
+	for key := range ix.docs {
+		if !excluded[key] {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (q *notQuery) terms(_ *Index, terms []string) []string {
+	return terms
+}
+
+// allQuery matches every key in the index.
+type allQuery struct{}
+
+func (q *allQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool, len(ix.docs))
+	for key := range ix.docs {
+		keys[key] = true
+	}
+	return keys
+}
+
+func (q *allQuery) terms(_ *Index, terms []string) []string {
+	return terms
+}
+
+// tagQuery matches the keys with any of the tags in a tag field.
+type tagQuery struct {
+	field string
+	tags  []string
+}
+
+func (q *tagQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for _, tag := range q.tags {
+		for key := range ix.tags[q.field][tag] {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (q *tagQuery) terms(_ *Index, terms []string) []string {
+	return terms
+}
+
+// numericQuery matches the keys with a numeric field in a range.
+type numericQuery struct {
+	field string
+	r     datatypes.ScoreRange
+}
+
+func (q *numericQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for _, entry := range ix.numbers[q.field].RangeByScore(q.r, false, 0, -1) {
+		keys[entry.Name] = true
+	}
+	return keys
+}
+
+func (q *numericQuery) terms(_ *Index, terms []string) []string {
+	return terms
+}
+
+// geoQuery matches the keys with a geo field within a radius.
+type geoQuery struct {
+	field string
+	area  datatypes.GeoQuery
+}
+
+func (q *geoQuery) match(ix *Index) map[string]bool {
+	keys := make(map[string]bool)
+	for _, m := range ix.points[q.field].GeoSearch(q.area) {
+		keys[m.Name] = true
+	}
+	return keys
+}
+
+func (q *geoQuery) terms(_ *Index, terms []string) []string {
+	return terms
+}
+
+type queryTokenKind int
+
+const (
+	tokenWord queryTokenKind = iota
+	tokenPrefix
+	tokenPhrase
+	tokenNot
+	tokenOr
+	tokenOpen
+	tokenClose
+	tokenAll
+	tokenTags  // @field:{tag | tag}
+	tokenRange // @field:[min max] or @field:[longitude latitude radius unit]
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	text  string
+	field string // The field of a predicate
+}
+
+// lexPredicate reads a predicate, which starts at an @, and returns its token along with its length.
+func lexPredicate(s string) (queryToken, int, error) {
+	colon := strings.IndexByte(s, ':')
+	if colon <= 1 || colon == len(s)-1 || strings.ContainsFunc(s[1:colon], unicode.IsSpace) {
+		return queryToken{}, 0, ErrInvalidQuery
+	}
+
+	var token queryToken
+	var closing byte
+	switch s[colon+1] {
+	case '{':
+		token.kind, closing = tokenTags, '}'
+	case '[':
+		token.kind, closing = tokenRange, ']'
+	default:
+		return queryToken{}, 0, ErrInvalidQuery
+	}
+
+	end := strings.IndexByte(s[colon+2:], closing)
+	if end == -1 {
+		return queryToken{}, 0, ErrInvalidQuery
+	}
+	token.field, token.text = s[1:colon], s[colon+2:colon+2+end]
+	return token, colon + 3 + end, nil
+}
+
+// lexQuery splits a query into tokens. A - only negates at the start of a word, so that
+// hyphenated words are searched as separate words like they are indexed.
+func lexQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	atStart := true
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen})
+			i += size
+			atStart = true
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose})
+			i += size
+			atStart = false
+		case r == '|':
+			tokens = append(tokens, queryToken{kind: tokenOr})
+			i += size
+			atStart = true
+		case r == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end == -1 {
+				return nil, ErrInvalidQuery
+			}
+			tokens = append(tokens, queryToken{kind: tokenPhrase, text: s[i+1 : i+1+end]})
+			i += end + 2
+			atStart = false
+		case r == '-' && atStart:
+			tokens = append(tokens, queryToken{kind: tokenNot})
+			i += size
+		case r == '*':
+			tokens = append(tokens, queryToken{kind: tokenAll})
+			i += size
+			atStart = false
+		case r == '@':
+			token, n, err := lexPredicate(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i += n
+			atStart = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			end := strings.IndexFunc(s[i:], func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if end == -1 {
+				end = len(s) - i
+			}
+			token := queryToken{kind: tokenWord, text: strings.ToLower(s[i : i+end])}
+			i += end
+			if i < len(s) && s[i] == '*' {
+				token.kind = tokenPrefix
+				i++
+			}
+			tokens = append(tokens, token)
+			atStart = false
+		default:
+			i += size
+			atStart = unicode.IsSpace(r)
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	schema []Field
+}
+
+// ParseQuery parses a search query over an index with a schema. Words are matched after stemming,
+// and words next to each other must all match. | matches either side, - excludes the words or group
+// after it, "quotes" match a phrase, a trailing * matches the words starting with a prefix, a lone *
+// matches everything, and parentheses group.
+//
+// Tag, numeric and geo fields are matched by predicates: @field:{a | b} matches either tag,
+// @field:[min max] matches numbers in a range, where ( before a bound excludes it and -inf and +inf
+// leave it open, and @field:[longitude latitude radius M|KM|FT|MI] matches positions within the radius.
+func ParseQuery(s string, schema []Field) (Query, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens, schema: schema}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, ErrInvalidQuery
+	}
+	return q, nil
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos == len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (Query, error) {
+	var children []Query
+	for {
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+
+		if token, ok := p.peek(); !ok || token.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &orQuery{children: children}, nil
+}
+
+func (p *queryParser) parseAnd() (Query, error) {
+	var children []Query
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind == tokenOr || token.kind == tokenClose {
+			break
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, ErrInvalidQuery
+	case 1:
+		return children[0], nil
+	default:
+		return &andQuery{children: children}, nil
+	}
+}
+
+func (p *queryParser) parseUnary() (Query, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, ErrInvalidQuery
+	}
+	p.pos++
+
+	switch token.kind {
+	case tokenNot:
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notQuery{child: child}, nil
+	case tokenOpen:
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := p.peek(); !ok || token.kind != tokenClose {
+			return nil, ErrInvalidQuery
+		}
+		p.pos++
+		return q, nil
+	case tokenWord:
+		return &termQuery{term: Stem(token.text)}, nil
+	case tokenPrefix:
+		if utf8.RuneCountInString(token.text) < 2 {
+			return nil, ErrInvalidQuery
+		}
+		return &prefixQuery{prefix: token.text}, nil
+	case tokenAll:
+		return &allQuery{}, nil
+	case tokenTags, tokenRange:
+		return p.parsePredicate(token)
+	case tokenPhrase:
+		phrase := Tokenize(token.text)
+		switch len(phrase) {
+		case 0:
+			return nil, ErrInvalidQuery
+		case 1:
+			return &termQuery{term: phrase[0]}, nil
+		default:
+			return &phraseQuery{phrase: phrase}, nil
+		}
+	default:
+		return nil, ErrInvalidQuery
+	}
+}
+
+func (p *queryParser) parsePredicate(token queryToken) (Query, error) {
+	var field *Field
+	for i := range p.schema {
+		if p.schema[i].Name == token.field {
+			field = &p.schema[i]
+		}
+	}
+	if field == nil {
+		return nil, ErrUnknownField
+	}
+
+	switch {
+	case token.kind == tokenTags && field.Type == FieldTag:
+		tags := splitTags(strings.Split(token.text, "|"))
+		if len(tags) == 0 {
+			return nil, ErrInvalidQuery
+		}
+		return &tagQuery{field: field.Name, tags: tags}, nil
+	case token.kind == tokenRange && field.Type == FieldNumeric:
+		return parseNumericPredicate(field.Name, strings.Fields(token.text))
+	case token.kind == tokenRange && field.Type == FieldGeo:
+		return parseGeoPredicate(field.Name, strings.Fields(token.text))
+	default:
+		return nil, ErrInvalidQuery
+	}
+}
+
+// parseBound parses a bound of a numeric range, returning whether it is exclusive.
+func parseBound(s string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(s, "(")
+	n, err := strconv.ParseFloat(strings.TrimPrefix(s, "("), 64)
+	if err != nil || math.IsNaN(n) {
+		return 0, false, ErrInvalidQuery
+	}
+	return n, exclusive, nil
+}
+
+func parseNumericPredicate(field string, bounds []string) (Query, error) {
+	if len(bounds) != 2 {
+		return nil, ErrInvalidQuery
+	}
+
+	q := &numericQuery{field: field}
+	var err error
+	if q.r.Min, q.r.MinExclusive, err = parseBound(bounds[0]); err != nil {
+		return nil, err
+	}
+	if q.r.Max, q.r.MaxExclusive, err = parseBound(bounds[1]); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func parseGeoPredicate(field string, args []string) (Query, error) {
+	if len(args) != 4 {
+		return nil, ErrInvalidQuery
+	}
+
+	var center datatypes.GeoPoint
+	var errLon, errLat error
+	center.Longitude, errLon = strconv.ParseFloat(args[0], 64)
+	center.Latitude, errLat = strconv.ParseFloat(args[1], 64)
+	if errLon != nil || errLat != nil || !datatypes.IsValidGeoPoint(center) {
+		return nil, ErrInvalidQuery
+	}
+
+	radius, err := strconv.ParseFloat(args[2], 64)
+	unit, ok := commands.GeoUnits[strings.ToUpper(args[3])]
+	if err != nil || !ok || radius < 0 || math.IsInf(radius, 0) || math.IsNaN(radius) {
+		return nil, ErrInvalidQuery
+	}
+	return &geoQuery{field: field, area: datatypes.GeoQuery{Center: center, Radius: radius * unit}}, nil
+}
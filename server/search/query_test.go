@@ -0,0 +1,85 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	ix := newTestIndex()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"dogs", []string{"book:1", "book:2"}},
+		{"brown dog", []string{"book:1"}},
+		{"bread | fox", []string{"book:1", "book:2", "book:3"}},
+		{"brown -bread", []string{"book:1"}},
+		{"-(dog | bread)", []string{}},
+		{"-lazy", []string{"book:3"}},
+		{`"lazy dog"`, []string{"book:1", "book:2"}},
+		{`"dog lazy"`, []string{}},
+		{`"fox jumps"`, []string{}}, // The words are in different fields
+		{`"Dogs, sleeping"`, []string{"book:2"}},
+		{"dre*", []string{"book:2"}},
+		{"brow* (lazy | baking)", []string{"book:1", "book:3"}},
+		{"e-mail", []string{}},
+		{"unknown", []string{}},
+		{"*", []string{"book:1", "book:2", "book:3"}},
+		{"@genre:{ANIMALS}", []string{"book:1", "book:2"}},
+		{"@genre:{poetry | cooking}", []string{"book:2", "book:3"}},
+		{"@genre:{animals} -@genre:{fiction}", []string{"book:2"}},
+		{"@pages:[48 120]", []string{"book:1", "book:2"}},
+		{"@pages:[(48 +inf]", []string{"book:1", "book:3"}},
+		{"@pages:[-inf (120]", []string{"book:2"}},
+		{"brown @pages:[100 200]", []string{"book:1"}},
+		{"@shop:[0 51 200 km]", []string{"book:1"}},
+		{"@shop:[0 51 500 KM]", []string{"book:1", "book:2"}},
+		{"@shop:[0 51 10 mi] | @genre:{cooking}", []string{"book:3"}},
+	}
+
+	for _, tt := range tests {
+		got := searchKeys(t, ix, tt.query)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuery_Invalid(t *testing.T) {
+	schema := newTestIndex().Definition().Schema
+	for _, query := range []string{
+		"", "   ", "(dog", "dog)", "a | ", `"dog`, `""`, "d*", "dog -", "()",
+		"@genre", "@genre:", "@genre:animals", "@genre:{animals", "@genre:{ | }", "@genre:[1 2]", "@title:{a}",
+		"@pages:[1]", "@pages:[a 2]", "@pages:[1 nan]", "@pages:{1}",
+		"@shop:[0 51 10]", "@shop:[0 91 10 km]", "@shop:[0 51 -1 km]", "@shop:[0 51 10 au]",
+	} {
+		if _, err := ParseQuery(query, schema); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseQuery(%q) returned %v, want ErrInvalidQuery", query, err)
+		}
+	}
+
+	if _, err := ParseQuery("@author:{fox}", schema); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("ParseQuery of an unknown field returned %v, want ErrUnknownField", err)
+	}
+}
+
+func TestQuery_Scoring(t *testing.T) {
+	ix := newTestIndex()
+
+	// Terms only count once, and negated terms don't count at all.
+	q, _ := ParseQuery("lazy lazy -bread @pages:[0 1000]", ix.Definition().Schema)
+	_, twice := ix.Search(q, 0, 10)
+	q, _ = ParseQuery("lazy", ix.Definition().Schema)
+	_, once := ix.Search(q, 0, 10)
+	if !reflect.DeepEqual(twice, once) {
+		t.Errorf("Search(lazy lazy -bread) = %v, want %v", twice, once)
+	}
+	if once[0].Score <= 0 {
+		t.Errorf("Search(lazy) scored %v", once[0].Score)
+	}
+}
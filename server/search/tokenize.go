@@ -0,0 +1,22 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitWords splits text into lower case words of letters and digits, dropping everything in between.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Tokenize splits text into the terms it is indexed and searched by, which are its words, lower cased and stemmed.
+func Tokenize(text string) []string {
+	words := splitWords(text)
+	for i, word := range words {
+		words[i] = Stem(word)
+	}
+	return words
+}
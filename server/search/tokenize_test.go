@@ -0,0 +1,25 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Connected connections are CONNECTING", []string{"connect", "connect", "ar", "connect"}},
+		{"e-mail 42 times", []string{"e", "mail", "42", "time"}},
+		{"Größe über Äpfel", []string{"größe", "über", "äpfel"}},
+	}
+
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) && len(got)+len(tt.want) > 0 {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
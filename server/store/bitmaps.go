@@ -0,0 +1,164 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) SetBit(cmd *commands.SetBitCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) BitOp(cmd *commands.BitOpCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// BitField is always replicated, even when it only reads, so that all of its
+// sub-operations execute atomically as a single log entry.
+func (node *RaftNode) BitField(cmd *commands.BitFieldCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) GetBit(cmd *commands.GetBitCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bitmap, err := node.findBitmapOrEmpty(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: bitValue(bitmap.GetBit(cmd.Offset))}).String()
+}
+
+func (node *RaftNode) BitCount(cmd *commands.BitCountCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bitmap, err := node.findBitmapOrEmpty(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: bitmap.Count(cmd.Start, cmd.End, cmd.BitMode)}).String()
+}
+
+func (node *RaftNode) BitPos(cmd *commands.BitPosCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bitmap, err := node.findBitmapOrEmpty(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	pos := bitmap.Pos(cmd.Value, cmd.Start, cmd.End, cmd.BitMode, cmd.HasEnd)
+	return (&commands.CountResponse{Count: int(pos)}).String()
+}
+
+func (node *RaftNode) applySetBit(cmd *commands.SetBitCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bitmap, err := node.findBitmap(cmd.Key)
+	if err == commands.ErrorNotFound {
+		bitmap = datatypes.NewBitmap()
+		node.m[cmd.Key] = bitmap
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	old := bitmap.SetBit(cmd.Offset, cmd.Value)
+	return (&commands.CountResponse{Count: bitValue(old)}).String()
+}
+
+func (node *RaftNode) applyBitOp(cmd *commands.BitOpCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	sources := make([]*datatypes.Bitmap, 0, len(cmd.SourceKeys))
+	for _, key := range cmd.SourceKeys {
+		bitmap, err := node.findBitmapOrEmpty(key)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		sources = append(sources, bitmap)
+	}
+
+	result := datatypes.BitOp(datatypes.BitOperation(cmd.Operation), sources...)
+	if result.Len() == 0 {
+		delete(node.m, cmd.DestKey)
+	} else {
+		node.m[cmd.DestKey] = result
+	}
+	node.reindex(cmd.DestKey)
+	return (&commands.CountResponse{Count: result.Len()}).String()
+}
+
+func (node *RaftNode) applyBitField(cmd *commands.BitFieldCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bitmap, err := node.findBitmap(cmd.Key)
+	if err == commands.ErrorNotFound {
+		bitmap = datatypes.NewBitmap()
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	results := make([]string, 0, len(cmd.Ops))
+	for _, op := range cmd.Ops {
+		fieldType := datatypes.BitFieldType{Signed: op.Signed, Bits: op.Bits}
+		overflow := datatypes.BitFieldOverflow(op.Overflow)
+
+		var value int64
+		ok := true
+		switch op.Kind {
+		case "GET":
+			value = bitmap.GetField(op.Offset, fieldType)
+		case "SET":
+			value, ok = bitmap.SetField(op.Offset, fieldType, op.Value, overflow)
+		case "INCRBY":
+			value, ok = bitmap.IncrField(op.Offset, fieldType, op.Value, overflow)
+		}
+
+		if ok {
+			results = append(results, strconv.FormatInt(value, 10))
+		} else {
+			results = append(results, "nil")
+		}
+	}
+
+	if bitmap.Len() > 0 {
+		node.m[cmd.Key] = bitmap
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+func (node *RaftNode) findBitmap(key string) (*datatypes.Bitmap, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "bitmap":
+			bitmap := val.(*datatypes.Bitmap)
+			return bitmap, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
+
+// findBitmapOrEmpty treats a missing key as an empty bitmap, as every bit of a bitmap is zero until it is set.
+func (node *RaftNode) findBitmapOrEmpty(key string) (*datatypes.Bitmap, error) {
+	bitmap, err := node.findBitmap(key)
+	if err == commands.ErrorNotFound {
+		return datatypes.NewBitmap(), nil
+	}
+	return bitmap, err
+}
+
+func bitValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
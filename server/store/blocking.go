@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"sync"
+	"time"
+)
+
+// blockRecheckInterval is how often a blocked command looks at its keys without being woken. This covers elements
+// which arrive without a push, such as from a snapshot. Looking is local, so it costs no Raft log entries, and only
+// the command at the head of a key's queue goes on to pop from it.
+const blockRecheckInterval = time.Second
+
+// waiter is a blocked command, which is woken when one of its keys may have an element for it.
+type waiter struct {
+	keys  []string
+	ready chan struct{}
+}
+
+// waitQueues holds the commands blocked on each key, in the order they blocked. A waiter only ever sits in the
+// queues of the node its client is connected to, so it is local state rather than part of the FSM.
+type waitQueues struct {
+	mu     sync.Mutex
+	queues map[string][]*waiter
+}
+
+// add queues a waiter on keys, behind the waiters already on them.
+func (q *waitQueues) add(keys []string) *waiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queues == nil {
+		q.queues = make(map[string][]*waiter)
+	}
+	w := &waiter{keys: keys, ready: make(chan struct{}, 1)}
+	for _, key := range keys {
+		q.queues[key] = append(q.queues[key], w)
+	}
+	return w
+}
+
+// remove dequeues a waiter, and wakes the waiters which take its place at the head of its keys' queues, since
+// they may have been woken before they got there, while they still had to leave the elements to it.
+func (q *waitQueues) remove(w *waiter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, key := range w.keys {
+		queue := q.queues[key]
+		for i, other := range queue {
+			if other == w {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+		if len(queue) == 0 {
+			delete(q.queues, key)
+			continue
+		}
+		q.queues[key] = queue
+		select {
+		case queue[0].ready <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// isHead reports whether a waiter is the first of those blocked on a key.
+func (q *waitQueues) isHead(w *waiter, key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[key]
+	return len(queue) > 0 && queue[0] == w
+}
+
+// wake wakes up to n of the waiters on a key in the order they blocked, for n elements pushed onto it.
+// Waiters which are already awake are skipped, so that each element goes to a different waiter.
+func (q *waitQueues) wake(key string, n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, w := range q.queues[key] {
+		if n == 0 {
+			return
+		}
+		select {
+		case w.ready <- struct{}{}:
+			n--
+		default:
+		}
+	}
+}
+
+// ApplyCmdContext serves a command for a client connection. Unlike ApplyCmd, which only tries blocking commands
+// once, it retries them until they succeed, time out, or ctx is done.
+//
+// A blocking command is only sent through Raft once this node's copy of the store has an element on one of its
+// keys, and is otherwise left waiting to be woken by a push onto them, so an idle client adds nothing to the log. Since every
+// replica applies the pushes, a client blocked on a follower is woken just the same, and its next attempt goes
+// through whichever node is leader by then.
+//
+// Clients of a node are served first in, first out: a command only pops from a key once it is at the head of the
+// key's queue, so a client arriving while others are blocked on the key waits behind them.
+func (node *RaftNode) ApplyCmdContext(ctx context.Context, cmd commands.Command) string {
+	if !commands.IsBlocking(cmd.GetMessageType()) {
+		return node.ApplyCmd(cmd)
+	}
+
+	var keys []string
+	var timeout time.Duration
+	switch c := cmd.(type) {
+	case *commands.BPopCommand:
+		keys, timeout = c.Keys, c.Timeout
+	case *commands.LMoveCommand:
+		keys, timeout = []string{c.Source}, c.Timeout
+	}
+
+	// Queue before the first look, so that a push between looking and waiting isn't missed.
+	w := node.waiters.add(keys)
+	defer node.waiters.remove(w)
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(blockRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if key, ok := node.readyKey(keys); ok && node.waiters.isHead(w, key) {
+			// A client of another node may have taken the elements first, in which case this one goes back to
+			// waiting. Any other error is returned, as the pop may have been committed even if its response was lost.
+			response := node.ApplyCmd(cmd)
+			if response != (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String() {
+				return response
+			}
+		}
+
+		select {
+		case <-w.ready:
+		case <-ticker.C:
+		case <-deadline:
+			return (&commands.ErrorResponse{Err: commands.ErrorTimeout}).String()
+		case <-ctx.Done():
+			return (&commands.ErrorResponse{Err: ctx.Err()}).String()
+		}
+	}
+}
+
+// readyKey returns the first of the keys in this node's copy of the store to hold a list with an element, which is
+// the one a pop would take from, or a value of another type, which is tried so that its error is returned.
+func (node *RaftNode) readyKey(keys []string) (string, bool) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	for _, key := range keys {
+		val, ok := node.m[key]
+		if !ok {
+			continue
+		}
+		if list, isList := val.(*datatypes.List); !isList || list.LLen() > 0 {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// BPop serves BLPOP and BRPOP, trying each key once. ApplyCmdContext is what blocks.
+func (node *RaftNode) BPop(cmd *commands.BPopCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// applyBPop pops from the first of the keys to have an element, responding with the key and the element.
+func (node *RaftNode) applyBPop(cmd *commands.BPopCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	for _, key := range cmd.Keys {
+		list, err := node.findList(key)
+		if err == commands.ErrorNotFound {
+			continue
+		}
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+
+		var value string
+		var ok bool
+		if cmd.GetMessageType() == commands.BLPop {
+			value, ok = list.LPop()
+		} else {
+			value, ok = list.RPop()
+		}
+		if !ok {
+			continue
+		}
+		if list.LLen() == 0 {
+			delete(node.m, key)
+		}
+		return (&commands.ListResponse{Values: []string{key, value}}).String()
+	}
+	return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+}
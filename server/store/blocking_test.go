@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"testing"
+	"time"
+)
+
+// blockOn starts a BLPOP on a node and waits until it is queued behind the ones already blocked on the key.
+func blockOn(t *testing.T, node *RaftNode, key string) <-chan string {
+	t.Helper()
+
+	cmd, err := commands.ParseStringIntoCommand("BLPOP " + key + " 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node.waiters.mu.Lock()
+	queued := len(node.waiters.queues[key])
+	node.waiters.mu.Unlock()
+
+	responses := make(chan string, 1)
+	go func() {
+		responses <- node.ApplyCmdContext(context.Background(), cmd)
+	}()
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		node.waiters.mu.Lock()
+		n := len(node.waiters.queues[key])
+		node.waiters.mu.Unlock()
+		if n > queued {
+			return responses
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("BLPOP did not block")
+		}
+	}
+}
+
+// receive returns the response of the first of the clients to be served, failing if it isn't want.
+func receive(t *testing.T, want <-chan string, others ...<-chan string) string {
+	t.Helper()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		for _, other := range others {
+			select {
+			case got := <-other:
+				t.Fatalf("a client blocked later got %q first", got)
+			default:
+			}
+		}
+		select {
+		case got := <-want:
+			return got
+		case <-timeout:
+			t.Fatal("the first blocked client was not served")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestApplyCmdContext_FIFO(t *testing.T) {
+	node := newTestNode(t)
+	first := blockOn(t, node, "queue")
+	second := blockOn(t, node, "queue")
+
+	applyLine(t, node, "RPUSH queue a")
+	if got, want := receive(t, first, second), (&commands.ListResponse{Values: []string{"queue", "a"}}).String(); got != want {
+		t.Errorf("first blocked client got %q, want %q", got, want)
+	}
+
+	applyLine(t, node, "RPUSH queue b")
+	if got, want := receive(t, second), (&commands.ListResponse{Values: []string{"queue", "b"}}).String(); got != want {
+		t.Errorf("second blocked client got %q, want %q", got, want)
+	}
+}
+
+func TestApplyCmdContext_FIFOOnArrival(t *testing.T) {
+	node := newTestNode(t)
+	first := blockOn(t, node, "queue")
+
+	// An element which arrives without waking anyone, as from a snapshot, is found by the first client's recheck.
+	// A client arriving in the meantime must not take it.
+	list := datatypes.NewList()
+	list.RPush("a")
+	node.mu.Lock()
+	node.m["queue"] = list
+	node.mu.Unlock()
+
+	second := blockOn(t, node, "queue")
+	if got, want := receive(t, first, second), (&commands.ListResponse{Values: []string{"queue", "a"}}).String(); got != want {
+		t.Errorf("first blocked client got %q, want %q", got, want)
+	}
+}
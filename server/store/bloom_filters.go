@@ -0,0 +1,152 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+const (
+	defaultBloomFilterCapacity  = 100
+	defaultBloomFilterErrorRate = 0.01
+)
+
+func (node *RaftNode) BFReserve(cmd *commands.BFReserveCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) BFAdd(cmd *commands.BFAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) BFExists(cmd *commands.BFExistsCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bf, err := node.findBloomFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if cmd.GetMessageType() == commands.BFExists {
+		return (&commands.BooleanResponse{Value: bf.Contains(cmd.Items[0])}).String()
+	}
+
+	results := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		results = append(results, strconv.FormatBool(bf.Contains(item)))
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+func (node *RaftNode) BFCard(cmd *commands.BFCardCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bf, err := node.findBloomFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: int(bf.Count())}).String()
+}
+
+func (node *RaftNode) BFInfo(cmd *commands.BFInfoCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bf, err := node.findBloomFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	switch cmd.Field {
+	case "CAPACITY":
+		return (&commands.CountResponse{Count: int(bf.Capacity())}).String()
+	case "SIZE":
+		return (&commands.CountResponse{Count: int(bf.Size())}).String()
+	case "HASHES":
+		return (&commands.CountResponse{Count: int(bf.NumHashes())}).String()
+	case "FILTERS":
+		return (&commands.CountResponse{Count: int(bf.NumFilters())}).String()
+	case "ITEMS":
+		return (&commands.CountResponse{Count: int(bf.Count())}).String()
+	case "EXPANSION":
+		return (&commands.CountResponse{Count: int(bf.Expansion())}).String()
+	default:
+		return (&commands.ListResponse{Values: []string{
+			"Capacity", strconv.FormatUint(uint64(bf.Capacity()), 10),
+			"Size", strconv.FormatUint(uint64(bf.Size()), 10),
+			"Number of hash functions", strconv.FormatUint(uint64(bf.NumHashes()), 10),
+			"Number of filters", strconv.FormatUint(uint64(bf.NumFilters()), 10),
+			"Number of items inserted", strconv.FormatUint(uint64(bf.Count()), 10),
+			"Expansion rate", strconv.FormatUint(uint64(bf.Expansion()), 10),
+		}}).String()
+	}
+}
+
+func (node *RaftNode) applyBFReserve(cmd *commands.BFReserveCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	expansion := cmd.Expansion
+	if expansion == 0 {
+		expansion = datatypes.DefaultBloomExpansion
+	}
+	if cmd.NonScaling {
+		expansion = 0
+	}
+
+	node.m[cmd.Key] = datatypes.NewBloomFilterWithExpansion(cmd.Capacity, cmd.ErrorRate, expansion)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) applyBFAdd(cmd *commands.BFAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	bf, err := node.findBloomFilter(cmd.Key)
+	if err == commands.ErrorNotFound {
+		bf = datatypes.NewBloomFilter(defaultBloomFilterCapacity, defaultBloomFilterErrorRate)
+		node.m[cmd.Key] = bf
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if cmd.GetMessageType() == commands.BFAdd {
+		added, err := bf.Add(cmd.Items[0])
+		if err != nil {
+			return (&commands.ErrorResponse{Err: commands.ErrorFilterFull}).String()
+		}
+		return (&commands.BooleanResponse{Value: added}).String()
+	}
+
+	// Once the filter is full, each of the remaining items is answered with the error instead.
+	results := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		added, err := bf.Add(item)
+		if err != nil {
+			results = append(results, (&commands.ErrorResponse{Err: commands.ErrorFilterFull}).String())
+			continue
+		}
+		results = append(results, strconv.FormatBool(added))
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+func (node *RaftNode) findBloomFilter(key string) (*datatypes.BloomFilter, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "bloom":
+			bf := val.(*datatypes.BloomFilter)
+			return bf, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
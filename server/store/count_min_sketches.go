@@ -0,0 +1,149 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"math"
+	"strconv"
+)
+
+func (node *RaftNode) CMSInitByDim(cmd *commands.CMSInitByDimCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CMSInitByProb(cmd *commands.CMSInitByProbCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CMSIncrBy(cmd *commands.CMSIncrByCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CMSMerge(cmd *commands.CMSMergeCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CMSQuery(cmd *commands.CMSQueryCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cms, err := node.findCountMinSketch(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	counts := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		counts = append(counts, strconv.FormatUint(cms.Query(item), 10))
+	}
+	return (&commands.ListResponse{Values: counts}).String()
+}
+
+func (node *RaftNode) CMSInfo(cmd *commands.CMSInfoCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cms, err := node.findCountMinSketch(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	return (&commands.ListResponse{Values: []string{
+		"width", strconv.FormatUint(uint64(cms.Width()), 10),
+		"depth", strconv.FormatUint(uint64(cms.Depth()), 10),
+		"count", strconv.FormatUint(cms.Count(), 10),
+	}}).String()
+}
+
+func (node *RaftNode) applyCMSInitByDim(cmd *commands.CMSInitByDimCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	node.m[cmd.Key] = datatypes.NewCountMinSketch(cmd.Width, cmd.Depth)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) applyCMSInitByProb(cmd *commands.CMSInitByProbCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	node.m[cmd.Key] = datatypes.NewCountMinSketchForError(cmd.ErrorRate, cmd.Probability)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyCMSIncrBy responds with the new estimated count of each item.
+func (node *RaftNode) applyCMSIncrBy(cmd *commands.CMSIncrByCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cms, err := node.findCountMinSketch(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	// The total is checked up front, so that an overflow leaves every count unchanged.
+	room := math.MaxUint64 - cms.Count()
+	for _, incr := range cmd.Increments {
+		if incr.Increment > room {
+			return (&commands.ErrorResponse{Err: datatypes.ErrOverflow}).String()
+		}
+		room -= incr.Increment
+	}
+
+	counts := make([]string, 0, len(cmd.Increments))
+	for _, incr := range cmd.Increments {
+		count, err := cms.IncrBy(incr.Item, incr.Increment)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		counts = append(counts, strconv.FormatUint(count, 10))
+	}
+	return (&commands.ListResponse{Values: counts}).String()
+}
+
+// applyCMSMerge replaces the counts of the destination, which must already exist, with the weighted sum of the sources.
+func (node *RaftNode) applyCMSMerge(cmd *commands.CMSMergeCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	dest, err := node.findCountMinSketch(cmd.DestKey)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	sources := make([]*datatypes.CountMinSketch, 0, len(cmd.SourceKeys))
+	for _, key := range cmd.SourceKeys {
+		cms, err := node.findCountMinSketch(key)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		sources = append(sources, cms)
+	}
+
+	if err := dest.Merge(sources, cmd.Weights); err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) findCountMinSketch(key string) (*datatypes.CountMinSketch, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "cms":
+			cms := val.(*datatypes.CountMinSketch)
+			return cms, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
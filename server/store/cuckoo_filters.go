@@ -0,0 +1,136 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+const defaultCuckooFilterCapacity = 1024
+
+func (node *RaftNode) CFReserve(cmd *commands.CFReserveCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CFAdd(cmd *commands.CFAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CFDel(cmd *commands.CFDelCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) CFExists(cmd *commands.CFExistsCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cf, err := node.findCuckooFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.BooleanResponse{Value: cf.Lookup(cmd.Item)}).String()
+}
+
+func (node *RaftNode) CFCount(cmd *commands.CFCountCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cf, err := node.findCuckooFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: cf.Count(cmd.Item)}).String()
+}
+
+func (node *RaftNode) CFInfo(cmd *commands.CFInfoCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cf, err := node.findCuckooFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	return (&commands.ListResponse{Values: []string{
+		"Size", strconv.FormatUint(uint64(cf.NumBuckets()*cf.BucketSize()*cf.FingerprintSize()), 10),
+		"Number of buckets", strconv.FormatUint(uint64(cf.NumBuckets()), 10),
+		"Number of items inserted", strconv.FormatUint(uint64(cf.Size()), 10),
+		"Number of items deleted", strconv.FormatUint(uint64(cf.Deleted()), 10),
+		"Bucket size", strconv.FormatUint(uint64(cf.BucketSize()), 10),
+		"Fingerprint size", strconv.FormatUint(uint64(cf.FingerprintSize()), 10),
+		"Max iterations", strconv.FormatUint(uint64(cf.MaxKicks()), 10),
+	}}).String()
+}
+
+func (node *RaftNode) applyCFReserve(cmd *commands.CFReserveCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	bucketSize := cmd.BucketSize
+	if bucketSize == 0 {
+		bucketSize = datatypes.DefaultCuckooBucketSize
+	}
+	fingerprintSize := cmd.FingerprintSize
+	if fingerprintSize == 0 {
+		fingerprintSize = datatypes.DefaultCuckooFingerprintSize
+	}
+
+	cf := datatypes.NewCuckooFilterWithCapacity(cmd.Capacity, bucketSize, fingerprintSize)
+	if cmd.MaxIterations != 0 {
+		cf.SetMaxKicks(cmd.MaxIterations)
+	}
+
+	node.m[cmd.Key] = cf
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) applyCFAdd(cmd *commands.CFAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cf, err := node.findCuckooFilter(cmd.Key)
+	if err == commands.ErrorNotFound {
+		cf = datatypes.NewCuckooFilterWithCapacity(defaultCuckooFilterCapacity, datatypes.DefaultCuckooBucketSize, datatypes.DefaultCuckooFingerprintSize)
+		node.m[cmd.Key] = cf
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if cmd.GetMessageType() == commands.CFAddNX && cf.Lookup(cmd.Item) {
+		return (&commands.BooleanResponse{Value: false}).String()
+	}
+
+	if !cf.Insert(cmd.Item) {
+		return (&commands.ErrorResponse{Err: commands.ErrorFilterFull}).String()
+	}
+	return (&commands.BooleanResponse{Value: true}).String()
+}
+
+func (node *RaftNode) applyCFDel(cmd *commands.CFDelCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	cf, err := node.findCuckooFilter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.BooleanResponse{Value: cf.Delete(cmd.Item)}).String()
+}
+
+func (node *RaftNode) findCuckooFilter(key string) (*datatypes.CuckooFilter, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "cuckoo":
+			cf := val.(*datatypes.CuckooFilter)
+			return cf, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) GeoAdd(cmd *commands.GeoAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) GeoPos(cmd *commands.GeoMembersCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	positions := make([]string, 0, len(cmd.Members))
+	for _, member := range cmd.Members {
+		score, ok := zset.GetScore(member)
+		if !ok {
+			positions = append(positions, "nil")
+			continue
+		}
+		point := datatypes.GeoPointFromScore(score)
+		positions = append(positions, commands.FormatFloat(point.Longitude)+" "+commands.FormatFloat(point.Latitude))
+	}
+	return (&commands.ListResponse{Values: positions}).String()
+}
+
+func (node *RaftNode) GeoHash(cmd *commands.GeoMembersCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	hashes := make([]string, 0, len(cmd.Members))
+	for _, member := range cmd.Members {
+		score, ok := zset.GetScore(member)
+		if !ok {
+			hashes = append(hashes, "nil")
+			continue
+		}
+		hashes = append(hashes, datatypes.GeoHashString(score))
+	}
+	return (&commands.ListResponse{Values: hashes}).String()
+}
+
+func (node *RaftNode) GeoDist(cmd *commands.GeoDistCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	score1, ok1 := zset.GetScore(cmd.Member1)
+	score2, ok2 := zset.GetScore(cmd.Member2)
+	if !ok1 || !ok2 {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+
+	distance := datatypes.GeoDistance(datatypes.GeoPointFromScore(score1), datatypes.GeoPointFromScore(score2))
+	return (&commands.FloatResponse{Value: roundGeoDistance(distance / cmd.Unit)}).String()
+}
+
+func (node *RaftNode) GeoSearch(cmd *commands.GeoSearchCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	center := datatypes.GeoPoint{Longitude: cmd.Longitude, Latitude: cmd.Latitude}
+	if !cmd.FromLonLat {
+		score, ok := zset.GetScore(cmd.FromMember)
+		if !ok {
+			return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+		}
+		center = datatypes.GeoPointFromScore(score)
+	} else if !datatypes.IsValidGeoPoint(center) {
+		return (&commands.ErrorResponse{Err: commands.ErrorInvalidCoordinates}).String()
+	}
+
+	matches := zset.GeoSearch(datatypes.GeoQuery{
+		Center: center,
+		Radius: cmd.Radius,
+		Width:  cmd.Width,
+		Height: cmd.Height,
+	})
+
+	if cmd.Desc {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+	if cmd.Count > 0 && cmd.Count < len(matches) {
+		matches = matches[:cmd.Count]
+	}
+
+	values := make([]string, 0, len(matches))
+	for _, match := range matches {
+		values = append(values, match.Name)
+		if cmd.WithDist {
+			values = append(values, strconv.FormatFloat(match.Distance/cmd.Unit, 'f', 4, 64))
+		}
+		if cmd.WithHash {
+			values = append(values, strconv.FormatUint(uint64(match.Score), 10))
+		}
+		if cmd.WithCoord {
+			values = append(values, commands.FormatFloat(match.Point.Longitude), commands.FormatFloat(match.Point.Latitude))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyGeoAdd(cmd *commands.GeoAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	for _, location := range cmd.Locations {
+		point := datatypes.GeoPoint{Longitude: location.Longitude, Latitude: location.Latitude}
+		if !datatypes.IsValidGeoPoint(point) {
+			return (&commands.ErrorResponse{Err: commands.ErrorInvalidCoordinates}).String()
+		}
+	}
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err == commands.ErrorNotFound {
+		zset = datatypes.NewSortedSet()
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var count int
+	for _, location := range cmd.Locations {
+		current, exists := zset.GetScore(location.Member)
+		if (cmd.NX && exists) || (cmd.XX && !exists) {
+			continue
+		}
+
+		score := datatypes.GeoScore(datatypes.GeoPoint{Longitude: location.Longitude, Latitude: location.Latitude})
+		zset.Add(location.Member, score)
+		if !exists || (cmd.CH && score != current) {
+			count++
+		}
+	}
+
+	if zset.Card() > 0 {
+		node.m[cmd.Key] = zset
+	}
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+// roundGeoDistance rounds a distance to four decimal places, the precision of distances in geo responses.
+func roundGeoDistance(distance float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(distance, 'f', 4, 64), 64)
+	return rounded
+}
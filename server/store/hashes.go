@@ -0,0 +1,235 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) HSet(cmd *commands.HSetCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) HSetNX(cmd *commands.HSetNXCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) HDel(cmd *commands.HFieldsCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) HIncrBy(cmd *commands.HIncrByCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) HIncrByFloat(cmd *commands.HIncrByFloatCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// HField serves HGET and HEXISTS.
+func (node *RaftNode) HField(cmd *commands.HFieldCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, ok := hash.Get(cmd.Field)
+	if cmd.GetMessageType() == commands.HExists {
+		return (&commands.BooleanResponse{Value: ok}).String()
+	}
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	return (&commands.StringResponse{Value: value}).String()
+}
+
+func (node *RaftNode) HMGet(cmd *commands.HFieldsCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	values := make([]string, 0, len(cmd.Fields))
+	for _, field := range cmd.Fields {
+		if value, ok := hash.Get(field); ok {
+			values = append(values, value)
+		} else {
+			values = append(values, "nil")
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// HKey serves HLEN, HKEYS, HVALS and HGETALL. Fields are listed in sorted order.
+func (node *RaftNode) HKey(cmd *commands.HKeyCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if cmd.GetMessageType() == commands.HLen {
+		return (&commands.CountResponse{Count: hash.Len()}).String()
+	}
+
+	keys := hash.Keys()
+	if cmd.GetMessageType() == commands.HKeys {
+		return (&commands.ListResponse{Values: keys}).String()
+	}
+
+	values := make([]string, 0, 2*len(keys))
+	for _, field := range keys {
+		value, _ := hash.Get(field)
+		if cmd.GetMessageType() == commands.HGetAll {
+			values = append(values, field)
+		}
+		values = append(values, value)
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// HScan responds with the cursor to continue the scan from, followed by the fields and values found.
+func (node *RaftNode) HScan(cmd *commands.HScanCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	fields, cursor := hash.Scan(cmd.Cursor, cmd.Pattern, cmd.Count)
+
+	values := make([]string, 0, 1+2*len(fields))
+	values = append(values, strconv.FormatUint(cursor, 10))
+	for _, field := range fields {
+		value, _ := hash.Get(field)
+		values = append(values, field, value)
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyHSet(cmd *commands.HSetCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findOrCreateHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var count int
+	for _, f := range cmd.Fields {
+		if hash.Set(f.Field, f.Value) {
+			count++
+		}
+	}
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+func (node *RaftNode) applyHSetNX(cmd *commands.HSetNXCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findOrCreateHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if _, ok := hash.Get(cmd.Field); ok {
+		return (&commands.BooleanResponse{Value: false}).String()
+	}
+	hash.Set(cmd.Field, cmd.Value)
+	node.reindex(cmd.Key)
+	return (&commands.BooleanResponse{Value: true}).String()
+}
+
+func (node *RaftNode) applyHDel(cmd *commands.HFieldsCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var count int
+	for _, field := range cmd.Fields {
+		if hash.Delete(field) {
+			count++
+		}
+	}
+	if hash.Len() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+func (node *RaftNode) applyHIncrBy(cmd *commands.HIncrByCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findOrCreateHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, err := hash.IncrBy(cmd.Field, cmd.Increment)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: int(value)}).String()
+}
+
+func (node *RaftNode) applyHIncrByFloat(cmd *commands.HIncrByFloatCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hash, err := node.findOrCreateHash(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, err := hash.IncrByFloat(cmd.Field, cmd.Increment)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.reindex(cmd.Key)
+	return (&commands.FloatResponse{Value: value}).String()
+}
+
+func (node *RaftNode) findHash(key string) (*datatypes.Hash, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "hash":
+			hash := val.(*datatypes.Hash)
+			return hash, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
+
+// findOrCreateHash returns the hash stored at key, storing a new empty hash there if the key is missing.
+func (node *RaftNode) findOrCreateHash(key string) (*datatypes.Hash, error) {
+	hash, err := node.findHash(key)
+	if err == commands.ErrorNotFound {
+		hash = datatypes.NewHash()
+		node.m[key] = hash
+		return hash, nil
+	}
+	return hash, err
+}
@@ -9,20 +9,33 @@ func (node *RaftNode) PFAdd(cmd *commands.PFAddCommand) string {
 	return node.respondAfterRaftCommit(cmd)
 }
 
+func (node *RaftNode) PFMerge(cmd *commands.PFMergeCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// PFCount returns the cardinality of the union of the given keys, merging them into a
+// temporary HyperLogLog so that none of them is modified. Missing keys count as empty,
+// but at least one of the keys must exist.
 func (node *RaftNode) PFCount(cmd *commands.PFCountCommand) string {
 	node.mu.Lock()
 	defer node.mu.Unlock()
-	if val, ok := node.m[cmd.Key]; ok {
-		switch val.GetName() {
-		case "hll":
-			hll := val.(*datatypes.HyperLogLog)
-			return (&commands.CountResponse{Count: int(hll.Estimate())}).String()
-		default:
-			return (&commands.ErrorResponse{Err: commands.ErrorInvalidDataType}).String()
-		}
-	} else {
+
+	hlls, err := node.findHyperLogLogs(cmd.Keys)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	if len(hlls) == 0 {
 		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
 	}
+	if len(hlls) == 1 {
+		return (&commands.CountResponse{Count: int(hlls[0].Estimate())}).String()
+	}
+
+	union, err := datatypes.MergeArrayIntoNew(hlls)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: int(union.Estimate())}).String()
 }
 
 func (node *RaftNode) applyPFAdd(cmd *commands.PFAddCommand) interface{} {
@@ -44,3 +57,41 @@ func (node *RaftNode) applyPFAdd(cmd *commands.PFAddCommand) interface{} {
 		return (&commands.CountResponse{Count: count}).String()
 	}
 }
+
+// applyPFMerge stores the union of the destination and source keys in the destination key.
+// Missing keys count as empty, and the destination is created if none of the keys exist.
+func (node *RaftNode) applyPFMerge(cmd *commands.PFMergeCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	hlls, err := node.findHyperLogLogs(append([]string{cmd.DestKey}, cmd.SourceKeys...))
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	if len(hlls) == 0 {
+		hlls = append(hlls, datatypes.NewHllWithErrorRate(0.6))
+	}
+
+	merged, err := datatypes.MergeArrayIntoNew(hlls)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.m[cmd.DestKey] = merged
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// findHyperLogLogs returns the HyperLogLogs stored at the keys which exist.
+func (node *RaftNode) findHyperLogLogs(keys []string) ([]*datatypes.HyperLogLog, error) {
+	hlls := make([]*datatypes.HyperLogLog, 0, len(keys))
+	for _, key := range keys {
+		if val, ok := node.m[key]; ok {
+			switch val.GetName() {
+			case "hll":
+				hlls = append(hlls, val.(*datatypes.HyperLogLog))
+			default:
+				return nil, commands.ErrorInvalidDataType
+			}
+		}
+	}
+	return hlls, nil
+}
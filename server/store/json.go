@@ -0,0 +1,214 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) JSONSet(cmd *commands.JSONSetCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) JSONDel(cmd *commands.JSONPathCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) JSONArrAppend(cmd *commands.JSONArrAppendCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) JSONNumIncrBy(cmd *commands.JSONNumIncrByCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// JSONGet responds with the values matching the path as a JSON array, or with an object
+// of each path to its array of values if several paths are given.
+func (node *RaftNode) JSONGet(cmd *commands.JSONGetCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	data, err := doc.Get(cmd.Paths...)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.StringResponse{Value: string(data)}).String()
+}
+
+func (node *RaftNode) JSONType(cmd *commands.JSONPathCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	types, err := doc.Type(cmd.Path)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.ListResponse{Values: types}).String()
+}
+
+// JSONObjKeys responds with the member names of each object matching the path as a JSON array,
+// or nil for matches which are not objects.
+func (node *RaftNode) JSONObjKeys(cmd *commands.JSONPathCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	keys, err := doc.ObjKeys(cmd.Path)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	values := make([]string, 0, len(keys))
+	for _, names := range keys {
+		if names == nil {
+			values = append(values, "nil")
+			continue
+		}
+		data, err := json.Marshal(names)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		values = append(values, string(data))
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// applyJSONSet creates the document if the key is missing and the path is the root,
+// and otherwise updates the existing document in place.
+func (node *RaftNode) applyJSONSet(cmd *commands.JSONSetCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err == commands.ErrorNotFound && datatypes.IsJSONRoot(cmd.Path) {
+		if cmd.XX {
+			return (&commands.BooleanResponse{Value: false}).String()
+		}
+
+		doc, err := datatypes.NewJSON([]byte(cmd.Value))
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		node.m[cmd.Key] = doc
+		node.reindex(cmd.Key)
+		return (&commands.BooleanResponse{Value: true}).String()
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	ok, err := doc.Set(cmd.Path, []byte(cmd.Value), cmd.NX, cmd.XX)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.reindex(cmd.Key)
+	return (&commands.BooleanResponse{Value: ok}).String()
+}
+
+// applyJSONDel removes the values matching the path, or the whole key if the path is the root.
+func (node *RaftNode) applyJSONDel(cmd *commands.JSONPathCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err == commands.ErrorNotFound {
+		return (&commands.CountResponse{Count: 0}).String()
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if datatypes.IsJSONRoot(cmd.Path) {
+		delete(node.m, cmd.Key)
+		node.reindex(cmd.Key)
+		return (&commands.CountResponse{Count: 1}).String()
+	}
+
+	count, err := doc.Delete(cmd.Path)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+// applyJSONArrAppend responds with the new length of each array matching the path, or nil for matches which are not arrays.
+func (node *RaftNode) applyJSONArrAppend(cmd *commands.JSONArrAppendCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	data := make([][]byte, 0, len(cmd.Values))
+	for _, value := range cmd.Values {
+		data = append(data, []byte(value))
+	}
+
+	lengths, err := doc.ArrAppend(cmd.Path, data)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	node.reindex(cmd.Key)
+
+	values := make([]string, 0, len(lengths))
+	for _, length := range lengths {
+		if length < 0 {
+			values = append(values, "nil")
+		} else {
+			values = append(values, strconv.Itoa(length))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// applyJSONNumIncrBy responds with the new value of each number matching the path as a JSON array,
+// with null for matches which are not numbers.
+func (node *RaftNode) applyJSONNumIncrBy(cmd *commands.JSONNumIncrByCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	doc, err := node.findJSON(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	data, err := doc.NumIncrBy(cmd.Path, cmd.Increment)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.reindex(cmd.Key)
+	return (&commands.StringResponse{Value: string(data)}).String()
+}
+
+func (node *RaftNode) findJSON(key string) (*datatypes.JSON, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "json":
+			doc := val.(*datatypes.JSON)
+			return doc, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
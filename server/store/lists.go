@@ -3,6 +3,7 @@ package store
 import (
 	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
 )
 
 func (node *RaftNode) LLen(cmd *commands.LLenCommand) string {
@@ -29,11 +30,7 @@ func (node *RaftNode) LRange(cmd *commands.LRangeCommand) string {
 		switch val.GetName() {
 		case "list":
 			listVal := val.(*datatypes.List)
-			lrange, err := listVal.LRange(cmd.Start, cmd.End)
-			if err != nil {
-				return (&commands.ErrorResponse{Err: err}).String()
-			}
-			response := &commands.ListResponse{Values: lrange}
+			response := &commands.ListResponse{Values: listVal.LRange(cmd.Start, cmd.End)}
 			return response.String()
 		default:
 			return (&commands.ErrorResponse{Err: commands.ErrorInvalidDataType}).String()
@@ -59,6 +56,207 @@ func (node *RaftNode) LPop(cmd *commands.LPopCommand) string {
 	return node.respondAfterRaftCommit(cmd)
 }
 
+func (node *RaftNode) LIndex(cmd *commands.LIndexCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, ok := list.LIndex(cmd.Index)
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	return (&commands.StringResponse{Value: value}).String()
+}
+
+// LPos responds with the index of the first match, or a list of the indices of the matches if given a count.
+func (node *RaftNode) LPos(cmd *commands.LPosCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	positions := list.LPos(cmd.Value, cmd.Rank, cmd.Count, cmd.MaxLen)
+	if !cmd.WithCount {
+		if len(positions) == 0 {
+			return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+		}
+		return (&commands.CountResponse{Count: positions[0]}).String()
+	}
+
+	values := make([]string, 0, len(positions))
+	for _, position := range positions {
+		values = append(values, strconv.Itoa(position))
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) LSet(cmd *commands.LSetCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) LInsert(cmd *commands.LInsertCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) LRem(cmd *commands.LRemCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) LTrim(cmd *commands.LTrimCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// LMove serves LMOVE, BLMOVE and RPOPLPUSH, trying BLMOVE once. ApplyCmdContext is what blocks.
+func (node *RaftNode) LMove(cmd *commands.LMoveCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// applyPushX pushes onto a list only if it already exists, responding like LPUSH and RPUSH, or with zero if it doesn't.
+func (node *RaftNode) applyPushX(key string, values []string, left bool) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(key)
+	if err == commands.ErrorNotFound {
+		return (&commands.CountResponse{Count: 0}).String()
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if left {
+		list.LPushAll(values)
+	} else {
+		list.RPushAll(values)
+	}
+	node.waiters.wake(key, len(values))
+	return (&commands.CountResponse{Count: len(values)}).String()
+}
+
+func (node *RaftNode) applyLSet(cmd *commands.LSetCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if err := list.LSet(cmd.Index, cmd.Value); err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyLInsert responds with the new length of the list, or -1 if the pivot isn't in it.
+func (node *RaftNode) applyLInsert(cmd *commands.LInsertCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	n := list.LInsert(cmd.Before, cmd.Pivot, cmd.Value)
+	if n > 0 {
+		node.waiters.wake(cmd.Key, 1)
+	}
+	return (&commands.CountResponse{Count: n}).String()
+}
+
+func (node *RaftNode) applyLRem(cmd *commands.LRemCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	removed := list.LRem(cmd.Count, cmd.Value)
+	if list.LLen() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return (&commands.CountResponse{Count: removed}).String()
+}
+
+func (node *RaftNode) applyLTrim(cmd *commands.LTrimCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	list, err := node.findList(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	list.LTrim(cmd.Start, cmd.End)
+	if list.LLen() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyLMove pops an element from the source and pushes it onto the destination, creating it if it is missing,
+// and responds with the element. The source and destination can be the same list, to rotate it.
+func (node *RaftNode) applyLMove(cmd *commands.LMoveCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	source, err := node.findList(cmd.Source)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	destination, err := node.findList(cmd.Destination)
+	if err == commands.ErrorNotFound {
+		destination = datatypes.NewList()
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var value string
+	var ok bool
+	if cmd.FromLeft {
+		value, ok = source.LPop()
+	} else {
+		value, ok = source.RPop()
+	}
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+
+	if cmd.ToLeft {
+		destination.LPush(value)
+	} else {
+		destination.RPush(value)
+	}
+	node.m[cmd.Destination] = destination
+	if source.LLen() == 0 {
+		delete(node.m, cmd.Source)
+	}
+	node.waiters.wake(cmd.Destination, 1)
+	return (&commands.StringResponse{Value: value}).String()
+}
+
+func (node *RaftNode) findList(key string) (*datatypes.List, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "list":
+			return val.(*datatypes.List), nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
+
 func (node *RaftNode) applyLPush(cmd *commands.LPushCommand) interface{} {
 	node.mu.Lock()
 	defer node.mu.Unlock()
@@ -67,6 +265,7 @@ func (node *RaftNode) applyLPush(cmd *commands.LPushCommand) interface{} {
 		case "list":
 			listVal := val.(*datatypes.List)
 			listVal.LPushAll(cmd.Values)
+			node.waiters.wake(cmd.Key, len(cmd.Values))
 			return (&commands.CountResponse{Count: len(cmd.Values)}).String()
 		default:
 			return (&commands.ErrorResponse{Err: commands.ErrorInvalidDataType}).String()
@@ -75,6 +274,7 @@ func (node *RaftNode) applyLPush(cmd *commands.LPushCommand) interface{} {
 		list := datatypes.NewList()
 		list.LPushAll(cmd.Values)
 		node.m[cmd.Key] = list
+		node.waiters.wake(cmd.Key, len(cmd.Values))
 		return (&commands.CountResponse{Count: len(cmd.Values)}).String()
 	}
 }
@@ -87,6 +287,7 @@ func (node *RaftNode) applyRPush(cmd *commands.RPushCommand) interface{} {
 		case "list":
 			listVal := val.(*datatypes.List)
 			listVal.RPushAll(cmd.Values)
+			node.waiters.wake(cmd.Key, len(cmd.Values))
 			return (&commands.CountResponse{Count: len(cmd.Values)}).String()
 		default:
 			return (&commands.ErrorResponse{Err: commands.ErrorInvalidDataType}).String()
@@ -95,6 +296,7 @@ func (node *RaftNode) applyRPush(cmd *commands.RPushCommand) interface{} {
 		list := datatypes.NewList()
 		list.RPushAll(cmd.Values)
 		node.m[cmd.Key] = list
+		node.waiters.wake(cmd.Key, len(cmd.Values))
 		return (&commands.CountResponse{Count: len(cmd.Values)}).String()
 	}
 }
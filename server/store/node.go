@@ -7,6 +7,7 @@ import (
 	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/bbolt"
 	"github.com/c16a/pouch/server/datatypes"
+	"github.com/c16a/pouch/server/search"
 	"github.com/google/uuid"
 	"github.com/hashicorp/raft"
 	"go.uber.org/zap"
@@ -15,6 +16,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -24,8 +26,10 @@ type RaftNode struct {
 	RaftDir  string
 	RaftBind string
 
-	mu sync.Mutex
-	m  map[string]datatypes.Type // The key-value store for the system.
+	mu      sync.Mutex
+	m       map[string]datatypes.Type // The key-value store for the system.
+	indexes map[string]*search.Index  // The indexes over the key-value store, by name.
+	waiters waitQueues                // The commands blocked on keys of this node's clients.
 
 	raft *raft.Raft // The consensus mechanism
 
@@ -62,6 +66,7 @@ func NewRaftNode(config *NodeConfig, logger *zap.Logger) *RaftNode {
 		RaftDir:  raftPath,
 		RaftBind: raftAddr,
 		m:        make(map[string]datatypes.Type),
+		indexes:  make(map[string]*search.Index),
 		logger:   logger,
 		Config:   config,
 	}
@@ -135,13 +140,33 @@ func (node *RaftNode) ApplyCmd(cmd commands.Command) string {
 	switch cmd.GetMessageType() {
 	case commands.Get:
 		return node.Get(cmd.(*commands.GetCommand))
-	case commands.Set:
+	case commands.Set, commands.SetNX, commands.GetSet:
 		return node.Set(cmd.(*commands.SetCommand))
+	case commands.GetDel:
+		return node.GetDel(cmd.(*commands.StringKeyCommand))
+	case commands.StrLen:
+		return node.StrLen(cmd.(*commands.StringKeyCommand))
+	case commands.MSet, commands.MSetNX:
+		return node.MSet(cmd.(*commands.MSetCommand))
+	case commands.MGet:
+		return node.MGet(cmd.(*commands.MGetCommand))
+	case commands.Append:
+		return node.Append(cmd.(*commands.AppendCommand))
+	case commands.GetRange:
+		return node.GetRange(cmd.(*commands.GetRangeCommand))
+	case commands.SetRange:
+		return node.SetRange(cmd.(*commands.SetRangeCommand))
 	case commands.Del:
 		return node.Delete(cmd.(*commands.DelCommand))
-	case commands.LPush:
+	case commands.Incr, commands.Decr, commands.IncrBy, commands.DecrBy:
+		return node.Incr(cmd.(*commands.IncrCommand))
+	case commands.IncrByFloat:
+		return node.IncrByFloat(cmd.(*commands.IncrByFloatCommand))
+	case commands.Throttle:
+		return node.Throttle(cmd.(*commands.ThrottleCommand))
+	case commands.LPush, commands.LPushX:
 		return node.LPush(cmd.(*commands.LPushCommand))
-	case commands.RPush:
+	case commands.RPush, commands.RPushX:
 		return node.RPush(cmd.(*commands.RPushCommand))
 	case commands.LLen:
 		return node.LLen(cmd.(*commands.LLenCommand))
@@ -151,6 +176,22 @@ func (node *RaftNode) ApplyCmd(cmd commands.Command) string {
 		return node.LPop(cmd.(*commands.LPopCommand))
 	case commands.LRange:
 		return node.LRange(cmd.(*commands.LRangeCommand))
+	case commands.LIndex:
+		return node.LIndex(cmd.(*commands.LIndexCommand))
+	case commands.LPos:
+		return node.LPos(cmd.(*commands.LPosCommand))
+	case commands.LSet:
+		return node.LSet(cmd.(*commands.LSetCommand))
+	case commands.LInsert:
+		return node.LInsert(cmd.(*commands.LInsertCommand))
+	case commands.LRem:
+		return node.LRem(cmd.(*commands.LRemCommand))
+	case commands.LTrim:
+		return node.LTrim(cmd.(*commands.LTrimCommand))
+	case commands.LMove, commands.RPopLPush, commands.BLMove:
+		return node.LMove(cmd.(*commands.LMoveCommand))
+	case commands.BLPop, commands.BRPop:
+		return node.BPop(cmd.(*commands.BPopCommand))
 	case commands.SAdd:
 		return node.SAdd(cmd.(*commands.SAddCommand))
 	case commands.SCard:
@@ -165,10 +206,192 @@ func (node *RaftNode) ApplyCmd(cmd commands.Command) string {
 		return node.SDiff(cmd.(*commands.SDiffCommand))
 	case commands.SUnion:
 		return node.SUnion(cmd.(*commands.SUnionCommand))
+	case commands.SInterCard:
+		return node.SInterCard(cmd.(*commands.SInterCardCommand))
+	case commands.SMIsMember:
+		return node.SMIsMember(cmd.(*commands.SValuesCommand))
+	case commands.SRem:
+		return node.SRem(cmd.(*commands.SValuesCommand))
+	case commands.SPop:
+		return node.SPop(cmd.(*commands.SPopCommand))
+	case commands.SRandMember:
+		return node.SRandMember(cmd.(*commands.SPopCommand))
+	case commands.SMove:
+		return node.SMove(cmd.(*commands.SMoveCommand))
+	case commands.SInterStore, commands.SUnionStore, commands.SDiffStore:
+		return node.SStore(cmd.(*commands.SStoreCommand))
+	case commands.HSet:
+		return node.HSet(cmd.(*commands.HSetCommand))
+	case commands.HSetNX:
+		return node.HSetNX(cmd.(*commands.HSetNXCommand))
+	case commands.HGet, commands.HExists:
+		return node.HField(cmd.(*commands.HFieldCommand))
+	case commands.HMGet:
+		return node.HMGet(cmd.(*commands.HFieldsCommand))
+	case commands.HDel:
+		return node.HDel(cmd.(*commands.HFieldsCommand))
+	case commands.HLen, commands.HKeys, commands.HVals, commands.HGetAll:
+		return node.HKey(cmd.(*commands.HKeyCommand))
+	case commands.HIncrBy:
+		return node.HIncrBy(cmd.(*commands.HIncrByCommand))
+	case commands.HIncrByFloat:
+		return node.HIncrByFloat(cmd.(*commands.HIncrByFloatCommand))
+	case commands.HScan:
+		return node.HScan(cmd.(*commands.HScanCommand))
+	case commands.ZAdd:
+		return node.ZAdd(cmd.(*commands.ZAddCommand))
+	case commands.ZRem:
+		return node.ZRem(cmd.(*commands.ZRemCommand))
+	case commands.ZIncrBy:
+		return node.ZIncrBy(cmd.(*commands.ZIncrByCommand))
+	case commands.ZScore:
+		return node.ZScore(cmd.(*commands.ZScoreCommand))
+	case commands.ZCard:
+		return node.ZCard(cmd.(*commands.ZCardCommand))
+	case commands.ZRank, commands.ZRevRank:
+		return node.ZRank(cmd.(*commands.ZRankCommand))
+	case commands.ZRange, commands.ZRevRange, commands.ZRangeByScore, commands.ZRevRangeByScore:
+		return node.ZRange(cmd.(*commands.ZRangeCommand))
+	case commands.GeoAdd:
+		return node.GeoAdd(cmd.(*commands.GeoAddCommand))
+	case commands.GeoPos:
+		return node.GeoPos(cmd.(*commands.GeoMembersCommand))
+	case commands.GeoHash:
+		return node.GeoHash(cmd.(*commands.GeoMembersCommand))
+	case commands.GeoDist:
+		return node.GeoDist(cmd.(*commands.GeoDistCommand))
+	case commands.GeoSearch:
+		return node.GeoSearch(cmd.(*commands.GeoSearchCommand))
+	case commands.SetBit:
+		return node.SetBit(cmd.(*commands.SetBitCommand))
+	case commands.GetBit:
+		return node.GetBit(cmd.(*commands.GetBitCommand))
+	case commands.BitCount:
+		return node.BitCount(cmd.(*commands.BitCountCommand))
+	case commands.BitPos:
+		return node.BitPos(cmd.(*commands.BitPosCommand))
+	case commands.BitOp:
+		return node.BitOp(cmd.(*commands.BitOpCommand))
+	case commands.BitField:
+		return node.BitField(cmd.(*commands.BitFieldCommand))
+	case commands.BFReserve:
+		return node.BFReserve(cmd.(*commands.BFReserveCommand))
+	case commands.BFAdd, commands.BFMAdd:
+		return node.BFAdd(cmd.(*commands.BFAddCommand))
+	case commands.BFExists, commands.BFMExists:
+		return node.BFExists(cmd.(*commands.BFExistsCommand))
+	case commands.BFCard:
+		return node.BFCard(cmd.(*commands.BFCardCommand))
+	case commands.BFInfo:
+		return node.BFInfo(cmd.(*commands.BFInfoCommand))
+	case commands.CFReserve:
+		return node.CFReserve(cmd.(*commands.CFReserveCommand))
+	case commands.CFAdd, commands.CFAddNX:
+		return node.CFAdd(cmd.(*commands.CFAddCommand))
+	case commands.CFDel:
+		return node.CFDel(cmd.(*commands.CFDelCommand))
+	case commands.CFExists:
+		return node.CFExists(cmd.(*commands.CFExistsCommand))
+	case commands.CFCount:
+		return node.CFCount(cmd.(*commands.CFCountCommand))
+	case commands.CFInfo:
+		return node.CFInfo(cmd.(*commands.CFInfoCommand))
+	case commands.CMSInitByDim:
+		return node.CMSInitByDim(cmd.(*commands.CMSInitByDimCommand))
+	case commands.CMSInitByProb:
+		return node.CMSInitByProb(cmd.(*commands.CMSInitByProbCommand))
+	case commands.CMSIncrBy:
+		return node.CMSIncrBy(cmd.(*commands.CMSIncrByCommand))
+	case commands.CMSQuery:
+		return node.CMSQuery(cmd.(*commands.CMSQueryCommand))
+	case commands.CMSMerge:
+		return node.CMSMerge(cmd.(*commands.CMSMergeCommand))
+	case commands.CMSInfo:
+		return node.CMSInfo(cmd.(*commands.CMSInfoCommand))
+	case commands.TopKReserve:
+		return node.TopKReserve(cmd.(*commands.TopKReserveCommand))
+	case commands.TopKAdd, commands.TopKIncrBy:
+		return node.TopKAdd(cmd.(*commands.TopKAddCommand))
+	case commands.TopKQuery, commands.TopKCount:
+		return node.TopKItems(cmd.(*commands.TopKItemsCommand))
+	case commands.TopKList:
+		return node.TopKList(cmd.(*commands.TopKListCommand))
+	case commands.TDigestCreate:
+		return node.TDigestCreate(cmd.(*commands.TDigestCreateCommand))
+	case commands.TDigestAdd:
+		return node.TDigestAdd(cmd.(*commands.TDigestAddCommand))
+	case commands.TDigestMerge:
+		return node.TDigestMerge(cmd.(*commands.TDigestMergeCommand))
+	case commands.TDigestQuantile, commands.TDigestCDF:
+		return node.TDigestQuery(cmd.(*commands.TDigestQueryCommand))
+	case commands.TDigestMin, commands.TDigestMax, commands.TDigestInfo:
+		return node.TDigestKey(cmd.(*commands.TDigestKeyCommand))
+	case commands.TDigestTrimmedMean:
+		return node.TDigestTrimmedMean(cmd.(*commands.TDigestTrimmedMeanCommand))
+	case commands.TSCreate:
+		return node.TSCreate(cmd.(*commands.TSCreateCommand))
+	case commands.TSAdd:
+		return node.TSAdd(cmd.(*commands.TSAddCommand))
+	case commands.TSMAdd:
+		return node.TSMAdd(cmd.(*commands.TSMAddCommand))
+	case commands.TSGet:
+		return node.TSGet(cmd.(*commands.TSGetCommand))
+	case commands.TSRange, commands.TSRevRange:
+		return node.TSRange(cmd.(*commands.TSRangeCommand))
+	case commands.JSONSet:
+		return node.JSONSet(cmd.(*commands.JSONSetCommand))
+	case commands.JSONGet:
+		return node.JSONGet(cmd.(*commands.JSONGetCommand))
+	case commands.JSONDel:
+		return node.JSONDel(cmd.(*commands.JSONPathCommand))
+	case commands.JSONType:
+		return node.JSONType(cmd.(*commands.JSONPathCommand))
+	case commands.JSONObjKeys:
+		return node.JSONObjKeys(cmd.(*commands.JSONPathCommand))
+	case commands.JSONArrAppend:
+		return node.JSONArrAppend(cmd.(*commands.JSONArrAppendCommand))
+	case commands.JSONNumIncrBy:
+		return node.JSONNumIncrBy(cmd.(*commands.JSONNumIncrByCommand))
+	case commands.XAdd:
+		return node.XAdd(cmd.(*commands.XAddCommand))
+	case commands.XRange, commands.XRevRange:
+		return node.XRange(cmd.(*commands.XRangeCommand))
+	case commands.XLen:
+		return node.XLen(cmd.(*commands.XLenCommand))
+	case commands.XTrim:
+		return node.XTrim(cmd.(*commands.XTrimCommand))
+	case commands.XGroup:
+		return node.XGroup(cmd.(*commands.XGroupCommand))
+	case commands.XReadGroup:
+		return node.XReadGroup(cmd.(*commands.XReadGroupCommand))
+	case commands.XAck:
+		return node.XAck(cmd.(*commands.XAckCommand))
+	case commands.XPending:
+		return node.XPending(cmd.(*commands.XPendingCommand))
+	case commands.XClaim:
+		return node.XClaim(cmd.(*commands.XClaimCommand))
+	case commands.XAutoClaim:
+		return node.XAutoClaim(cmd.(*commands.XAutoClaimCommand))
+	case commands.VAdd:
+		return node.VAdd(cmd.(*commands.VAddCommand))
+	case commands.VRem:
+		return node.VRem(cmd.(*commands.VRemCommand))
+	case commands.VSim:
+		return node.VSim(cmd.(*commands.VSimCommand))
+	case commands.VCard:
+		return node.VCard(cmd.(*commands.VCardCommand))
+	case commands.FTCreate:
+		return node.FTCreate(cmd.(*commands.FTCreateCommand))
+	case commands.FTDropIndex:
+		return node.FTDropIndex(cmd.(*commands.FTDropIndexCommand))
+	case commands.FTSearch:
+		return node.FTSearch(cmd.(*commands.FTSearchCommand))
 	case commands.PFAdd:
 		return node.PFAdd(cmd.(*commands.PFAddCommand))
 	case commands.PFCount:
 		return node.PFCount(cmd.(*commands.PFCountCommand))
+	case commands.PFMerge:
+		return node.PFMerge(cmd.(*commands.PFMergeCommand))
 	default:
 		return (&commands.ErrorResponse{Err: commands.ErrInvalidCommand}).String()
 	}
@@ -212,7 +435,7 @@ func (node *RaftNode) respondAfterRaftCommit(cmd commands.Command) string {
 	return f.Response().(string)
 }
 
-// Set sets the value for the given key.
+// Set sets the value for the given key. It serves SET, SETNX and GETSET.
 func (node *RaftNode) Set(cmd *commands.SetCommand) string {
 	return node.respondAfterRaftCommit(cmd)
 }
@@ -222,7 +445,19 @@ func (node *RaftNode) Delete(cmd *commands.DelCommand) string {
 	return node.respondAfterRaftCommit(cmd)
 }
 
+const (
+	maxDatagramSize = 65507   // The largest UDP payload over IPv4, which bounds the commands and responses relayed to the leader
+	peerBufferSize  = 1 << 16 // Larger than any UDP datagram, so that reading one is never truncated
+)
+
+// sendToLeaderViaUdp relays a command to the leader in a single datagram, and returns its response. A command too
+// large for a datagram is refused with ErrorTooLarge rather than sent truncated.
 func (node *RaftNode) sendToLeaderViaUdp(cmd commands.Command, addr raft.ServerAddress, id raft.ServerID) (string, error) {
+	message := []byte(cmd.String())
+	if len(message) > maxDatagramSize {
+		return "", commands.ErrorTooLarge
+	}
+
 	udpAddr, err := net.ResolveUDPAddr("udp", string(addr))
 	if err != nil {
 		return "", err
@@ -235,17 +470,22 @@ func (node *RaftNode) sendToLeaderViaUdp(cmd commands.Command, addr raft.ServerA
 
 	defer conn.Close()
 
-	_, err = conn.Write([]byte(cmd.String()))
+	_, err = conn.Write(message)
 	if err != nil {
 		return "", err
 	}
 
-	var responseBytes = make([]byte, 1024)
-	_, err = conn.Read(responseBytes)
+	// A leader which can't answer, such as one which stepped down, would otherwise leave the client waiting forever.
+	if err := conn.SetReadDeadline(time.Now().Add(raftTimeout)); err != nil {
+		return "", err
+	}
+
+	var responseBytes = make([]byte, peerBufferSize)
+	n, err := conn.Read(responseBytes)
 	if err != nil {
 		return "", err
 	}
-	return string(responseBytes), nil
+	return string(responseBytes[:n]), nil
 }
 
 // Join joins a node, identified by nodeID and located at addr, to this store.
@@ -272,7 +512,7 @@ func (node *RaftNode) Join(nodeID, addr string) error {
 
 			future := node.raft.RemoveServer(srv.ID, 0, 0)
 			if err := future.Error(); err != nil {
-				return fmt.Errorf("error removing existing peer node", zap.String("node_id", nodeID), zap.String("addr", addr), zap.Error(err))
+				return fmt.Errorf("error removing existing peer node %s at %s: %w", nodeID, addr, err)
 			}
 		}
 	}
@@ -297,10 +537,24 @@ func (node *RaftNode) Apply(l *raft.Log) interface{} {
 	}
 
 	switch cmd.GetMessageType() {
-	case commands.Set:
+	case commands.Set, commands.SetNX, commands.GetSet:
 		return node.applySet(cmd.(*commands.SetCommand))
+	case commands.GetDel:
+		return node.applyGetDel(cmd.(*commands.StringKeyCommand))
+	case commands.MSet, commands.MSetNX:
+		return node.applyMSet(cmd.(*commands.MSetCommand))
+	case commands.Append:
+		return node.applyAppend(cmd.(*commands.AppendCommand))
+	case commands.SetRange:
+		return node.applySetRange(cmd.(*commands.SetRangeCommand))
 	case commands.Del:
 		return node.applyDelete(cmd.(*commands.DelCommand))
+	case commands.Incr, commands.Decr, commands.IncrBy, commands.DecrBy:
+		return node.applyIncr(cmd.(*commands.IncrCommand))
+	case commands.IncrByFloat:
+		return node.applyIncrByFloat(cmd.(*commands.IncrByFloatCommand))
+	case commands.Throttle:
+		return node.applyThrottle(cmd.(*commands.ThrottleCommand), appendedAt(l))
 	case commands.LPush:
 		return node.applyLPush(cmd.(*commands.LPushCommand))
 	case commands.RPush:
@@ -309,53 +563,213 @@ func (node *RaftNode) Apply(l *raft.Log) interface{} {
 		return node.applyLpop(cmd.(*commands.LPopCommand))
 	case commands.RPop:
 		return node.applyRpop(cmd.(*commands.RPopCommand))
+	case commands.LPushX:
+		push := cmd.(*commands.LPushCommand)
+		return node.applyPushX(push.Key, push.Values, true)
+	case commands.RPushX:
+		push := cmd.(*commands.RPushCommand)
+		return node.applyPushX(push.Key, push.Values, false)
+	case commands.LSet:
+		return node.applyLSet(cmd.(*commands.LSetCommand))
+	case commands.LInsert:
+		return node.applyLInsert(cmd.(*commands.LInsertCommand))
+	case commands.LRem:
+		return node.applyLRem(cmd.(*commands.LRemCommand))
+	case commands.LTrim:
+		return node.applyLTrim(cmd.(*commands.LTrimCommand))
+	case commands.LMove, commands.RPopLPush, commands.BLMove:
+		return node.applyLMove(cmd.(*commands.LMoveCommand))
+	case commands.BLPop, commands.BRPop:
+		return node.applyBPop(cmd.(*commands.BPopCommand))
 	case commands.SAdd:
 		return node.applySADD(cmd.(*commands.SAddCommand))
+	case commands.SRem:
+		return node.applySRem(cmd.(*commands.SValuesCommand))
+	case commands.SPop:
+		return node.applySPop(cmd.(*commands.SPopCommand), l.Index)
+	case commands.SMove:
+		return node.applySMove(cmd.(*commands.SMoveCommand))
+	case commands.SInterStore, commands.SUnionStore, commands.SDiffStore:
+		return node.applySStore(cmd.(*commands.SStoreCommand))
+	case commands.HSet:
+		return node.applyHSet(cmd.(*commands.HSetCommand))
+	case commands.HSetNX:
+		return node.applyHSetNX(cmd.(*commands.HSetNXCommand))
+	case commands.HDel:
+		return node.applyHDel(cmd.(*commands.HFieldsCommand))
+	case commands.HIncrBy:
+		return node.applyHIncrBy(cmd.(*commands.HIncrByCommand))
+	case commands.HIncrByFloat:
+		return node.applyHIncrByFloat(cmd.(*commands.HIncrByFloatCommand))
+	case commands.ZAdd:
+		return node.applyZAdd(cmd.(*commands.ZAddCommand))
+	case commands.ZRem:
+		return node.applyZRem(cmd.(*commands.ZRemCommand))
+	case commands.ZIncrBy:
+		return node.applyZIncrBy(cmd.(*commands.ZIncrByCommand))
+	case commands.GeoAdd:
+		return node.applyGeoAdd(cmd.(*commands.GeoAddCommand))
+	case commands.SetBit:
+		return node.applySetBit(cmd.(*commands.SetBitCommand))
+	case commands.BitOp:
+		return node.applyBitOp(cmd.(*commands.BitOpCommand))
+	case commands.BitField:
+		return node.applyBitField(cmd.(*commands.BitFieldCommand))
+	case commands.BFReserve:
+		return node.applyBFReserve(cmd.(*commands.BFReserveCommand))
+	case commands.BFAdd, commands.BFMAdd:
+		return node.applyBFAdd(cmd.(*commands.BFAddCommand))
+	case commands.CFReserve:
+		return node.applyCFReserve(cmd.(*commands.CFReserveCommand))
+	case commands.CFAdd, commands.CFAddNX:
+		return node.applyCFAdd(cmd.(*commands.CFAddCommand))
+	case commands.CFDel:
+		return node.applyCFDel(cmd.(*commands.CFDelCommand))
+	case commands.CMSInitByDim:
+		return node.applyCMSInitByDim(cmd.(*commands.CMSInitByDimCommand))
+	case commands.CMSInitByProb:
+		return node.applyCMSInitByProb(cmd.(*commands.CMSInitByProbCommand))
+	case commands.CMSIncrBy:
+		return node.applyCMSIncrBy(cmd.(*commands.CMSIncrByCommand))
+	case commands.CMSMerge:
+		return node.applyCMSMerge(cmd.(*commands.CMSMergeCommand))
+	case commands.TopKReserve:
+		return node.applyTopKReserve(cmd.(*commands.TopKReserveCommand))
+	case commands.TopKAdd, commands.TopKIncrBy:
+		return node.applyTopKAdd(cmd.(*commands.TopKAddCommand))
+	case commands.TDigestCreate:
+		return node.applyTDigestCreate(cmd.(*commands.TDigestCreateCommand))
+	case commands.TDigestAdd:
+		return node.applyTDigestAdd(cmd.(*commands.TDigestAddCommand))
+	case commands.TDigestMerge:
+		return node.applyTDigestMerge(cmd.(*commands.TDigestMergeCommand))
+	case commands.TSCreate:
+		return node.applyTSCreate(cmd.(*commands.TSCreateCommand))
+	case commands.TSAdd:
+		return node.applyTSAdd(cmd.(*commands.TSAddCommand), appendedAt(l))
+	case commands.TSMAdd:
+		return node.applyTSMAdd(cmd.(*commands.TSMAddCommand), appendedAt(l))
+	case commands.JSONSet:
+		return node.applyJSONSet(cmd.(*commands.JSONSetCommand))
+	case commands.JSONDel:
+		return node.applyJSONDel(cmd.(*commands.JSONPathCommand))
+	case commands.JSONArrAppend:
+		return node.applyJSONArrAppend(cmd.(*commands.JSONArrAppendCommand))
+	case commands.JSONNumIncrBy:
+		return node.applyJSONNumIncrBy(cmd.(*commands.JSONNumIncrByCommand))
+	case commands.XAdd:
+		return node.applyXAdd(cmd.(*commands.XAddCommand), appendedAt(l))
+	case commands.XTrim:
+		return node.applyXTrim(cmd.(*commands.XTrimCommand))
+	case commands.XGroup:
+		return node.applyXGroup(cmd.(*commands.XGroupCommand))
+	case commands.XReadGroup:
+		return node.applyXReadGroup(cmd.(*commands.XReadGroupCommand), appendedAt(l))
+	case commands.XAck:
+		return node.applyXAck(cmd.(*commands.XAckCommand))
+	case commands.XClaim:
+		return node.applyXClaim(cmd.(*commands.XClaimCommand), appendedAt(l))
+	case commands.XAutoClaim:
+		return node.applyXAutoClaim(cmd.(*commands.XAutoClaimCommand), appendedAt(l))
+	case commands.VAdd:
+		return node.applyVAdd(cmd.(*commands.VAddCommand))
+	case commands.VRem:
+		return node.applyVRem(cmd.(*commands.VRemCommand))
+	case commands.FTCreate:
+		return node.applyFTCreate(cmd.(*commands.FTCreateCommand))
+	case commands.FTDropIndex:
+		return node.applyFTDropIndex(cmd.(*commands.FTDropIndexCommand))
 	case commands.PFAdd:
 		return node.applyPFAdd(cmd.(*commands.PFAddCommand))
+	case commands.PFMerge:
+		return node.applyPFMerge(cmd.(*commands.PFMergeCommand))
 	default:
 		node.logger.Error("unrecognised command", zap.String("type", string(cmd.GetMessageType())))
 		return nil
 	}
 }
 
+// appendedAt returns the Unix time in milliseconds the leader appended the log at, which is the same on every replica,
+// unlike the clock of the replica applying it. It is zero for logs appended without a time.
+func appendedAt(l *raft.Log) int64 {
+	if l.AppendedAt.IsZero() {
+		return 0
+	}
+	return l.AppendedAt.UnixMilli()
+}
+
 // Snapshot returns a snapshot of the key-value store.
+//
+// Values are mutated in place by Apply, so they are encoded while holding the lock
+// rather than later in Persist, which runs concurrently with Apply.
 func (node *RaftNode) Snapshot() (raft.FSMSnapshot, error) {
 	node.mu.Lock()
 	defer node.mu.Unlock()
 
-	// Clone the map.
-	o := make(map[string]datatypes.Type)
-	for k, v := range node.m {
-		o[k] = v
+	state := &fsmState{Version: snapshotVersion, Keys: node.m, Indexes: make([]search.Definition, 0, len(node.indexes))}
+	for _, ix := range node.indexes {
+		state.Indexes = append(state.Indexes, ix.Definition())
 	}
-	return &FsmSnapshot{store: o}, nil
+	sort.Slice(state.Indexes, func(i, j int) bool {
+		return state.Indexes[i].Name < state.Indexes[j].Name
+	})
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return &FsmSnapshot{data: b}, nil
 }
 
 // Restore stores the key-value store to a previous state.
 func (node *RaftNode) Restore(rc io.ReadCloser) error {
-	o := make(map[string]datatypes.Type)
-	if err := json.NewDecoder(rc).Decode(&o); err != nil {
+	encoded := make(map[string]json.RawMessage)
+	if err := json.NewDecoder(rc).Decode(&encoded); err != nil {
 		return err
 	}
 
+	// Snapshots taken before indexes existed hold only the keyspace, where every value is an object
+	// rather than a version number.
+	var state struct {
+		Keys    map[string]json.RawMessage `json:"keys"`
+		Indexes []search.Definition        `json:"indexes"`
+	}
+	var version int
+	if err := json.Unmarshal(encoded["version"], &version); err == nil {
+		if err := json.Unmarshal(encoded["keys"], &state.Keys); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(encoded["indexes"], &state.Indexes); err != nil {
+			return err
+		}
+	} else {
+		state.Keys = encoded
+	}
+
+	o := make(map[string]datatypes.Type, len(state.Keys))
+	for k, v := range state.Keys {
+		val, err := datatypes.UnmarshalType(v)
+		if err != nil {
+			return fmt.Errorf("failed to restore key %s: %w", k, err)
+		}
+		o[k] = val
+	}
+
 	// Set the state from the snapshot, no lock required according to
 	// Hashicorp docs.
 	node.m = o
+	node.indexes = make(map[string]*search.Index, len(state.Indexes))
+	for _, def := range state.Indexes {
+		node.indexes[def.Name] = node.buildIndex(def)
+	}
 	return nil
 }
 
-func (node *RaftNode) applySet(cmd *commands.SetCommand) interface{} {
-	node.mu.Lock()
-	defer node.mu.Unlock()
-	node.m[cmd.Key] = datatypes.NewString(cmd.Value)
-	return (&commands.CountResponse{Count: 1}).String()
-}
-
 func (node *RaftNode) applyDelete(cmd *commands.DelCommand) interface{} {
 	node.mu.Lock()
 	defer node.mu.Unlock()
 	delete(node.m, cmd.Key)
+	node.reindex(cmd.Key)
 	return (&commands.CountResponse{Count: 1}).String()
 }
 
@@ -438,8 +852,11 @@ func (node *RaftNode) startPeeringServer() error {
 
 func handlePeerMessage(conn *net.UDPConn, s *RaftNode) {
 	for {
-		buf := make([]byte, 1024)
+		buf := make([]byte, peerBufferSize)
 		n, addr, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
 			continue
 		}
@@ -474,4 +891,7 @@ func handlePeerLog(buf []byte, n int, node *RaftNode, conn *net.UDPConn, addr *n
 	}
 
 	strResponse = node.ApplyCmd(c)
+	if len(strResponse) > maxDatagramSize {
+		strResponse = (&commands.ErrorResponse{Err: commands.ErrorTooLarge}).String()
+	}
 }
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"github.com/c16a/pouch/server/search"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestNode starts a node over an in-memory transport and stores, bootstrapping a cluster of its own if asked.
+// The address is what its peers see it as, or a generated one if empty.
+func startTestNode(t *testing.T, id, addr string, bootstrap bool) (*RaftNode, *raft.InmemTransport) {
+	t.Helper()
+
+	node := &RaftNode{
+		m:       make(map[string]datatypes.Type),
+		indexes: make(map[string]*search.Index),
+		logger:  zap.NewNop(),
+		Config:  &NodeConfig{Cluster: &Cluster{NodeID: id}},
+	}
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(id)
+	config.LogOutput = io.Discard
+	config.HeartbeatTimeout = 50 * time.Millisecond
+	config.ElectionTimeout = 50 * time.Millisecond
+	config.LeaderLeaseTimeout = 50 * time.Millisecond
+
+	store := raft.NewInmemStore()
+	localAddr, transport := raft.NewInmemTransport(raft.ServerAddress(addr))
+	ra, err := raft.NewRaft(config, node, store, store, raft.NewInmemSnapshotStore(), transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ra.Shutdown().Error()
+	})
+	node.raft = ra
+
+	if bootstrap {
+		configuration := raft.Configuration{Servers: []raft.Server{{ID: config.LocalID, Address: localAddr}}}
+		if err := ra.BootstrapCluster(configuration).Error(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return node, transport
+}
+
+// waitFor polls until a condition holds, failing if it doesn't within a few seconds.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !condition(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal(what)
+		}
+	}
+}
+
+// newTestNode returns a node which is the leader of a cluster of its own.
+func newTestNode(t *testing.T) *RaftNode {
+	t.Helper()
+
+	node, _ := startTestNode(t, "test", "", true)
+	waitFor(t, "node did not become leader", func() bool {
+		return node.raft.State() == raft.Leader
+	})
+	return node
+}
+
+// applyLine parses a line and serves it on a node, as a client connection would.
+func applyLine(t *testing.T, node *RaftNode, line string) string {
+	t.Helper()
+
+	cmd, err := commands.ParseStringIntoCommand(line)
+	if err != nil {
+		t.Fatalf("%s: %v", line, err)
+	}
+	return node.ApplyCmd(cmd)
+}
+
+func TestRelayToLeader(t *testing.T) {
+	// The leader's Raft address is its peering address, as it is when both are the cluster address.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	leader, leaderTransport := startTestNode(t, "leader", conn.LocalAddr().String(), true)
+	waitFor(t, "leader was not elected", func() bool {
+		return leader.raft.State() == raft.Leader
+	})
+	go handlePeerMessage(conn, leader)
+
+	follower, followerTransport := startTestNode(t, "follower", "", false)
+	leaderTransport.Connect(followerTransport.LocalAddr(), followerTransport)
+	followerTransport.Connect(leaderTransport.LocalAddr(), leaderTransport)
+	if err := leader.raft.AddVoter("follower", followerTransport.LocalAddr(), 0, 0).Error(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "follower did not find the leader", func() bool {
+		addr, _ := follower.raft.LeaderWithID()
+		return addr == leaderTransport.LocalAddr()
+	})
+
+	// A document larger than the old 1KB buffers must arrive whole.
+	value := strings.Repeat("x", 4096)
+	if got, want := applyLine(t, follower, `JSON.SET doc $ {"value":"`+value+`"}`), (&commands.BooleanResponse{Value: true}).String(); got != want {
+		t.Fatalf("JSON.SET through the follower = %.100q, want %q", got, want)
+	}
+	if got, want := applyLine(t, leader, "JSON.GET doc $.value"), (&commands.StringResponse{Value: `["` + value + `"]`}).String(); got != want {
+		t.Errorf("JSON.GET on the leader = %.100q, want the %d byte value", got, len(value))
+	}
+
+	// A command too large for a datagram is refused rather than truncated.
+	value = strings.Repeat("x", maxDatagramSize)
+	if got, want := applyLine(t, follower, `JSON.SET doc $ {"value":"`+value+`"}`), (&commands.ErrorResponse{Err: commands.ErrorTooLarge}).String(); got != want {
+		t.Errorf("JSON.SET of %d bytes through the follower = %.100q, want %q", len(value), got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"github.com/c16a/pouch/server/search"
+	"strconv"
+)
+
+func (node *RaftNode) FTCreate(cmd *commands.FTCreateCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) FTDropIndex(cmd *commands.FTDropIndexCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// FTSearch responds with the number of matching keys, followed by a page of them, each followed by its score if asked for.
+func (node *RaftNode) FTSearch(cmd *commands.FTSearchCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	ix, ok := node.indexes[cmd.Index]
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+
+	q, err := search.ParseQuery(cmd.Query, ix.Definition().Schema)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	total, results := ix.Search(q, cmd.Offset, cmd.Limit)
+	values := make([]string, 0, 1+2*len(results))
+	values = append(values, strconv.Itoa(total))
+	for _, r := range results {
+		values = append(values, r.Key)
+		if cmd.WithScores {
+			values = append(values, commands.FormatFloat(r.Score))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// applyFTCreate creates an index and fills it with the keys that already exist under its prefix.
+func (node *RaftNode) applyFTCreate(cmd *commands.FTCreateCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.indexes[cmd.Index]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorIndexExists}).String()
+	}
+
+	def := search.Definition{Name: cmd.Index, On: search.IndexOn(cmd.On), Prefix: cmd.Prefix}
+	if def.On == search.OnString {
+		def.Schema = []search.Field{{Name: search.StringField, Type: search.FieldText}}
+	}
+	for _, f := range cmd.Schema {
+		if def.On == search.OnJSON && !datatypes.IsValidJSONPath(f.Path) {
+			return (&commands.ErrorResponse{Err: datatypes.ErrInvalidJSONPath}).String()
+		}
+		field := search.Field{Name: f.Name, Type: search.FieldType(f.Type)}
+		if f.Path != f.Name {
+			field.Path = f.Path
+		}
+		def.Schema = append(def.Schema, field)
+	}
+
+	node.indexes[cmd.Index] = node.buildIndex(def)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) applyFTDropIndex(cmd *commands.FTDropIndexCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.indexes[cmd.Index]; !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	delete(node.indexes, cmd.Index)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// buildIndex creates an index from its definition over the keys in the store.
+func (node *RaftNode) buildIndex(def search.Definition) *search.Index {
+	ix := search.NewIndex(def)
+	for key := range node.m {
+		if ix.Covers(key) {
+			node.indexKey(ix, key)
+		}
+	}
+	return ix
+}
+
+// reindex brings the indexes covering a key up to date with its value. Every write which changes a
+// string, a hash or a JSON document, or replaces a key, calls it before releasing mu, so that searches always agree
+// with the keyspace.
+func (node *RaftNode) reindex(key string) {
+	for _, ix := range node.indexes {
+		if ix.Covers(key) {
+			node.indexKey(ix, key)
+		}
+	}
+}
+
+// indexKey indexes the value of a key if it is of the type an index is on, and removes it from the index otherwise.
+func (node *RaftNode) indexKey(ix *search.Index, key string) {
+	val, ok := node.m[key]
+	if !ok {
+		ix.Remove(key)
+		return
+	}
+
+	def := ix.Definition()
+	doc := make(search.Document, len(def.Schema))
+	switch {
+	case val.GetName() == "string" && def.On == search.OnString:
+		doc[search.StringField] = []string{val.(*datatypes.String).GetValue()}
+	case val.GetName() == "hash" && def.On == search.OnHash:
+		hash := val.(*datatypes.Hash)
+		for _, f := range def.Schema {
+			if value, ok := hash.Get(f.Source()); ok {
+				doc[f.Name] = []string{value}
+			}
+		}
+	case val.GetName() == "json" && def.On == search.OnJSON:
+		j := val.(*datatypes.JSON)
+		for _, f := range def.Schema {
+			values, _ := j.Values(f.Source())
+			doc[f.Name] = jsonFieldValues(values, nil)
+		}
+	default:
+		ix.Remove(key)
+		return
+	}
+	ix.Add(key, doc)
+}
+
+// jsonFieldValues appends the strings, numbers and booleans among JSON values, and in the arrays among them.
+func jsonFieldValues(values []interface{}, result []string) []string {
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			result = append(result, v)
+		case json.Number:
+			result = append(result, v.String())
+		case bool:
+			result = append(result, strconv.FormatBool(v))
+		case []interface{}:
+			result = jsonFieldValues(v, result)
+		}
+	}
+	return result
+}
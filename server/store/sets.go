@@ -3,6 +3,8 @@ package store
 import (
 	"github.com/c16a/pouch/sdk/commands"
 	"github.com/c16a/pouch/server/datatypes"
+	"math/rand/v2"
+	"strconv"
 )
 
 func (node *RaftNode) SAdd(cmd *commands.SAddCommand) string {
@@ -83,24 +85,81 @@ func (node *RaftNode) SUnion(cmd *commands.SUnionCommand) string {
 	node.mu.Lock()
 	defer node.mu.Unlock()
 
+	union, err := node.combineSets(commands.SUnion, cmd.Key, cmd.OtherKeys)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.ListResponse{Values: union.GetMembers()}).String()
+}
+
+func (node *RaftNode) SInter(cmd *commands.SInterCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	intersection, err := node.combineSets(commands.SInter, cmd.Key, cmd.OtherKeys)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.ListResponse{Values: intersection.GetMembers()}).String()
+}
+
+func (node *RaftNode) SDiff(cmd *commands.SDiffCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	diff, err := node.combineSets(commands.SDiff, cmd.Key, cmd.OtherKeys)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.ListResponse{Values: diff.GetMembers()}).String()
+}
+
+// SInterCard responds with the number of members in the intersection of sets, counting up to the limit if there is one.
+func (node *RaftNode) SInterCard(cmd *commands.SInterCardCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	intersection, err := node.combineSets(commands.SInter, cmd.Keys[0], cmd.Keys[1:])
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	count := intersection.Size()
+	if cmd.Limit > 0 {
+		count = min(count, cmd.Limit)
+	}
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+// SMIsMember responds with whether each of the members is in a set.
+func (node *RaftNode) SMIsMember(cmd *commands.SValuesCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
 	set, err := node.findSet(cmd.Key)
 	if err != nil {
 		return (&commands.ErrorResponse{Err: err}).String()
 	}
 
-	union := set.Copy()
-	for _, otherKey := range cmd.OtherKeys {
-		otherSet, err := node.findSet(otherKey)
-		if err != nil {
-			continue
-		}
-		union = union.Union(otherSet)
+	results := make([]string, 0, len(cmd.Values))
+	for _, value := range cmd.Values {
+		results = append(results, strconv.FormatBool(set.Contains(value)))
 	}
+	return (&commands.ListResponse{Values: results}).String()
+}
 
-	return (&commands.ListResponse{Values: union.GetMembers()}).String()
+func (node *RaftNode) SRem(cmd *commands.SValuesCommand) string {
+	return node.respondAfterRaftCommit(cmd)
 }
 
-func (node *RaftNode) SInter(cmd *commands.SInterCommand) string {
+// SPop goes through the log, as the members it removes are seeded from its index.
+func (node *RaftNode) SPop(cmd *commands.SPopCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// SRandMember picks random members of a set without removing them. As nothing changes, every node can pick
+// its own members from an unseeded generator.
+func (node *RaftNode) SRandMember(cmd *commands.SPopCommand) string {
 	node.mu.Lock()
 	defer node.mu.Unlock()
 
@@ -109,19 +168,38 @@ func (node *RaftNode) SInter(cmd *commands.SInterCommand) string {
 		return (&commands.ErrorResponse{Err: err}).String()
 	}
 
-	intersection := set.Copy()
-	for _, otherKey := range cmd.OtherKeys {
-		otherSet, err := node.findSet(otherKey)
-		if err != nil {
-			continue
-		}
-		intersection = intersection.Intersection(otherSet)
+	members := datatypes.RandomMembers(set, cmd.Count, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+	return randomMembersResponse(cmd, members)
+}
+
+func (node *RaftNode) SMove(cmd *commands.SMoveCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// SStore serves SINTERSTORE, SUNIONSTORE and SDIFFSTORE.
+func (node *RaftNode) SStore(cmd *commands.SStoreCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) applySRem(cmd *commands.SValuesCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	set, err := node.findSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
 	}
 
-	return (&commands.ListResponse{Values: intersection.GetMembers()}).String()
+	count := set.RemoveMany(cmd.Values)
+	if set.Size() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return (&commands.CountResponse{Count: count}).String()
 }
 
-func (node *RaftNode) SDiff(cmd *commands.SDiffCommand) string {
+// applySPop removes random members of a set picked with a generator seeded from the index of the log, so that every
+// replica removes the same members.
+func (node *RaftNode) applySPop(cmd *commands.SPopCommand, seed uint64) interface{} {
 	node.mu.Lock()
 	defer node.mu.Unlock()
 
@@ -130,16 +208,116 @@ func (node *RaftNode) SDiff(cmd *commands.SDiffCommand) string {
 		return (&commands.ErrorResponse{Err: err}).String()
 	}
 
-	diff := set.Copy()
-	for _, otherKey := range cmd.OtherKeys {
-		otherSet, err := node.findSet(otherKey)
+	members := datatypes.RandomMembers(set, cmd.Count, rand.New(rand.NewPCG(seed, 0)))
+	set.RemoveMany(members)
+	if set.Size() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return randomMembersResponse(cmd, members)
+}
+
+// randomMembersResponse responds to SPOP and SRANDMEMBER with a single member unless given a count.
+func randomMembersResponse(cmd *commands.SPopCommand, members []string) string {
+	if !cmd.WithCount {
+		if len(members) == 0 {
+			return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+		}
+		return (&commands.StringResponse{Value: members[0]}).String()
+	}
+	return (&commands.ListResponse{Values: members}).String()
+}
+
+// applySMove moves a member from one set to another, creating the destination if it is missing,
+// and responds with whether the member was in the source.
+func (node *RaftNode) applySMove(cmd *commands.SMoveCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	source, err := node.findSet(cmd.Source)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	destination, err := node.findSet(cmd.Destination)
+	if err == commands.ErrorNotFound {
+		destination = datatypes.NewSet[string]()
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if source.Remove(cmd.Member) == 0 {
+		return (&commands.BooleanResponse{Value: false}).String()
+	}
+	destination.Add(cmd.Member)
+	node.m[cmd.Destination] = destination
+	if source.Size() == 0 {
+		delete(node.m, cmd.Source)
+	}
+	return (&commands.BooleanResponse{Value: true}).String()
+}
+
+// applySStore replaces the destination with the result of SINTER, SUNION or SDIFF, deleting it if the
+// result is empty, and responds with the number of members stored.
+func (node *RaftNode) applySStore(cmd *commands.SStoreCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	var op commands.MessageType
+	switch cmd.GetMessageType() {
+	case commands.SInterStore:
+		op = commands.SInter
+	case commands.SUnionStore:
+		op = commands.SUnion
+	default:
+		op = commands.SDiff
+	}
+
+	result, err := node.combineSets(op, cmd.Key, cmd.OtherKeys)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if result.Size() == 0 {
+		delete(node.m, cmd.Destination)
+	} else {
+		node.m[cmd.Destination] = result
+	}
+	node.reindex(cmd.Destination)
+	return (&commands.CountResponse{Count: result.Size()}).String()
+}
+
+// combineSets returns the intersection, union or difference of the set at a key with the sets at the
+// other keys, where a missing key is an empty set.
+func (node *RaftNode) combineSets(op commands.MessageType, key string, otherKeys []string) (*datatypes.Set[string], error) {
+	set, err := node.findSetOrEmpty(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := set.Copy()
+	for _, otherKey := range otherKeys {
+		otherSet, err := node.findSetOrEmpty(otherKey)
 		if err != nil {
-			continue
+			return nil, err
+		}
+		switch op {
+		case commands.SInter:
+			result = result.Intersection(otherSet)
+		case commands.SUnion:
+			result = result.Union(otherSet)
+		case commands.SDiff:
+			result = result.Difference(otherSet)
 		}
-		diff = diff.Difference(otherSet)
 	}
+	return result, nil
+}
 
-	return (&commands.ListResponse{Values: diff.GetMembers()}).String()
+// findSetOrEmpty is findSet, except that a missing key is an empty set rather than an error.
+func (node *RaftNode) findSetOrEmpty(key string) (*datatypes.Set[string], error) {
+	set, err := node.findSet(key)
+	if err == commands.ErrorNotFound {
+		return datatypes.NewSet[string](), nil
+	}
+	return set, err
 }
 
 func (node *RaftNode) findSet(key string) (*datatypes.Set[string], error) {
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"testing"
+)
+
+func TestCombineSets_MissingKeys(t *testing.T) {
+	node := newTestNode(t)
+	applyLine(t, node, "SADD a x y")
+	applyLine(t, node, "SADD b y z")
+	applyLine(t, node, "SET s v")
+
+	count := func(n int) string {
+		return (&commands.CountResponse{Count: n}).String()
+	}
+	invalid := (&commands.ErrorResponse{Err: commands.ErrorInvalidDataType}).String()
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		// A missing key is an empty set, wherever it comes.
+		{"SINTERSTORE dst a missing", count(0)},
+		{"SINTERSTORE dst missing a", count(0)},
+		{"SUNIONSTORE dst a missing b", count(3)},
+		{"SUNIONSTORE dst missing a", count(2)},
+		{"SDIFFSTORE dst a missing b", count(1)},
+		{"SDIFFSTORE dst missing a", count(0)},
+		{"SINTERCARD 2 a missing", count(0)},
+		{"SINTERCARD 2 missing a", count(0)},
+		{"SINTERCARD 2 a b", count(1)},
+
+		// A key which isn't a set is an error rather than skipped.
+		{"SINTERSTORE dst a s", invalid},
+		{"SUNIONSTORE dst s a", invalid},
+		{"SDIFFSTORE dst a missing s", invalid},
+		{"SINTERCARD 3 a missing s", invalid},
+	}
+	for _, tt := range tests {
+		if got := applyLine(t, node, tt.line); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	// An empty result deletes the destination.
+	applyLine(t, node, "SUNIONSTORE dst a")
+	applyLine(t, node, "SINTERSTORE dst a missing")
+	if got, want := applyLine(t, node, "SCARD dst"), (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String(); got != want {
+		t.Errorf("SCARD of an empty intersection's destination = %q, want %q", got, want)
+	}
+}
@@ -1,25 +1,28 @@
 package store
 
 import (
-	"encoding/json"
 	"github.com/c16a/pouch/server/datatypes"
+	"github.com/c16a/pouch/server/search"
 	"github.com/hashicorp/raft"
 )
 
+// snapshotVersion is the version of the snapshot format, which holds the key-value store along with the index definitions.
+const snapshotVersion = 1
+
+type fsmState struct {
+	Version int                       `json:"version"`
+	Keys    map[string]datatypes.Type `json:"keys"`
+	Indexes []search.Definition       `json:"indexes"`
+}
+
 type FsmSnapshot struct {
-	store map[string]datatypes.Type
+	data []byte // The JSON encoded fsmState
 }
 
 func (f *FsmSnapshot) Persist(sink raft.SnapshotSink) error {
 	err := func() error {
-		// Encode data.
-		b, err := json.Marshal(f.store)
-		if err != nil {
-			return err
-		}
-
 		// Write data to sink.
-		if _, err := sink.Write(b); err != nil {
+		if _, err := sink.Write(f.data); err != nil {
 			return err
 		}
 
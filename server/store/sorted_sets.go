@@ -0,0 +1,205 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"math"
+)
+
+func (node *RaftNode) ZAdd(cmd *commands.ZAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) ZRem(cmd *commands.ZRemCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) ZIncrBy(cmd *commands.ZIncrByCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) ZScore(cmd *commands.ZScoreCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	score, ok := zset.GetScore(cmd.Member)
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	return (&commands.FloatResponse{Value: score}).String()
+}
+
+func (node *RaftNode) ZCard(cmd *commands.ZCardCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: zset.Card()}).String()
+}
+
+func (node *RaftNode) ZRank(cmd *commands.ZRankCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	rank, ok := zset.Rank(cmd.Member, cmd.Reverse)
+	if !ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	return (&commands.CountResponse{Count: rank}).String()
+}
+
+func (node *RaftNode) ZRange(cmd *commands.ZRangeCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var entries []datatypes.SortedSetEntry
+	if cmd.ByScore {
+		scoreRange := datatypes.ScoreRange{
+			Min:          cmd.Min.Value,
+			Max:          cmd.Max.Value,
+			MinExclusive: cmd.Min.Exclusive,
+			MaxExclusive: cmd.Max.Exclusive,
+		}
+		entries = zset.RangeByScore(scoreRange, cmd.Rev, cmd.Offset, cmd.Count)
+	} else {
+		entries = zset.RangeByIndex(cmd.Start, cmd.Stop, cmd.Rev)
+	}
+
+	values := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		values = append(values, entry.Name)
+		if cmd.WithScores {
+			values = append(values, commands.FormatFloat(entry.Score))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyZAdd(cmd *commands.ZAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err == commands.ErrorNotFound {
+		zset = datatypes.NewSortedSet()
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	// New sets are only stored once they actually hold a member.
+	defer func() {
+		if zset.Card() > 0 {
+			node.m[cmd.Key] = zset
+		}
+	}()
+
+	var count int
+	for _, member := range cmd.Members {
+		current, exists := zset.GetScore(member.Member)
+		if (cmd.NX && exists) || (cmd.XX && !exists) {
+			continue
+		}
+
+		score := member.Score
+		if cmd.Incr {
+			score += current
+			if math.IsNaN(score) {
+				return (&commands.ErrorResponse{Err: commands.ErrorNotANumber}).String()
+			}
+		}
+
+		if exists && ((cmd.GT && score <= current) || (cmd.LT && score >= current)) {
+			continue
+		}
+
+		zset.Add(member.Member, score)
+		if !exists || (cmd.CH && score != current) {
+			count++
+		}
+
+		if cmd.Incr {
+			return (&commands.FloatResponse{Value: score}).String()
+		}
+	}
+
+	// An increment which was skipped because of NX, XX, GT or LT has no resulting score.
+	if cmd.Incr {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+	}
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+func (node *RaftNode) applyZRem(cmd *commands.ZRemCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var count int
+	for _, member := range cmd.Members {
+		if zset.Remove(member) {
+			count++
+		}
+	}
+
+	if zset.Card() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return (&commands.CountResponse{Count: count}).String()
+}
+
+func (node *RaftNode) applyZIncrBy(cmd *commands.ZIncrByCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	zset, err := node.findSortedSet(cmd.Key)
+	if err == commands.ErrorNotFound {
+		zset = datatypes.NewSortedSet()
+		node.m[cmd.Key] = zset
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	current, _ := zset.GetScore(cmd.Member)
+	if math.IsNaN(current + cmd.Increment) {
+		return (&commands.ErrorResponse{Err: commands.ErrorNotANumber}).String()
+	}
+
+	score := zset.IncrBy(cmd.Member, cmd.Increment)
+	return (&commands.FloatResponse{Value: score}).String()
+}
+
+func (node *RaftNode) findSortedSet(key string) (*datatypes.SortedSet, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "zset":
+			zset := val.(*datatypes.SortedSet)
+			return zset, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
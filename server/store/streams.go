@@ -0,0 +1,358 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (node *RaftNode) XAdd(cmd *commands.XAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) XTrim(cmd *commands.XTrimCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) XGroup(cmd *commands.XGroupCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// XReadGroup is replicated, as reading new entries moves the consumer group forward.
+func (node *RaftNode) XReadGroup(cmd *commands.XReadGroupCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) XAck(cmd *commands.XAckCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) XClaim(cmd *commands.XClaimCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) XAutoClaim(cmd *commands.XAutoClaimCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// XRange serves both XRANGE and XREVRANGE.
+func (node *RaftNode) XRange(cmd *commands.XRangeCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	entries := stream.Range(datatypes.StreamID(cmd.Start), datatypes.StreamID(cmd.End), cmd.Count, cmd.Reverse)
+
+	values := make([]string, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, formatStreamEntry(e))
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) XLen(cmd *commands.XLenCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: stream.Len()}).String()
+}
+
+// XPending summarises the pending entries of a group as their count, smallest and largest ID,
+// followed by the number of entries pending for each consumer. The extended form lists each
+// pending entry with its consumer, idle time in milliseconds and delivery count instead.
+func (node *RaftNode) XPending(cmd *commands.XPendingCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	group, err := stream.Group(cmd.Group)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	now := time.Now().UnixMilli()
+
+	if cmd.Extended {
+		values := make([]string, 0)
+		if cmd.Count == 0 {
+			return (&commands.ListResponse{Values: values}).String()
+		}
+
+		pending := group.PendingEntries(datatypes.StreamID(cmd.Start), datatypes.StreamID(cmd.End), cmd.Count, cmd.Consumer, cmd.MinIdle, now)
+		for _, pe := range pending {
+			idle := max(now-pe.DeliveryTime, 0)
+			values = append(values, pe.ID.String()+" "+pe.Consumer+" "+strconv.FormatInt(idle, 10)+" "+strconv.FormatUint(pe.DeliveryCount, 10))
+		}
+		return (&commands.ListResponse{Values: values}).String()
+	}
+
+	pending := group.PendingEntries(datatypes.StreamID{}, datatypes.MaxStreamID, 0, "", 0, now)
+	if len(pending) == 0 {
+		return (&commands.CountResponse{Count: 0}).String()
+	}
+
+	values := []string{strconv.Itoa(len(pending)), pending[0].ID.String(), pending[len(pending)-1].ID.String()}
+
+	perConsumer := make(map[string]int)
+	consumers := make([]string, 0)
+	for _, pe := range pending {
+		if perConsumer[pe.Consumer] == 0 {
+			consumers = append(consumers, pe.Consumer)
+		}
+		perConsumer[pe.Consumer]++
+	}
+	sort.Strings(consumers)
+	for _, consumer := range consumers {
+		values = append(values, consumer+" "+strconv.Itoa(perConsumer[consumer]))
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// applyXAdd adds an entry at time now, the Unix time in milliseconds the leader appended the command at,
+// which is the same on every replica.
+func (node *RaftNode) applyXAdd(cmd *commands.XAddCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	created := false
+	if err == commands.ErrorNotFound && !cmd.NoMkStream {
+		stream, err, created = datatypes.NewStream(), nil, true
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	var id datatypes.StreamID
+	switch {
+	case cmd.AutoID:
+		id = stream.NextID(uint64(now))
+	case cmd.AutoSeq:
+		if id = stream.NextID(cmd.ID.Ms); id.Ms != cmd.ID.Ms {
+			return (&commands.ErrorResponse{Err: datatypes.ErrStreamIDTooSmall}).String()
+		}
+	default:
+		id = datatypes.StreamID(cmd.ID)
+	}
+
+	if err := stream.Add(id, cmd.Fields); err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	if created {
+		node.m[cmd.Key] = stream
+	}
+	trimStream(stream, cmd.Trim)
+
+	return (&commands.StringResponse{Value: id.String()}).String()
+}
+
+func (node *RaftNode) applyXTrim(cmd *commands.XTrimCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: trimStream(stream, cmd.Trim)}).String()
+}
+
+func (node *RaftNode) applyXGroup(cmd *commands.XGroupCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err == commands.ErrorNotFound && cmd.MkStream {
+		stream, err = datatypes.NewStream(), nil
+		node.m[cmd.Key] = stream
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	id := datatypes.StreamID(cmd.ID)
+	if cmd.LastID {
+		id = stream.LastID()
+	}
+	if err := stream.CreateGroup(cmd.Group, id); err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyXReadGroup responds with the entries read from each stream, prefixed by the key of the stream.
+// Pending entries which have since been trimmed from the stream are listed with nil in place of their fields.
+func (node *RaftNode) applyXReadGroup(cmd *commands.XReadGroupCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	// Every stream and group is looked up first, so that nothing is delivered if any of them is missing.
+	streams := make([]*datatypes.Stream, 0, len(cmd.Streams))
+	groups := make([]*datatypes.ConsumerGroup, 0, len(cmd.Streams))
+	for _, s := range cmd.Streams {
+		stream, err := node.findStream(s.Key)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		group, err := stream.Group(cmd.Group)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		streams = append(streams, stream)
+		groups = append(groups, group)
+	}
+
+	values := make([]string, 0)
+	for i, s := range cmd.Streams {
+		var entries []datatypes.StreamEntry
+		if s.New {
+			entries = streams[i].ReadGroup(groups[i], cmd.Consumer, cmd.Count, cmd.NoAck, now)
+		} else {
+			entries = streams[i].ReadGroupHistory(groups[i], cmd.Consumer, datatypes.StreamID(s.After), cmd.Count, now)
+		}
+
+		for _, e := range entries {
+			values = append(values, s.Key+" "+formatStreamEntry(e))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyXAck(cmd *commands.XAckCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	group, err := stream.Group(cmd.Group)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	ids := make([]datatypes.StreamID, 0, len(cmd.IDs))
+	for _, id := range cmd.IDs {
+		ids = append(ids, datatypes.StreamID(id))
+	}
+	return (&commands.CountResponse{Count: group.Ack(ids)}).String()
+}
+
+func (node *RaftNode) applyXClaim(cmd *commands.XClaimCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	group, err := stream.Group(cmd.Group)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	opts := datatypes.ClaimOptions{DeliveryCount: cmd.RetryCount, Force: cmd.Force, JustID: cmd.JustID}
+	if cmd.Idle != nil {
+		opts.DeliveryTime = now - *cmd.Idle
+	}
+	if cmd.Time != nil {
+		opts.DeliveryTime = *cmd.Time
+	}
+
+	ids := make([]datatypes.StreamID, 0, len(cmd.IDs))
+	for _, id := range cmd.IDs {
+		ids = append(ids, datatypes.StreamID(id))
+	}
+
+	claimed := stream.Claim(group, cmd.Consumer, cmd.MinIdle, ids, now, opts)
+
+	values := make([]string, 0, len(claimed))
+	for _, e := range claimed {
+		if cmd.JustID {
+			values = append(values, e.ID.String())
+		} else {
+			values = append(values, formatStreamEntry(e))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// applyXAutoClaim responds with the ID to continue claiming from, followed by the entries claimed,
+// followed by the IDs dropped from the pending entries as they are no longer in the stream.
+func (node *RaftNode) applyXAutoClaim(cmd *commands.XAutoClaimCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	stream, err := node.findStream(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	group, err := stream.Group(cmd.Group)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	next, claimed, deleted := stream.AutoClaim(group, cmd.Consumer, cmd.MinIdle, datatypes.StreamID(cmd.Start), cmd.Count, now, cmd.JustID)
+
+	values := make([]string, 0, 1+len(claimed)+len(deleted))
+	values = append(values, next.String())
+	for _, e := range claimed {
+		if cmd.JustID {
+			values = append(values, e.ID.String())
+		} else {
+			values = append(values, formatStreamEntry(e))
+		}
+	}
+	for _, id := range deleted {
+		values = append(values, id.String()+" nil")
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// trimStream trims the stream with the strategy of XADD or XTRIM, and returns the number of entries removed.
+func trimStream(stream *datatypes.Stream, trim commands.StreamTrim) int {
+	switch trim.Strategy {
+	case "MAXLEN":
+		return stream.TrimMaxLen(trim.MaxLen)
+	case "MINID":
+		return stream.TrimMinID(datatypes.StreamID(trim.MinID))
+	default:
+		return 0
+	}
+}
+
+// formatStreamEntry formats an entry as its ID followed by its fields and values.
+func formatStreamEntry(e datatypes.StreamEntry) string {
+	if e.Fields == nil {
+		return e.ID.String() + " nil"
+	}
+	return e.ID.String() + " " + strings.Join(e.Fields, " ")
+}
+
+func (node *RaftNode) findStream(key string) (*datatypes.Stream, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "stream":
+			stream := val.(*datatypes.Stream)
+			return stream, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) GetDel(cmd *commands.StringKeyCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// MSet serves MSET and MSETNX.
+func (node *RaftNode) MSet(cmd *commands.MSetCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) Append(cmd *commands.AppendCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) SetRange(cmd *commands.SetRangeCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// MGet responds with the value of each key, or nil for keys which are missing or don't hold a string.
+func (node *RaftNode) MGet(cmd *commands.MGetCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	values := make([]string, 0, len(cmd.Keys))
+	for _, key := range cmd.Keys {
+		if str, err := node.findString(key); err == nil {
+			values = append(values, str.GetValue())
+		} else {
+			values = append(values, "nil")
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+// StrLen responds with the length of a string in bytes, which is zero for a missing key.
+func (node *RaftNode) StrLen(cmd *commands.StringKeyCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findString(cmd.Key)
+	if err == commands.ErrorNotFound {
+		return (&commands.CountResponse{Count: 0}).String()
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: str.Len()}).String()
+}
+
+// GetRange responds with a range of bytes of a string, which is empty for a missing key.
+func (node *RaftNode) GetRange(cmd *commands.GetRangeCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findString(cmd.Key)
+	if err == commands.ErrorNotFound {
+		return (&commands.StringResponse{Value: ""}).String()
+	}
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.StringResponse{Value: str.GetRange(cmd.Start, cmd.End)}).String()
+}
+
+// applySet sets a key to a string if its NX or XX condition holds, responding with whether it did.
+// With GET, it instead responds with the string the key held before, just as GET would have, and
+// leaves the key untouched if that was of another type.
+func (node *RaftNode) applySet(cmd *commands.SetCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	_, exists := node.m[cmd.Key]
+	previous, err := node.findString(cmd.Key)
+	if cmd.Get && err == commands.ErrorInvalidDataType {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	set := !(cmd.NX && exists) && !(cmd.XX && !exists)
+	if set {
+		node.m[cmd.Key] = datatypes.NewString(cmd.Value)
+		node.reindex(cmd.Key)
+	}
+
+	switch {
+	case cmd.Get && err != nil:
+		return (&commands.ErrorResponse{Err: err}).String()
+	case cmd.Get:
+		return (&commands.StringResponse{Value: previous.GetValue()}).String()
+	case set:
+		return (&commands.CountResponse{Count: 1}).String()
+	default:
+		return (&commands.CountResponse{Count: 0}).String()
+	}
+}
+
+func (node *RaftNode) applyGetDel(cmd *commands.StringKeyCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findString(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	delete(node.m, cmd.Key)
+	node.reindex(cmd.Key)
+	return (&commands.StringResponse{Value: str.GetValue()}).String()
+}
+
+// applyMSet sets every key to its string, responding with the number of keys set. MSETNX sets none
+// of them if any already exists, and responds with whether it set them.
+func (node *RaftNode) applyMSet(cmd *commands.MSetCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if cmd.GetMessageType() == commands.MSetNX {
+		for _, pair := range cmd.Pairs {
+			if _, ok := node.m[pair.Key]; ok {
+				return (&commands.BooleanResponse{Value: false}).String()
+			}
+		}
+	}
+
+	for _, pair := range cmd.Pairs {
+		node.m[pair.Key] = datatypes.NewString(pair.Value)
+		node.reindex(pair.Key)
+	}
+
+	if cmd.GetMessageType() == commands.MSetNX {
+		return (&commands.BooleanResponse{Value: true}).String()
+	}
+	return (&commands.CountResponse{Count: len(cmd.Pairs)}).String()
+}
+
+// applyAppend appends to a string, creating it if the key is missing, and responds with its new length.
+func (node *RaftNode) applyAppend(cmd *commands.AppendCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findOrCreateString(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	n, err := str.Append(cmd.Value)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.m[cmd.Key] = str
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: n}).String()
+}
+
+// applySetRange overwrites part of a string, creating it if the key is missing, and responds with its
+// new length. Writing nothing to a missing key leaves it missing.
+func (node *RaftNode) applySetRange(cmd *commands.SetRangeCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findOrCreateString(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	n, err := str.SetRange(cmd.Offset, cmd.Value)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	if n > 0 {
+		node.m[cmd.Key] = str
+		node.reindex(cmd.Key)
+	}
+	return (&commands.CountResponse{Count: n}).String()
+}
+
+// Incr serves INCR, DECR, INCRBY and DECRBY.
+func (node *RaftNode) Incr(cmd *commands.IncrCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) IncrByFloat(cmd *commands.IncrByFloatCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// Throttle serves THROTTLE through Raft, so that every replica sees the same requests at the same leader time.
+func (node *RaftNode) Throttle(cmd *commands.ThrottleCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) applyIncr(cmd *commands.IncrCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findOrCreateCounter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, err := str.IncrBy(cmd.Increment)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.m[cmd.Key] = str
+	node.reindex(cmd.Key)
+	return (&commands.CountResponse{Count: int(value)}).String()
+}
+
+func (node *RaftNode) applyIncrByFloat(cmd *commands.IncrByFloatCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findOrCreateCounter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	value, err := str.IncrByFloat(cmd.Increment)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.m[cmd.Key] = str
+	node.reindex(cmd.Key)
+	return (&commands.FloatResponse{Value: value}).String()
+}
+
+// applyThrottle rate limits a request at the time in milliseconds the leader appended it, and responds with whether
+// it is allowed, the limit, the remaining requests, and the milliseconds to retry after and until the limit resets.
+// A request which is denied leaves the key as it was, and isn't stored if the key didn't exist.
+func (node *RaftNode) applyThrottle(cmd *commands.ThrottleCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	str, err := node.findOrCreateCounter(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	result, err := str.Throttle(now*1000, cmd.Interval(), cmd.MaxBurst, cmd.Quantity)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	if result.Allowed {
+		node.m[cmd.Key] = str
+		node.reindex(cmd.Key)
+	}
+
+	retryAfter := result.RetryAfter
+	if retryAfter > 0 {
+		retryAfter = ceilMillis(retryAfter)
+	}
+	return (&commands.ListResponse{Values: []string{
+		strconv.FormatBool(result.Allowed),
+		strconv.FormatInt(result.Limit, 10),
+		strconv.FormatInt(result.Remaining, 10),
+		strconv.FormatInt(retryAfter, 10),
+		strconv.FormatInt(ceilMillis(result.ResetAfter), 10),
+	}}).String()
+}
+
+// ceilMillis converts a non-negative number of microseconds to milliseconds, rounding up so that waiting that long
+// is always enough.
+func ceilMillis(micros int64) int64 {
+	return micros/1000 + min(micros%1000, 1)
+}
+
+func (node *RaftNode) findString(key string) (*datatypes.String, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "string":
+			return val.(*datatypes.String), nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
+
+// findOrCreateCounter returns the string at a key, or a zero that is only stored once an increment succeeds.
+func (node *RaftNode) findOrCreateCounter(key string) (*datatypes.String, error) {
+	str, err := node.findString(key)
+	if err == commands.ErrorNotFound {
+		return datatypes.NewString("0"), nil
+	}
+	return str, err
+}
+
+// findOrCreateString returns the string at a key, or an empty one that is only stored once a write succeeds.
+func (node *RaftNode) findOrCreateString(key string) (*datatypes.String, error) {
+	str, err := node.findString(key)
+	if err == commands.ErrorNotFound {
+		return datatypes.NewString(""), nil
+	}
+	return str, err
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"math"
+	"strconv"
+)
+
+func (node *RaftNode) TDigestCreate(cmd *commands.TDigestCreateCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) TDigestAdd(cmd *commands.TDigestAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) TDigestMerge(cmd *commands.TDigestMergeCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// TDigestQuery serves TDIGEST.QUANTILE and TDIGEST.CDF.
+func (node *RaftNode) TDigestQuery(cmd *commands.TDigestQueryCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	td, err := node.findTDigest(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	results := make([]string, 0, len(cmd.Values))
+	for _, value := range cmd.Values {
+		if cmd.GetMessageType() == commands.TDigestQuantile {
+			results = append(results, commands.FormatFloat(td.Quantile(value)))
+		} else {
+			results = append(results, commands.FormatFloat(td.CDF(value)))
+		}
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+// TDigestKey serves TDIGEST.MIN, TDIGEST.MAX and TDIGEST.INFO.
+func (node *RaftNode) TDigestKey(cmd *commands.TDigestKeyCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	td, err := node.findTDigest(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	switch cmd.GetMessageType() {
+	case commands.TDigestMin:
+		return (&commands.FloatResponse{Value: td.Min()}).String()
+	case commands.TDigestMax:
+		return (&commands.FloatResponse{Value: td.Max()}).String()
+	default:
+		return (&commands.ListResponse{Values: []string{
+			"Compression", commands.FormatFloat(td.Compression()),
+			"Capacity", strconv.Itoa(td.Capacity()),
+			"Merged nodes", strconv.Itoa(td.MergedNodes()),
+			"Unmerged nodes", strconv.Itoa(td.UnmergedNodes()),
+			"Merged weight", commands.FormatFloat(td.MergedWeight()),
+			"Unmerged weight", commands.FormatFloat(td.UnmergedWeight()),
+			"Observations", strconv.FormatUint(td.Observations(), 10),
+			"Total compressions", strconv.FormatUint(td.TotalCompressions(), 10),
+		}}).String()
+	}
+}
+
+func (node *RaftNode) TDigestTrimmedMean(cmd *commands.TDigestTrimmedMeanCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	td, err := node.findTDigest(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.FloatResponse{Value: td.TrimmedMean(cmd.LowQuantile, cmd.HighQuantile)}).String()
+}
+
+func (node *RaftNode) applyTDigestCreate(cmd *commands.TDigestCreateCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	compression := cmd.Compression
+	if compression == 0 {
+		compression = datatypes.DefaultTDigestCompression
+	}
+	node.m[cmd.Key] = datatypes.NewTDigest(compression)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) applyTDigestAdd(cmd *commands.TDigestAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	td, err := node.findTDigest(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	td.Add(cmd.Values...)
+	return (&commands.CountResponse{Count: len(cmd.Values)}).String()
+}
+
+func (node *RaftNode) applyTDigestMerge(cmd *commands.TDigestMergeCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	sources := make([]*datatypes.TDigest, 0, len(cmd.SourceKeys)+1)
+	compression := cmd.Compression
+	for _, key := range cmd.SourceKeys {
+		td, err := node.findTDigest(key)
+		if err != nil {
+			return (&commands.ErrorResponse{Err: err}).String()
+		}
+		sources = append(sources, td)
+		if cmd.Compression == 0 {
+			compression = math.Max(compression, td.Compression())
+		}
+	}
+
+	dest, err := node.findTDigest(cmd.DestKey)
+	if err == nil && !cmd.Override {
+		sources = append(sources, dest)
+		if cmd.Compression == 0 {
+			compression = math.Max(compression, dest.Compression())
+		}
+	} else if err != nil && err != commands.ErrorNotFound {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	merged := datatypes.NewTDigest(compression)
+	merged.Merge(sources...)
+	node.m[cmd.DestKey] = merged
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+func (node *RaftNode) findTDigest(key string) (*datatypes.TDigest, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "tdigest":
+			td := val.(*datatypes.TDigest)
+			return td, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+const defaultDuplicatePolicy = datatypes.DuplicateBlock
+
+func (node *RaftNode) TSCreate(cmd *commands.TSCreateCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) TSAdd(cmd *commands.TSAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) TSMAdd(cmd *commands.TSMAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) TSGet(cmd *commands.TSGetCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	ts, err := node.findTimeSeries(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	samples := make([]string, 0, 1)
+	if last, ok := ts.Last(); ok {
+		samples = append(samples, formatSample(last))
+	}
+	return (&commands.ListResponse{Values: samples}).String()
+}
+
+// TSRange serves both TS.RANGE and TS.REVRANGE.
+func (node *RaftNode) TSRange(cmd *commands.TSRangeCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	ts, err := node.findTimeSeries(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	samples := ts.Range(datatypes.RangeQuery{
+		From:        cmd.From,
+		To:          cmd.To,
+		Timestamps:  cmd.FilterByTS,
+		MinValue:    cmd.MinValue,
+		MaxValue:    cmd.MaxValue,
+		Aggregator:  datatypes.Aggregator(cmd.Aggregator),
+		BucketWidth: cmd.BucketDuration,
+		Reverse:     cmd.Reverse,
+		Count:       cmd.Count,
+	})
+
+	values := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, formatSample(sample))
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyTSCreate(cmd *commands.TSCreateCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	node.m[cmd.Key] = newTimeSeries(cmd.Retention, cmd.DuplicatePolicy)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyTSAdd adds a sample, taking a * timestamp to be the time in milliseconds the leader appended the command,
+// so that every replica records the same timestamp.
+func (node *RaftNode) applyTSAdd(cmd *commands.TSAddCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	timestamp := cmd.Timestamp
+	if cmd.AutoTimestamp {
+		timestamp = now
+	}
+
+	ts, err := node.findTimeSeries(cmd.Key)
+	if err == commands.ErrorNotFound {
+		ts = newTimeSeries(cmd.Retention, cmd.DuplicatePolicy)
+		node.m[cmd.Key] = ts
+	} else if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	if err := ts.Add(timestamp, cmd.Value, datatypes.DuplicatePolicy(cmd.OnDuplicate)); err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: int(timestamp)}).String()
+}
+
+// applyTSMAdd adds samples, taking * timestamps to be the time the leader appended the command, as for applyTSAdd.
+func (node *RaftNode) applyTSMAdd(cmd *commands.TSMAddCommand, now int64) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	results := make([]string, 0, len(cmd.Samples))
+	for _, sample := range cmd.Samples {
+		timestamp := sample.Timestamp
+		if sample.AutoTimestamp {
+			timestamp = now
+		}
+
+		ts, err := node.findTimeSeries(sample.Key)
+		if err == nil {
+			err = ts.Add(timestamp, sample.Value, "")
+		}
+
+		if err != nil {
+			results = append(results, err.Error())
+		} else {
+			results = append(results, strconv.FormatInt(timestamp, 10))
+		}
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+func (node *RaftNode) findTimeSeries(key string) (*datatypes.TimeSeries, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "timeseries":
+			ts := val.(*datatypes.TimeSeries)
+			return ts, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
+
+func newTimeSeries(retention int64, duplicatePolicy string) *datatypes.TimeSeries {
+	policy := datatypes.DuplicatePolicy(duplicatePolicy)
+	if policy == "" {
+		policy = defaultDuplicatePolicy
+	}
+	return datatypes.NewTimeSeries(retention, policy)
+}
+
+func formatSample(sample datatypes.Sample) string {
+	return strconv.FormatInt(sample.Timestamp, 10) + " " + commands.FormatFloat(sample.Value)
+}
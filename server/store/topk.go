@@ -0,0 +1,109 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+	"strconv"
+)
+
+func (node *RaftNode) TopKReserve(cmd *commands.TopKReserveCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// TopKAdd serves both TOPK.ADD and TOPK.INCRBY.
+func (node *RaftNode) TopKAdd(cmd *commands.TopKAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// TopKItems serves both TOPK.QUERY and TOPK.COUNT.
+func (node *RaftNode) TopKItems(cmd *commands.TopKItemsCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	tk, err := node.findTopK(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	results := make([]string, 0, len(cmd.Items))
+	for _, item := range cmd.Items {
+		if cmd.GetMessageType() == commands.TopKQuery {
+			results = append(results, strconv.FormatBool(tk.Query(item)))
+		} else {
+			results = append(results, strconv.FormatUint(tk.Count(item), 10))
+		}
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+// TopKList responds with the top k items from the highest count to the lowest, each followed by its count if asked for.
+func (node *RaftNode) TopKList(cmd *commands.TopKListCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	tk, err := node.findTopK(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	items := tk.List()
+	values := make([]string, 0, 2*len(items))
+	for _, ti := range items {
+		values = append(values, ti.Item)
+		if cmd.WithCount {
+			values = append(values, strconv.FormatUint(ti.Count, 10))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) applyTopKReserve(cmd *commands.TopKReserveCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if _, ok := node.m[cmd.Key]; ok {
+		return (&commands.ErrorResponse{Err: commands.ErrorKeyExists}).String()
+	}
+
+	width, depth, decay := cmd.Width, cmd.Depth, cmd.Decay
+	if width == 0 {
+		width, depth, decay = datatypes.DefaultTopKWidth, datatypes.DefaultTopKDepth, datatypes.DefaultTopKDecay
+	}
+	node.m[cmd.Key] = datatypes.NewTopK(cmd.K, width, depth, decay)
+	return (&commands.CountResponse{Count: 1}).String()
+}
+
+// applyTopKAdd responds with the item expelled from the top k by each increment, or nil if none was.
+func (node *RaftNode) applyTopKAdd(cmd *commands.TopKAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	tk, err := node.findTopK(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	results := make([]string, 0, len(cmd.Increments))
+	for _, incr := range cmd.Increments {
+		if expelled, ok := tk.IncrBy(incr.Item, incr.Increment); ok {
+			results = append(results, expelled)
+		} else {
+			results = append(results, "nil")
+		}
+	}
+	return (&commands.ListResponse{Values: results}).String()
+}
+
+func (node *RaftNode) findTopK(key string) (*datatypes.TopK, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "topk":
+			tk := val.(*datatypes.TopK)
+			return tk, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}
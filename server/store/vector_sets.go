@@ -0,0 +1,124 @@
+package store
+
+import (
+	"github.com/c16a/pouch/sdk/commands"
+	"github.com/c16a/pouch/server/datatypes"
+)
+
+func (node *RaftNode) VAdd(cmd *commands.VAddCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+func (node *RaftNode) VRem(cmd *commands.VRemCommand) string {
+	return node.respondAfterRaftCommit(cmd)
+}
+
+// VSim responds with the nearest elements, nearest first, each followed by its score if asked for.
+// Searching may build the graph of a large set, but that is derived state, so it is done as a read.
+func (node *RaftNode) VSim(cmd *commands.VSimCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	vs, err := node.findVectorSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	query := cmd.Vector
+	if query == nil {
+		e, ok := vs.Element(cmd.Element)
+		if !ok {
+			return (&commands.ErrorResponse{Err: commands.ErrorNotFound}).String()
+		}
+		query = e.Vector
+	}
+
+	ef := cmd.EF
+	if ef == 0 {
+		ef = datatypes.DefaultVectorEF
+	}
+	matches, err := vs.Search(query, cmd.Count, ef, cmd.Filter)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	values := make([]string, 0, 2*len(matches))
+	for _, m := range matches {
+		values = append(values, m.Name)
+		if cmd.WithScores {
+			values = append(values, commands.FormatFloat(m.Score))
+		}
+	}
+	return (&commands.ListResponse{Values: values}).String()
+}
+
+func (node *RaftNode) VCard(cmd *commands.VCardCommand) string {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	vs, err := node.findVectorSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	return (&commands.CountResponse{Count: vs.Len()}).String()
+}
+
+// applyVAdd responds with whether the element is new. A new set takes the dimension of its first vector.
+func (node *RaftNode) applyVAdd(cmd *commands.VAddCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	vs, err := node.findVectorSet(cmd.Key)
+	switch err {
+	case nil:
+		if cmd.Metric != "" && datatypes.VectorMetric(cmd.Metric) != vs.Metric() {
+			return (&commands.ErrorResponse{Err: datatypes.ErrMetricMismatch}).String()
+		}
+	case commands.ErrorNotFound:
+		metric := datatypes.MetricCosine
+		if cmd.Metric != "" {
+			metric = datatypes.VectorMetric(cmd.Metric)
+		}
+		vs = datatypes.NewVectorSet(metric, len(cmd.Vector))
+	default:
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	added, err := vs.Add(cmd.Element, cmd.Vector, cmd.Attributes)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+	node.m[cmd.Key] = vs
+	return (&commands.BooleanResponse{Value: added}).String()
+}
+
+// applyVRem deletes the set once its last element is removed.
+func (node *RaftNode) applyVRem(cmd *commands.VRemCommand) interface{} {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	vs, err := node.findVectorSet(cmd.Key)
+	if err != nil {
+		return (&commands.ErrorResponse{Err: err}).String()
+	}
+
+	removed := vs.Remove(cmd.Element)
+	if vs.Len() == 0 {
+		delete(node.m, cmd.Key)
+	}
+	return (&commands.BooleanResponse{Value: removed}).String()
+}
+
+func (node *RaftNode) findVectorSet(key string) (*datatypes.VectorSet, error) {
+	if val, ok := node.m[key]; ok {
+		switch val.GetName() {
+		case "vectorset":
+			vs := val.(*datatypes.VectorSet)
+			return vs, nil
+		default:
+			return nil, commands.ErrorInvalidDataType
+		}
+	} else {
+		return nil, commands.ErrorNotFound
+	}
+}